@@ -0,0 +1,208 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// fixedHeadSize is the length of a type definition with an eight byte
+// argument, as reserved for a back-patched length.
+const fixedHeadSize = 9
+
+// writeMajorsFixed composes a (major) type definition with an eight byte
+// argument into the Writer, independent of n's actual size.
+func writeMajorsFixed(m MajorType, n uint64, w io.Writer) error {
+	var buff [fixedHeadSize]byte
+
+	buff[0] = writeMajorType(m, 27)
+	for i := fixedHeadSize - 1; i > 0; i-- {
+		buff[i] = byte(n & 0xFF)
+		n = n >> 8
+	}
+
+	if wn, err := w.Write(buff[:]); err != nil {
+		return err
+	} else if wn != fixedHeadSize {
+		return fmt.Errorf("writeMajorsFixed: Wrote %d instead of %d bytes", wn, fixedHeadSize)
+	}
+	return nil
+}
+
+// lengthPatcher counts the elements of a container and writes its definite
+// length when being closed.
+type lengthPatcher struct {
+	major  MajorType
+	count  uint64
+	w      io.Writer
+	finish func(n uint64) error
+	closed bool
+}
+
+// newSeekPatcher reserves a fixed-width head at the WriteSeeker's current
+// position, which will be overwritten when closing.
+func newSeekPatcher(major MajorType, ws io.WriteSeeker) (*lengthPatcher, error) {
+	start, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeMajorsFixed(major, 0, ws); err != nil {
+		return nil, err
+	}
+
+	finish := func(n uint64) error {
+		end, err := ws.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+
+		if _, err := ws.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		if err := writeMajorsFixed(major, n, ws); err != nil {
+			return err
+		}
+
+		_, err = ws.Seek(end, io.SeekStart)
+		return err
+	}
+
+	return &lengthPatcher{major: major, w: ws, finish: finish}, nil
+}
+
+// newBufferedPatcher buffers all elements in memory and writes both the head
+// and the buffered elements into the Writer when closing. If minimize is set,
+// the head is written in its shortest form, otherwise in the fixed width.
+func newBufferedPatcher(major MajorType, w io.Writer, minimize bool) *lengthPatcher {
+	var buff bytes.Buffer
+
+	finish := func(n uint64) (err error) {
+		if minimize {
+			err = WriteMajors(major, n, w)
+		} else {
+			err = writeMajorsFixed(major, n, w)
+		}
+		if err != nil {
+			return
+		}
+
+		_, err = buff.WriteTo(w)
+		return
+	}
+
+	return &lengthPatcher{major: major, w: &buff, finish: finish}
+}
+
+func (lp *lengthPatcher) element(f func(w io.Writer) error) error {
+	if lp.closed {
+		return fmt.Errorf("lengthPatcher: Writing element to closed container")
+	}
+
+	if err := f(lp.w); err != nil {
+		return err
+	}
+	lp.count++
+	return nil
+}
+
+func (lp *lengthPatcher) close() error {
+	if lp.closed {
+		return fmt.Errorf("lengthPatcher: Container was already closed")
+	}
+	lp.closed = true
+
+	return lp.finish(lp.count)
+}
+
+// ArrayWriter writes an array of a definite length, which is only known after
+// its last element was written. Therefore, the length is patched when closing.
+type ArrayWriter struct {
+	lp *lengthPatcher
+}
+
+// NewArrayWriter creates an ArrayWriter, which reserves an array head with an
+// eight byte length at the WriteSeeker's current position. This head will be
+// overwritten when the ArrayWriter is closed.
+func NewArrayWriter(ws io.WriteSeeker) (*ArrayWriter, error) {
+	lp, err := newSeekPatcher(Array, ws)
+	if err != nil {
+		return nil, err
+	}
+	return &ArrayWriter{lp}, nil
+}
+
+// NewBufferedArrayWriter creates an ArrayWriter, which buffers its elements in
+// memory until the ArrayWriter is closed. Then the array is written into the
+// Writer. If minimize is set, the array's head will be in its shortest form.
+// Otherwise, an eight byte length is used, as with NewArrayWriter.
+func NewBufferedArrayWriter(w io.Writer, minimize bool) *ArrayWriter {
+	return &ArrayWriter{newBufferedPatcher(Array, w, minimize)}
+}
+
+// WriteElement writes exactly one array element through the function f.
+func (aw *ArrayWriter) WriteElement(f func(w io.Writer) error) error {
+	return aw.lp.element(f)
+}
+
+// Marshal writes a CborMarshaler as the next array element.
+func (aw *ArrayWriter) Marshal(data CborMarshaler) error {
+	return aw.lp.element(data.MarshalCbor)
+}
+
+// Len returns the amount of array elements written so far.
+func (aw *ArrayWriter) Len() uint64 {
+	return aw.lp.count
+}
+
+// Close patches the array's definite length. No further elements can be
+// written afterwards.
+func (aw *ArrayWriter) Close() error {
+	return aw.lp.close()
+}
+
+// MapWriter writes a map of a definite length, which is only known after its
+// last pair was written. Therefore, the length is patched when closing.
+type MapWriter struct {
+	lp *lengthPatcher
+}
+
+// NewMapWriter creates a MapWriter, which reserves a map head with an eight
+// byte length at the WriteSeeker's current position. This head will be
+// overwritten when the MapWriter is closed.
+func NewMapWriter(ws io.WriteSeeker) (*MapWriter, error) {
+	lp, err := newSeekPatcher(Map, ws)
+	if err != nil {
+		return nil, err
+	}
+	return &MapWriter{lp}, nil
+}
+
+// NewBufferedMapWriter creates a MapWriter, which buffers its pairs in memory
+// until the MapWriter is closed. Then the map is written into the Writer. If
+// minimize is set, the map's head will be in its shortest form. Otherwise, an
+// eight byte length is used, as with NewMapWriter.
+func NewBufferedMapWriter(w io.Writer, minimize bool) *MapWriter {
+	return &MapWriter{newBufferedPatcher(Map, w, minimize)}
+}
+
+// WritePair writes exactly one map pair through the key and value functions.
+func (mw *MapWriter) WritePair(key, value func(w io.Writer) error) error {
+	return mw.lp.element(func(w io.Writer) error {
+		if err := key(w); err != nil {
+			return err
+		}
+		return value(w)
+	})
+}
+
+// Len returns the amount of map pairs written so far.
+func (mw *MapWriter) Len() uint64 {
+	return mw.lp.count
+}
+
+// Close patches the map's definite length. No further pairs can be written
+// afterwards.
+func (mw *MapWriter) Close() error {
+	return mw.lp.close()
+}
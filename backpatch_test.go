@@ -0,0 +1,143 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestElements(aw *ArrayWriter, t *testing.T) {
+	for i := uint64(1); i <= 3; i++ {
+		if err := aw.WriteElement(func(w io.Writer) error { return WriteUInt(i, w) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := aw.Marshal(&embeddedTestItem{A: 4, B: 5}); err != nil {
+		t.Fatal(err)
+	}
+
+	if l := aw.Len(); l != 4 {
+		t.Fatalf("Length is %d instead of 4", l)
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestArrayWriterSeeker(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "array.cbor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// Some leading data to ensure the head is reserved at the current position.
+	if err := WriteUInt(23, f); err != nil {
+		t.Fatal(err)
+	}
+
+	aw, err := NewArrayWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestElements(aw, t)
+
+	// Following data must be appended after the array.
+	if err := WriteUInt(42, f); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0x17,
+		0x9B, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04,
+		0x01, 0x02, 0x03, 0x82, 0x04, 0x05,
+		0x18, 0x2A}
+	if !reflect.DeepEqual(data, expected) {
+		t.Fatalf("Serialized data mismatches: %x != %x", data, expected)
+	}
+}
+
+func TestArrayWriterBuffered(t *testing.T) {
+	tests := []struct {
+		minimize bool
+		data     []byte
+	}{
+		{true, []byte{0x84, 0x01, 0x02, 0x03, 0x82, 0x04, 0x05}},
+		{false, []byte{0x9B, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04,
+			0x01, 0x02, 0x03, 0x82, 0x04, 0x05}},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		aw := NewBufferedArrayWriter(&buff, test.minimize)
+
+		writeTestElements(aw, t)
+		if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.data)
+		}
+
+		if n, err := ReadArrayLength(&buff); err != nil {
+			t.Fatal(err)
+		} else if n != 4 {
+			t.Fatalf("Read length is %d instead of 4", n)
+		}
+	}
+}
+
+func TestMapWriterBuffered(t *testing.T) {
+	var buff bytes.Buffer
+	mw := NewBufferedMapWriter(&buff, true)
+
+	for i := uint64(0); i < 30; i++ {
+		err := mw.WritePair(
+			func(w io.Writer) error { return WriteUInt(i, w) },
+			func(w io.Writer) error { return WriteBoolean(i%2 == 0, w) })
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := ReadMapPairLength(&buff); err != nil {
+		t.Fatal(err)
+	} else if n != 30 {
+		t.Fatalf("Read length is %d instead of 30", n)
+	}
+
+	for i := uint64(0); i < 30; i++ {
+		if k, err := ReadUInt(&buff); err != nil {
+			t.Fatal(err)
+		} else if k != i {
+			t.Fatalf("Key %d is not %d", k, i)
+		}
+
+		if v, err := ReadBoolean(&buff); err != nil {
+			t.Fatal(err)
+		} else if v != (i%2 == 0) {
+			t.Fatalf("Value for key %d is %t", i, v)
+		}
+	}
+}
+
+func TestArrayWriterClosed(t *testing.T) {
+	aw := NewBufferedArrayWriter(io.Discard, true)
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := aw.WriteElement(func(w io.Writer) error { return WriteUInt(1, w) }); err == nil {
+		t.Fatal("Writing to a closed ArrayWriter did not error")
+	}
+	if err := aw.Close(); err == nil {
+		t.Fatal("Closing a closed ArrayWriter did not error")
+	}
+}
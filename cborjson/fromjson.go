@@ -0,0 +1,141 @@
+package cborjson
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dtn7/cboring"
+)
+
+// FromJSON converts the JSON values of the Reader into CBOR data items, written
+// to the Writer. Multiple JSON values, e.g., JSON Lines, become a CBOR
+// sequence. As encoding/json reads ahead, all values up to the Reader's end
+// are converted.
+//
+// Numbers without a fraction or an exponent become integers in their shortest
+// encoding, if they fit into 64 bits. Other numbers become double-precision
+// floating-point values or, if shortestFloat is set, the shortest
+// floating-point encoding preserving their value. As the lengths of JSON
+// arrays and objects are unknown while streaming, they become
+// indefinite-length arrays and maps.
+func FromJSON(r io.Reader, w io.Writer, shortestFloat bool) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	c := &fromJSON{dec: dec, enc: cboring.NewEncoder(w), shortestFloat: shortestFloat}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("FromJSON: %w", err)
+		}
+
+		if err := c.value(tok, 0); err != nil {
+			return fmt.Errorf("FromJSON: %w", err)
+		}
+	}
+}
+
+// fromJSON is the state of a FromJSON conversion.
+type fromJSON struct {
+	dec           *json.Decoder
+	enc           *cboring.Encoder
+	shortestFloat bool
+}
+
+// next reads the next token within a JSON value.
+func (c *fromJSON) next() (json.Token, error) {
+	tok, err := c.dec.Token()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
+// value converts the JSON value starting with the token.
+func (c *fromJSON) value(tok json.Token, depth int) error {
+	if depth > cboring.MaxNestingDepth {
+		return fmt.Errorf("Exceeding the maximum nesting depth of %d", cboring.MaxNestingDepth)
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			if err := c.enc.WriteIndefiniteArray(); err != nil {
+				return err
+			}
+		} else if err := c.enc.WriteIndefiniteMap(); err != nil {
+			return err
+		}
+
+		// Object keys are returned as strings by the json.Decoder.
+		for c.dec.More() {
+			tok, err := c.next()
+			if err != nil {
+				return err
+			} else if err := c.value(tok, depth+1); err != nil {
+				return err
+			}
+		}
+
+		// Consume the closing delimiter.
+		if _, err := c.next(); err != nil {
+			return err
+		}
+		return c.enc.End()
+
+	case json.Number:
+		return c.number(string(v))
+	case string:
+		return c.enc.WriteTextString(v)
+	case bool:
+		return c.enc.WriteBoolean(v)
+	case nil:
+		return c.enc.WriteNull()
+	default:
+		return fmt.Errorf("Unexpected JSON token %v", tok)
+	}
+}
+
+// number converts a JSON number into an integer, if possible, or into a
+// floating-point value.
+func (c *fromJSON) number(s string) error {
+	if !strings.ContainsAny(s, ".eE") {
+		if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return c.enc.WriteUInt(n)
+		} else if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return c.enc.WriteInt(n)
+		}
+
+		// Negative integers down to -2^64 fit into a negative integer.
+		if n, ok := new(big.Int).SetString(s, 10); ok && n.Sign() < 0 {
+			n.Neg(n).Sub(n, big.NewInt(1))
+			if n.IsUint64() {
+				return c.enc.WriteNInt(n.Uint64())
+			}
+		}
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && !math.IsInf(f, 0) {
+		return err
+	}
+	return c.float(f)
+}
+
+// float writes a floating-point value, optionally in its shortest encoding.
+func (c *fromJSON) float(f float64) error {
+	if !c.shortestFloat || float64(float32(f)) != f {
+		return c.enc.WriteFloat64(f)
+	}
+	if _, ok := cboring.Float32ToHalf(float32(f)); ok {
+		return c.enc.WriteFloat16(float32(f))
+	}
+	return c.enc.WriteFloat32(float32(f))
+}
@@ -0,0 +1,111 @@
+package cborjson
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFromJSON(t *testing.T) {
+	tests := []struct {
+		json          string
+		shortestFloat bool
+		expected      []byte
+	}{
+		// Integers in their shortest encoding
+		{"0", false, []byte{0x00}},
+		{"24", false, []byte{0x18, 0x18}},
+		{"18446744073709551615", false, []byte{0x1B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{"-100", false, []byte{0x38, 0x63}},
+		{"-18446744073709551616", false, []byte{0x3B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		// Integers exceeding 64 bits become floating-point values
+		{"18446744073709551616", false, []byte{0xFB, 0x43, 0xF0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"-18446744073709551617", true, []byte{0xFA, 0xDF, 0x80, 0x00, 0x00}},
+		// Floating-point values
+		{"1.5", false, []byte{0xFB, 0x3F, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"0.0", false, []byte{0xFB, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"4.9e-324", false, []byte{0xFB, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}},
+		{"0.0", true, []byte{0xF9, 0x00, 0x00}},
+		{"1.5", true, []byte{0xF9, 0x3E, 0x00}},
+		{"1e0", true, []byte{0xF9, 0x3C, 0x00}},
+		{"-0.0", true, []byte{0xF9, 0x80, 0x00}},
+		{"5.960464477539063e-8", true, []byte{0xF9, 0x00, 0x01}},
+		{"100000.0", true, []byte{0xFA, 0x47, 0xC3, 0x50, 0x00}},
+		{"1.1", true, []byte{0xFB, 0x3F, 0xF1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9A}},
+		{"1e400", false, []byte{0xFB, 0x7F, 0xF0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		// Other values
+		{`"aü"`, false, []byte{0x63, 0x61, 0xC3, 0xBC}},
+		{"true", false, []byte{0xF5}},
+		{"null", false, []byte{0xF6}},
+		// Arrays and objects
+		{"[]", false, []byte{0x9F, 0xFF}},
+		{"[1, [2]]", false, []byte{0x9F, 0x01, 0x9F, 0x02, 0xFF, 0xFF}},
+		{`{"a": 1, "b": {}}`, false, []byte{0xBF, 0x61, 0x61, 0x01, 0x61, 0x62, 0xBF, 0xFF, 0xFF}},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		if err := FromJSON(strings.NewReader(test.json), &buff, test.shortestFloat); err != nil {
+			t.Fatalf("Converting %s errored: %v", test.json, err)
+		} else if !bytes.Equal(buff.Bytes(), test.expected) {
+			t.Fatalf("Converting %s resulted in %x, expected %x", test.json, buff.Bytes(), test.expected)
+		}
+	}
+}
+
+func TestFromJSONSequence(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected []byte
+	}{
+		{"", nil},
+		{"1 [2]\n", []byte{0x01, 0x9F, 0x02, 0xFF}},
+		{"{}\n{}\n", []byte{0xBF, 0xFF, 0xBF, 0xFF}},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		if err := FromJSON(strings.NewReader(test.json), &buff, false); err != nil {
+			t.Fatalf("Converting %q errored: %v", test.json, err)
+		} else if !bytes.Equal(buff.Bytes(), test.expected) {
+			t.Fatalf("Converting %q resulted in %x, expected %x", test.json, buff.Bytes(), test.expected)
+		}
+	}
+}
+
+func TestFromJSONMalformed(t *testing.T) {
+	tests := []string{"[1", "[1,", `{"a":`, "]", "[1 2]", "tru", "1 }"}
+
+	for _, test := range tests {
+		if err := FromJSON(strings.NewReader(test), io.Discard, false); err == nil {
+			t.Fatalf("Converting %s did not error", test)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		json     string
+		expected string
+	}{
+		{`[1,-2,1.5,"a",true,null,[]]`, `[1,-2,1.5,"a",true,null,[]]`},
+		{`{"a":{"b":[18446744073709551615,-18446744073709551616]}}`, `{"a":{"b":[18446744073709551615,-18446744073709551616]}}`},
+		{`[0.5,1e-300]`, `[0.5,1e-300]`},
+		// Zero floating-point values must not become simple values, i.e., null.
+		{`[0.0,-0.0,5e-324]`, `[0,-0,5e-324]`},
+	}
+
+	for _, test := range tests {
+		for _, shortestFloat := range []bool{false, true} {
+			var data, out bytes.Buffer
+			if err := FromJSON(strings.NewReader(test.json), &data, shortestFloat); err != nil {
+				t.Fatal(err)
+			} else if err := ToJSON(&data, &out); err != nil {
+				t.Fatal(err)
+			} else if out.String() != test.expected {
+				t.Fatalf("Round trip of %s resulted in %s, expected %s", test.json, out.String(), test.expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Package cborjson converts between CBOR and JSON, following RFC 8949,
+// sections 6.1 and 6.2, based on the cboring package.
+//
+// Both directions work token by token, cboring.Decoder's tokens for CBOR and
+// encoding/json's tokens for JSON, without building a tree of the whole data
+// item in memory.
+package cborjson
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/dtn7/cboring"
+)
+
+// Tags of RFC 8949, sections 3.4.3 and 3.4.5.2, affecting the conversion of
+// byte strings into JSON.
+const (
+	TagUnsignedBignum uint64 = 2
+	TagNegativeBignum uint64 = 3
+	TagBase64URL      uint64 = 21
+	TagBase64         uint64 = 22
+	TagBase16         uint64 = 23
+)
+
+// ToJSON converts the next CBOR data item of the Reader into JSON, written to
+// the Writer. If the Reader is at its end, io.EOF is returned.
+//
+// Integers and floating-point values become numbers, where NaN and infinity
+// become null, as do undefined and other simple values. Byte strings become
+// base64url strings without padding, unless their data item is within an
+// expected-conversion tag 21 to 23, selecting base64url, base64 with padding
+// or base16. A bignum becomes its byte string's base64url string, with a "~"
+// prefix for a negative one. Other tags are ignored. Map keys which are not
+// text strings become strings of their JSON representation, e.g., "1" for the
+// key 1.
+func ToJSON(r io.Reader, w io.Writer) error {
+	dec := cboring.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	c := &toJSON{dec: dec, w: w}
+	if err := c.value(tok, TagBase64URL, 0); err != nil {
+		return fmt.Errorf("ToJSON: %w", err)
+	}
+	return nil
+}
+
+// toJSON is the state of a ToJSON conversion.
+type toJSON struct {
+	dec *cboring.Decoder
+	w   io.Writer
+	buf []byte
+}
+
+// write writes and resets the buffer.
+func (c *toJSON) write() error {
+	_, err := c.w.Write(c.buf)
+	c.buf = c.buf[:0]
+	return err
+}
+
+// next reads the next token within a data item.
+func (c *toJSON) next() (cboring.Token, error) {
+	tok, err := c.dec.Token()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return tok, err
+}
+
+// value converts the data item starting with the token. Byte strings are
+// encoded as selected by the expected-conversion tag.
+func (c *toJSON) value(tok cboring.Token, conversion uint64, depth int) error {
+	if depth > cboring.MaxNestingDepth {
+		return fmt.Errorf("Exceeding the maximum nesting depth of %d", cboring.MaxNestingDepth)
+	}
+
+	switch v := tok.(type) {
+	case cboring.ArrayStart:
+		return c.array(conversion, depth)
+
+	case cboring.MapStart:
+		return c.object(conversion, depth)
+
+	case cboring.TagNumber:
+		return c.tag(uint64(v), conversion, depth)
+
+	case uint64:
+		c.buf = strconv.AppendUint(c.buf, v, 10)
+	case int64:
+		c.buf = strconv.AppendInt(c.buf, v, 10)
+	case cboring.NegativeInt:
+		n := new(big.Int).SetUint64(uint64(v))
+		c.buf = n.Neg(n.Add(n, big.NewInt(1))).Append(c.buf, 10)
+
+	case float32:
+		c.buf = appendFloat(c.buf, float64(v), 32)
+	case float64:
+		c.buf = appendFloat(c.buf, v, 64)
+
+	case bool:
+		c.buf = strconv.AppendBool(c.buf, v)
+
+	case string:
+		c.buf = appendString(c.buf, v)
+	case []byte:
+		c.buf = appendString(c.buf, encodeBytes(v, conversion))
+
+	default:
+		// null, undefined and other simple values
+		c.buf = append(c.buf, "null"...)
+	}
+	return c.write()
+}
+
+func (c *toJSON) array(conversion uint64, depth int) error {
+	c.buf = append(c.buf, '[')
+	for first := true; ; first = false {
+		tok, err := c.next()
+		if err != nil {
+			return err
+		} else if _, ok := tok.(cboring.ArrayEnd); ok {
+			c.buf = append(c.buf, ']')
+			return c.write()
+		}
+
+		if !first {
+			c.buf = append(c.buf, ',')
+		}
+		if err := c.value(tok, conversion, depth+1); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *toJSON) object(conversion uint64, depth int) error {
+	c.buf = append(c.buf, '{')
+	for first := true; ; first = false {
+		tok, err := c.next()
+		if err != nil {
+			return err
+		} else if _, ok := tok.(cboring.MapEnd); ok {
+			c.buf = append(c.buf, '}')
+			return c.write()
+		}
+
+		if !first {
+			c.buf = append(c.buf, ',')
+		}
+		if err := c.key(tok, conversion, depth); err != nil {
+			return err
+		}
+		c.buf = append(c.buf, ':')
+
+		if tok, err = c.next(); err != nil {
+			return err
+		} else if err := c.value(tok, conversion, depth+1); err != nil {
+			return err
+		}
+	}
+}
+
+// key converts a map's key into a JSON string. A key which is not a text
+// string is converted into JSON first, which becomes the string, unless it
+// already is one.
+func (c *toJSON) key(tok cboring.Token, conversion uint64, depth int) error {
+	if s, ok := tok.(string); ok {
+		c.buf = appendString(c.buf, s)
+		return nil
+	}
+
+	var buff bytes.Buffer
+	nested := &toJSON{dec: c.dec, w: &buff}
+	if err := nested.value(tok, conversion, depth+1); err != nil {
+		return err
+	}
+
+	// Byte strings or bignums are already converted into JSON strings.
+	if bytes.HasPrefix(buff.Bytes(), []byte(`"`)) {
+		c.buf = append(c.buf, buff.Bytes()...)
+	} else {
+		c.buf = appendString(c.buf, buff.String())
+	}
+	return nil
+}
+
+// tag converts a tag's content. Bignums become strings and expected-conversion
+// tags select the byte strings' encoding within their content.
+func (c *toJSON) tag(n, conversion uint64, depth int) error {
+	tok, err := c.next()
+	if err != nil {
+		return err
+	}
+
+	if data, ok := tok.([]byte); ok && (n == TagUnsignedBignum || n == TagNegativeBignum) {
+		s := base64.RawURLEncoding.EncodeToString(data)
+		if n == TagNegativeBignum {
+			s = "~" + s
+		}
+		c.buf = appendString(c.buf, s)
+		return c.write()
+	}
+
+	if n == TagBase64URL || n == TagBase64 || n == TagBase16 {
+		conversion = n
+	}
+	return c.value(tok, conversion, depth+1)
+}
+
+// encodeBytes encodes a byte string as selected by the expected-conversion tag.
+func encodeBytes(data []byte, conversion uint64) string {
+	switch conversion {
+	case TagBase64:
+		return base64.StdEncoding.EncodeToString(data)
+	case TagBase16:
+		return hex.EncodeToString(data)
+	default:
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+}
+
+// appendFloat appends a floating-point number, where NaN and infinity, which
+// cannot be represented in JSON, become null.
+func appendFloat(buf []byte, f float64, bits int) []byte {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return append(buf, "null"...)
+	}
+	return strconv.AppendFloat(buf, f, 'g', -1, bits)
+}
+
+// appendString appends a JSON string.
+func appendString(buf []byte, s string) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return append(buf, bytes.TrimSuffix(b.Bytes(), []byte("\n"))...)
+}
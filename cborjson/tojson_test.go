@@ -0,0 +1,99 @@
+package cborjson
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestToJSON(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		// Integers
+		{[]byte{0x00}, "0"},
+		{[]byte{0x1B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "18446744073709551615"},
+		{[]byte{0x38, 0x63}, "-100"},
+		{[]byte{0x3B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "-18446744073709551616"},
+		// Floating-point values
+		{[]byte{0xF9, 0x3E, 0x00}, "1.5"},
+		{[]byte{0xFA, 0x47, 0xC3, 0x50, 0x00}, "100000"},
+		{[]byte{0xFB, 0x3F, 0xF1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9A}, "1.1"},
+		{[]byte{0xF9, 0x7E, 0x00}, "null"},
+		{[]byte{0xFA, 0x7F, 0x80, 0x00, 0x00}, "null"},
+		// Simple values
+		{[]byte{0xF4}, "false"},
+		{[]byte{0xF5}, "true"},
+		{[]byte{0xF6}, "null"},
+		{[]byte{0xF7}, "null"},
+		// Strings
+		{[]byte{0x62, 0x3C, 0x22}, `"<\""`},
+		{[]byte{0x7F, 0x61, 0x61, 0x61, 0x62, 0xFF}, `"ab"`},
+		{[]byte{0x43, 0xFB, 0xFF, 0x00}, `"-_8A"`},
+		// Expected conversions
+		{[]byte{0xD5, 0x43, 0xFB, 0xFF, 0x00}, `"-_8A"`},
+		{[]byte{0xD6, 0x43, 0xFB, 0xFF, 0x00}, `"+/8A"`},
+		{[]byte{0xD6, 0x42, 0xFB, 0xFF}, `"+/8="`},
+		{[]byte{0xD7, 0x82, 0x41, 0x01, 0xD6, 0x41, 0x02}, `["01","Ag=="]`},
+		// Bignums and other tags
+		{[]byte{0xC2, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, `"AQAAAAAAAAAA"`},
+		{[]byte{0xC3, 0x49, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, `"~AQAAAAAAAAAA"`},
+		{[]byte{0xC1, 0x1A, 0x51, 0x4B, 0x67, 0xB0}, "1363896240"},
+		// Arrays and maps
+		{[]byte{0x80}, "[]"},
+		{[]byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x9F, 0xFF}, "[1,[2,3],[]]"},
+		{[]byte{0xA2, 0x61, 0x61, 0x01, 0x61, 0x62, 0x82, 0x02, 0x03}, `{"a":1,"b":[2,3]}`},
+		{[]byte{0xBF, 0x61, 0x61, 0xF5, 0xFF}, `{"a":true}`},
+		// Map keys which are not text strings
+		{[]byte{0xA2, 0x01, 0x61, 0x61, 0x20, 0xF6}, `{"1":"a","-1":null}`},
+		{[]byte{0xA1, 0x42, 0x01, 0x02, 0x00}, `{"AQI":0}`},
+		{[]byte{0xA1, 0x82, 0x01, 0x61, 0x61, 0x00}, `{"[1,\"a\"]":0}`},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		if err := ToJSON(bytes.NewReader(test.data), &buff); err != nil {
+			t.Fatalf("Converting %x errored: %v", test.data, err)
+		} else if buff.String() != test.expected {
+			t.Fatalf("Converting %x resulted in %s, expected %s", test.data, buff.String(), test.expected)
+		}
+	}
+}
+
+func TestToJSONSequence(t *testing.T) {
+	r := bytes.NewReader([]byte{0x01, 0x81, 0x02})
+
+	var buff bytes.Buffer
+	for _, expected := range []string{"1", "[2]"} {
+		buff.Reset()
+		if err := ToJSON(r, &buff); err != nil {
+			t.Fatal(err)
+		} else if buff.String() != expected {
+			t.Fatalf("Expected %s, got %s", expected, buff.String())
+		}
+	}
+
+	if err := ToJSON(r, &buff); err != io.EOF {
+		t.Fatalf("Expected io.EOF at the end, got %v", err)
+	}
+}
+
+func TestToJSONMalformed(t *testing.T) {
+	tests := [][]byte{
+		{0x82, 0x01},
+		{0xA1, 0x01},
+		{0xC2},
+		{0x62, 0x61},
+	}
+
+	for _, data := range tests {
+		err := ToJSON(bytes.NewReader(data), io.Discard)
+		if err == nil || err == io.EOF {
+			t.Fatalf("Converting %x did not error: %v", data, err)
+		} else if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("Converting %x errored unexpectedly: %v", data, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+// Package cddl parses Concise Data Definition Language (CDDL) specifications,
+// RFC 8610, and validates CBOR data items against their rules.
+//
+// The supported subset includes type and group rules, their extensions by /=
+// and //=, type and group choices, occurrence indicators, member keys, ranges,
+// tags, major types, unwrapping, enumerations and the control operators .size,
+// .bits, .cbor, .cborseq, .regexp, .lt, .le, .gt, .ge, .eq, .ne and .default.
+// The standard prelude is always available. Generic rules and sockets are not
+// supported.
+package cddl
+
+import (
+	"fmt"
+	"math"
+)
+
+// Type is a node of a type expression, one of *Choice, *Ref, *Literal,
+// *Range, *Control, *Array, *Map, *TagType, *MajorType, *Unwrap, *Enum or
+// *GroupType.
+type Type interface {
+	fmt.Stringer
+	isType()
+}
+
+// Choice of types, written as "a / b".
+type Choice struct {
+	Options []Type
+}
+
+// Ref references a rule by its name.
+type Ref struct {
+	Name string
+}
+
+// Literal is a value, one of uint64, int64, float64, string for a text string
+// or []byte for a byte string.
+type Literal struct {
+	Value any
+}
+
+// Range of numbers, written as "min..max" or "min...max" for an exclusive
+// maximum. Both Min and Max resolve to a Literal.
+type Range struct {
+	Min, Max  Type
+	Exclusive bool
+}
+
+// Control is a control operator, e.g., "bstr .size 16".
+type Control struct {
+	Op         string
+	Target     Type
+	Controller Type
+}
+
+// Array is an array of a group's entries, written as "[group]".
+type Array struct {
+	Group *Group
+}
+
+// Map is a map of a group's entries, written as "{group}".
+type Map struct {
+	Group *Group
+}
+
+// TagType is a tagged data item, written as "#6.n(type)". Number is nil for
+// any tag.
+type TagType struct {
+	Number  *uint64
+	Content Type
+}
+
+// MajorType is a data item of a major type, written as "#n" or "#n.arg". Major
+// is -1 for any data item, written as "#". Argument is nil for any argument.
+type MajorType struct {
+	Major    int
+	Argument *uint64
+}
+
+// Unwrap removes the outer array, map or tag of a rule, written as "~name".
+type Unwrap struct {
+	Name string
+}
+
+// Enum is a choice of a group's values, written as "&group" or "&(group)".
+type Enum struct {
+	Group *Group
+}
+
+// GroupType is a parenthesized group within a group, written as "(group)".
+type GroupType struct {
+	Group *Group
+}
+
+func (*Choice) isType()    {}
+func (*Ref) isType()       {}
+func (*Literal) isType()   {}
+func (*Range) isType()     {}
+func (*Control) isType()   {}
+func (*Array) isType()     {}
+func (*Map) isType()       {}
+func (*TagType) isType()   {}
+func (*MajorType) isType() {}
+func (*Unwrap) isType()    {}
+func (*Enum) isType()      {}
+func (*GroupType) isType() {}
+
+// Group is a choice of sequences of entries, written as "a, b // c".
+type Group struct {
+	Choices [][]*Entry
+}
+
+// Unbounded is an Entry's Max without an upper limit.
+const Unbounded = math.MaxUint64
+
+// Entry of a group, optionally with an occurrence indicator and a member key.
+type Entry struct {
+	// Min and Max occurrences, both 1 by default.
+	Min, Max uint64
+	// Key is the member key's type, or nil. A bareword key is a text Literal.
+	Key Type
+	// Cut is set for keys written with ":" or "^ =>". Within a map, a
+	// matching key with a mismatching value is an error.
+	Cut   bool
+	Value Type
+}
+
+// Rule is a named type or group.
+type Rule struct {
+	Name string
+	// Type is set for a type rule and Group for a group rule.
+	Type  Type
+	Group *Group
+	// Line of the rule's first definition, zero for the prelude.
+	Line int
+}
+
+// Schema is a parsed CDDL specification together with the standard prelude.
+type Schema struct {
+	Rules map[string]*Rule
+	// Names of the specification's rules, in their order of definition and
+	// without the prelude's rules.
+	Names []string
+}
+
+func (c *Choice) String() string {
+	s := ""
+	for i, option := range c.Options {
+		if i > 0 {
+			s += " / "
+		}
+		s += option.String()
+	}
+	return s
+}
+
+func (r *Ref) String() string { return r.Name }
+
+func (l *Literal) String() string {
+	switch v := l.Value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("h'%x'", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func (r *Range) String() string {
+	op := ".."
+	if r.Exclusive {
+		op = "..."
+	}
+	return r.Min.String() + op + r.Max.String()
+}
+
+func (c *Control) String() string {
+	return fmt.Sprintf("%v .%s %v", c.Target, c.Op, c.Controller)
+}
+
+func (a *Array) String() string { return "[" + a.Group.String() + "]" }
+
+func (m *Map) String() string { return "{" + m.Group.String() + "}" }
+
+func (t *TagType) String() string {
+	if t.Number == nil {
+		return fmt.Sprintf("#6(%v)", t.Content)
+	}
+	return fmt.Sprintf("#6.%d(%v)", *t.Number, t.Content)
+}
+
+func (m *MajorType) String() string {
+	switch {
+	case m.Major < 0:
+		return "#"
+	case m.Argument == nil:
+		return fmt.Sprintf("#%d", m.Major)
+	default:
+		return fmt.Sprintf("#%d.%d", m.Major, *m.Argument)
+	}
+}
+
+func (u *Unwrap) String() string { return "~" + u.Name }
+
+func (e *Enum) String() string { return "&(" + e.Group.String() + ")" }
+
+func (g *GroupType) String() string { return "(" + g.Group.String() + ")" }
+
+func (g *Group) String() string {
+	s := ""
+	for i, choice := range g.Choices {
+		if i > 0 {
+			s += " // "
+		}
+		for j, entry := range choice {
+			if j > 0 {
+				s += ", "
+			}
+			s += entry.String()
+		}
+	}
+	return s
+}
+
+func (e *Entry) String() string {
+	s := ""
+	switch {
+	case e.Min == 1 && e.Max == 1:
+	case e.Min == 0 && e.Max == 1:
+		s = "? "
+	case e.Min == 0 && e.Max == Unbounded:
+		s = "* "
+	case e.Min == 1 && e.Max == Unbounded:
+		s = "+ "
+	case e.Max == Unbounded:
+		s = fmt.Sprintf("%d* ", e.Min)
+	default:
+		s = fmt.Sprintf("%d*%d ", e.Min, e.Max)
+	}
+
+	if e.Key != nil {
+		if l, ok := e.Key.(*Literal); ok && e.Cut && isBareword(l.Value) {
+			s += l.Value.(string) + ": "
+		} else if _, ok := e.Key.(*Literal); ok && e.Cut {
+			s += e.Key.String() + ": "
+		} else if e.Cut {
+			s += e.Key.String() + " ^ => "
+		} else {
+			s += e.Key.String() + " => "
+		}
+	}
+	return s + e.Value.String()
+}
+
+// isBareword checks if a member key can be written without quotes.
+func isBareword(value any) bool {
+	s, ok := value.(string)
+	if !ok || s == "" || !isIdentStart(s[0]) {
+		return false
+	}
+	tokens, err := lex(s)
+	return err == nil && len(tokens) == 2 && tokens[0].kind == tIdent && tokens[0].text == s
+}
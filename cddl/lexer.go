@@ -0,0 +1,299 @@
+package cddl
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tEOF tokenKind = iota
+	tIdent
+	tNumber
+	tText
+	tBytes
+	tPunct
+	// tCtl is a control operator, e.g., ".size", with the text "size".
+	tCtl
+	// tHash is a major type, e.g., "#6.24", with the text "6.24".
+	tHash
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// value of a tNumber, tText or tBytes token.
+	value any
+	line  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tEOF:
+		return "end of input"
+	case tCtl:
+		return "." + t.text
+	case tHash:
+		return "#" + t.text
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// punctuation ordered by descending length for the longest match.
+var punctuation = []string{
+	"...", "//=",
+	"..", "/=", "//", "=>",
+	"=", "/", "(", ")", "{", "}", "[", "]", "<", ">", ",", ":", "^", "?", "*", "+", "~", "&",
+}
+
+// lex splits a CDDL specification into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	line := 1
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		case c == ';':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		tok := token{line: line}
+		start := i
+
+		switch {
+		case isIdentStart(c):
+			// A text or byte string with a prefix, e.g., h'00'.
+			if j := i + 1; (c == 'h' && j < len(src) && src[j] == '\'') ||
+				(strings.HasPrefix(src[i:], "b64'")) {
+				prefix := "h"
+				if c == 'b' {
+					prefix, j = "b64", i+3
+				}
+				end := strings.IndexByte(src[j+1:], '\'')
+				if end < 0 {
+					return nil, fmt.Errorf("line %d: Unterminated byte string", line)
+				}
+
+				content := src[j+1 : j+1+end]
+				value, err := decodeBytes(prefix, content)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+
+				tok.kind, tok.value = tBytes, value
+				line += strings.Count(content, "\n")
+				i = j + 2 + end
+				break
+			}
+
+			i++
+			for i < len(src) {
+				if isIdentStart(src[i]) || isDigit(src[i]) {
+					i++
+					continue
+				}
+
+				// "-" and "." are only allowed within an identifier.
+				j := i
+				for j < len(src) && (src[j] == '-' || src[j] == '.') {
+					j++
+				}
+				if j > i && j < len(src) && (isIdentStart(src[j]) || isDigit(src[j])) {
+					i = j
+					continue
+				}
+				break
+			}
+			tok.kind = tIdent
+
+		case isDigit(c) || (c == '-' && i+1 < len(src) && isDigit(src[i+1])):
+			end, value, err := lexNumber(src, i)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			tok.kind, tok.value = tNumber, value
+			i = end
+
+		case c == '"':
+			j := i + 1
+			for j < len(src) && src[j] != '"' {
+				if src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(src) {
+				return nil, fmt.Errorf("line %d: Unterminated text string", line)
+			}
+
+			value, err := strconv.Unquote(src[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: Invalid text string %s", line, src[i:j+1])
+			}
+			tok.kind, tok.value = tText, value
+			i = j + 1
+
+		case c == '\'':
+			end := strings.IndexByte(src[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: Unterminated byte string", line)
+			}
+			tok.kind, tok.value = tBytes, []byte(src[i+1:i+1+end])
+			i += end + 2
+
+		case c == '#':
+			i++
+			for i < len(src) && (isDigit(src[i]) || (src[i] == '.' && i+1 < len(src) && isDigit(src[i+1]))) {
+				i++
+			}
+			tok.kind = tHash
+			tok.text = src[start+1 : i]
+			tokens = append(tokens, tok)
+			continue
+
+		case c == '.' && i+1 < len(src) && isIdentStart(src[i+1]):
+			i++
+			for i < len(src) && (isIdentStart(src[i]) || isDigit(src[i]) || src[i] == '-') {
+				i++
+			}
+			tok.kind = tCtl
+			tok.text = src[start+1 : i]
+			tokens = append(tokens, tok)
+			continue
+
+		default:
+			for _, p := range punctuation {
+				if strings.HasPrefix(src[i:], p) {
+					tok.kind = tPunct
+					i += len(p)
+					break
+				}
+			}
+			if tok.kind != tPunct {
+				return nil, fmt.Errorf("line %d: Unexpected character %q", line, c)
+			}
+		}
+
+		tok.text = src[start:i]
+		tokens = append(tokens, tok)
+	}
+
+	return append(tokens, token{kind: tEOF, line: line}), nil
+}
+
+func isIdentStart(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '@' || c == '_' || c == '$'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// lexNumber reads an integer or floating-point number starting at i.
+func lexNumber(src string, i int) (end int, value any, err error) {
+	start := i
+	if src[i] == '-' {
+		i++
+	}
+
+	if strings.HasPrefix(src[i:], "0x") || strings.HasPrefix(src[i:], "0b") {
+		base := 16
+		if src[i+1] == 'b' {
+			base = 2
+		}
+		i += 2
+		digits := i
+		for i < len(src) && strings.IndexByte("0123456789abcdefABCDEF", src[i]) >= 0 {
+			i++
+		}
+
+		n, parseErr := strconv.ParseUint(src[digits:i], base, 64)
+		if parseErr != nil {
+			return 0, nil, fmt.Errorf("Invalid number %s", src[start:i])
+		} else if src[start] == '-' {
+			return i, -int64(n), nil
+		}
+		return i, n, nil
+	}
+
+	isFloat := false
+	for i < len(src) && isDigit(src[i]) {
+		i++
+	}
+	if i+1 < len(src) && src[i] == '.' && isDigit(src[i+1]) {
+		isFloat = true
+		i++
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		isFloat = true
+		i++
+		if i < len(src) && (src[i] == '+' || src[i] == '-') {
+			i++
+		}
+		for i < len(src) && isDigit(src[i]) {
+			i++
+		}
+	}
+
+	text := src[start:i]
+	switch {
+	case isFloat:
+		value, err = strconv.ParseFloat(text, 64)
+	case text[0] == '-':
+		value, err = strconv.ParseInt(text, 10, 64)
+	default:
+		value, err = strconv.ParseUint(text, 10, 64)
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("Invalid number %s", text)
+	}
+	return i, value, nil
+}
+
+// decodeBytes decodes the content of a prefixed byte string, ignoring
+// whitespace.
+func decodeBytes(prefix, content string) ([]byte, error) {
+	content = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' {
+			return -1
+		}
+		return r
+	}, content)
+
+	if prefix == "h" {
+		value, err := hex.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid hex byte string %q", content)
+		}
+		return value, nil
+	}
+
+	content = strings.TrimRight(content, "=")
+	value, err := base64.RawURLEncoding.DecodeString(content)
+	if err != nil {
+		value, err = base64.RawStdEncoding.DecodeString(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Invalid base64 byte string %q", content)
+	}
+	return value, nil
+}
@@ -0,0 +1,66 @@
+package cddl
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected []string
+	}{
+		{"a = uint ; comment\n", []string{"a", "=", "uint"}},
+		{"encoded-cbor = #6.24(bstr)", []string{"encoded-cbor", "=", "#6.24", "(", "bstr", ")"}},
+		{"x = 0..10 / -1...0x10", []string{"x", "=", "0", "..", "10", "/", "-1", "...", "16"}},
+		{"f = 1.5e3", []string{"f", "=", "1500"}},
+		{"b = h'0a 0b' / b64'AQI' / 'ab'", []string{"b", "=", "[10 11]", "/", "[1 2]", "/", "[97 98]"}},
+		{`t = "a\"b" .size 3`, []string{"t", "=", `a"b`, ".size", "3"}},
+		{"g //= (? a: int, * b => c)", []string{"g", "//=", "(", "?", "a", ":", "int", ",", "*", "b", "=>", "c", ")"}},
+		{"m = { ^ 1 => 2 }", []string{"m", "=", "{", "^", "1", "=>", "2", "}"}},
+	}
+
+	for _, test := range tests {
+		tokens, err := lex(test.src)
+		if err != nil {
+			t.Fatalf("Lexing %q errored: %v", test.src, err)
+		}
+
+		var texts []string
+		for _, tok := range tokens {
+			switch tok.kind {
+			case tEOF:
+			case tNumber, tText, tBytes:
+				texts = append(texts, fmt.Sprintf("%v", tok.value))
+			default:
+				texts = append(texts, tok.String())
+			}
+		}
+		for i, text := range texts {
+			if len(text) > 1 && text[0] == '"' {
+				texts[i] = text[1 : len(text)-1]
+			}
+		}
+
+		if !reflect.DeepEqual(texts, test.expected) {
+			t.Fatalf("Lexing %q resulted in %q instead of %q", test.src, texts, test.expected)
+		}
+	}
+}
+
+func TestLexError(t *testing.T) {
+	tests := []string{
+		"a = 'unterminated",
+		`a = "unterminated`,
+		"a = h'0g'",
+		"a = 0x",
+		"a = %",
+	}
+
+	for _, test := range tests {
+		if _, err := lex(test); err == nil {
+			t.Fatalf("Lexing %q did not error", test)
+		}
+	}
+}
@@ -0,0 +1,603 @@
+package cddl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parser is a recursive descent parser for CDDL's grammar, RFC 8610,
+// appendix B.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// peekAt returns the token n positions ahead.
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// isPunct checks if the next token is the punctuation.
+func (p *parser) isPunct(text string) bool {
+	tok := p.peek()
+	return tok.kind == tPunct && tok.text == text
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.peek().line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) error {
+	if !p.isPunct(text) {
+		return p.errorf("Expected %q, got %v", text, p.peek())
+	}
+	p.next()
+	return nil
+}
+
+// atRuleStart checks if the next tokens start a new rule, "name =", "name /="
+// or "name //=".
+func (p *parser) atRuleStart() bool {
+	if p.peek().kind != tIdent {
+		return false
+	}
+
+	tok := p.peekAt(1)
+	if tok.kind != tPunct {
+		return false
+	}
+	switch tok.text {
+	case "=", "/=", "//=":
+		return true
+	case "<":
+		return true
+	default:
+		return false
+	}
+}
+
+// Parse parses a CDDL specification. The standard prelude is included and
+// references to undefined rules are reported as errors.
+func Parse(src string) (*Schema, error) {
+	s := &Schema{Rules: make(map[string]*Rule)}
+
+	if err := s.parse(prelude, true); err != nil {
+		panic(fmt.Sprintf("cddl: invalid prelude: %v", err))
+	}
+	if err := s.parse(src, false); err != nil {
+		return nil, fmt.Errorf("Parse: %w", err)
+	}
+	if err := s.checkRefs(); err != nil {
+		return nil, fmt.Errorf("Parse: %w", err)
+	}
+	return s, nil
+}
+
+// MustParse is like Parse, but panics on an error.
+func MustParse(src string) *Schema {
+	s, err := Parse(src)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) parse(src string, isPrelude bool) error {
+	tokens, err := lex(src)
+	if err != nil {
+		return err
+	}
+
+	p := &parser{tokens: tokens}
+	for p.peek().kind != tEOF {
+		if err := s.parseRule(p, isPrelude); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Schema) parseRule(p *parser, isPrelude bool) error {
+	nameTok := p.next()
+	if nameTok.kind != tIdent {
+		return fmt.Errorf("line %d: Expected a rule name, got %v", nameTok.line, nameTok)
+	} else if p.isPunct("<") {
+		return p.errorf("Generic rule %s is not supported", nameTok.text)
+	}
+
+	assign := p.next()
+	if assign.kind != tPunct || (assign.text != "=" && assign.text != "/=" && assign.text != "//=") {
+		return fmt.Errorf("line %d: Expected an assignment after %s, got %v", assign.line, nameTok.text, assign)
+	}
+
+	g, err := p.parseGroup(true)
+	if err != nil {
+		return err
+	}
+
+	name := nameTok.text
+	line := nameTok.line
+	if isPrelude {
+		line = 0
+	}
+
+	existing, exists := s.Rules[name]
+	if exists && existing.Line == 0 && !isPrelude && assign.text == "=" {
+		// A specification may redefine a prelude's rule.
+		exists = false
+	}
+
+	switch {
+	case assign.text == "=" && exists:
+		return fmt.Errorf("line %d: Rule %s is already defined in line %d", line, name, existing.Line)
+
+	case assign.text == "=":
+		rule := &Rule{Name: name, Line: line}
+		if t := g.asType(); t != nil {
+			rule.Type = t
+		} else {
+			rule.Group = g.unparen()
+		}
+		s.Rules[name] = rule
+		if !isPrelude {
+			s.Names = append(s.Names, name)
+		}
+
+	case !exists:
+		return fmt.Errorf("line %d: Rule %s is extended by %s before being defined", line, name, assign.text)
+
+	case assign.text == "/=":
+		t := g.asType()
+		if t == nil || existing.Type == nil {
+			return fmt.Errorf("line %d: Type choice /= requires types for rule %s", line, name)
+		}
+		existing.Type = appendChoice(existing.Type, t)
+
+	default:
+		if existing.Group == nil {
+			if existing.Group = groupOfType(existing.Type); existing.Group == nil {
+				return fmt.Errorf("line %d: Group choice //= requires a group for rule %s", line, name)
+			}
+			existing.Type = nil
+		}
+		existing.Group.Choices = append(existing.Group.Choices, g.unparen().Choices...)
+	}
+	return nil
+}
+
+// appendChoice adds an option to a type, creating a Choice if necessary.
+func appendChoice(t, option Type) Type {
+	if c, ok := t.(*Choice); ok {
+		return &Choice{Options: append(append([]Type{}, c.Options...), option)}
+	}
+	return &Choice{Options: []Type{t, option}}
+}
+
+// groupOfType returns the group of a single entry for a type, e.g., as parsed
+// for a rule consisting of a single type.
+func groupOfType(t Type) *Group {
+	if t == nil {
+		return nil
+	}
+	return &Group{Choices: [][]*Entry{{{Min: 1, Max: 1, Value: t}}}}
+}
+
+// asType returns the type of a group consisting of exactly one entry without
+// an occurrence indicator or a member key, otherwise nil.
+func (g *Group) asType() Type {
+	if len(g.Choices) != 1 || len(g.Choices[0]) != 1 {
+		return nil
+	}
+
+	e := g.Choices[0][0]
+	if e.Min != 1 || e.Max != 1 || e.Key != nil {
+		return nil
+	} else if _, ok := e.Value.(*GroupType); ok {
+		return nil
+	}
+	return e.Value
+}
+
+// unparen returns the inner group of a group consisting of exactly one
+// parenthesized group, e.g., for the rule "a = (b // c)".
+func (g *Group) unparen() *Group {
+	if len(g.Choices) != 1 || len(g.Choices[0]) != 1 {
+		return g
+	}
+
+	e := g.Choices[0][0]
+	if inner, ok := e.Value.(*GroupType); ok && e.Min == 1 && e.Max == 1 && e.Key == nil {
+		return inner.Group
+	}
+	return g
+}
+
+// parseGroup parses a group until a closing bracket or, at the top level, the
+// start of the next rule.
+func (p *parser) parseGroup(top bool) (*Group, error) {
+	g := &Group{}
+	for {
+		var entries []*Entry
+		for {
+			if p.atGroupEnd(top) {
+				break
+			}
+
+			e, err := p.parseEntry()
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+
+			if p.isPunct(",") {
+				p.next()
+			}
+		}
+		g.Choices = append(g.Choices, entries)
+
+		if !p.isPunct("//") {
+			return g, nil
+		}
+		p.next()
+	}
+}
+
+// atGroupEnd checks if the next token ends a group's choice.
+func (p *parser) atGroupEnd(top bool) bool {
+	tok := p.peek()
+	switch {
+	case tok.kind == tEOF:
+		return true
+	case top:
+		return p.atRuleStart()
+	case tok.kind != tPunct:
+		return false
+	default:
+		return tok.text == ")" || tok.text == "]" || tok.text == "}" || tok.text == "//"
+	}
+}
+
+func (p *parser) parseEntry() (*Entry, error) {
+	e := &Entry{Min: 1, Max: 1}
+
+	// Occurrence indicator
+	tok := p.peek()
+	switch {
+	case tok.kind == tPunct && tok.text == "?":
+		p.next()
+		e.Min, e.Max = 0, 1
+	case tok.kind == tPunct && tok.text == "+":
+		p.next()
+		e.Min, e.Max = 1, Unbounded
+	case tok.kind == tPunct && tok.text == "*":
+		p.next()
+		e.Min, e.Max = 0, Unbounded
+		if n, ok := p.peek().value.(uint64); ok && p.peek().kind == tNumber {
+			p.next()
+			e.Max = n
+		}
+	case tok.kind == tNumber && p.peekAt(1).kind == tPunct && p.peekAt(1).text == "*":
+		n, ok := tok.value.(uint64)
+		if !ok {
+			return nil, p.errorf("Invalid occurrence %v", tok)
+		}
+		p.next()
+		p.next()
+		e.Min, e.Max = n, Unbounded
+		if m, ok := p.peek().value.(uint64); ok && p.peek().kind == tNumber {
+			p.next()
+			e.Max = m
+		}
+	}
+
+	// Member key with ":", either a bareword or a value
+	tok = p.peek()
+	if next := p.peekAt(1); next.kind == tPunct && next.text == ":" {
+		switch tok.kind {
+		case tIdent:
+			e.Key = &Literal{Value: tok.text}
+		case tNumber, tText, tBytes:
+			e.Key = &Literal{Value: tok.value}
+		default:
+			return nil, p.errorf("Invalid member key %v", tok)
+		}
+		p.next()
+		p.next()
+		e.Cut = true
+
+		value, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		e.Value = value
+		return e, nil
+	}
+
+	first, err := p.parseType1()
+	if err != nil {
+		return nil, err
+	}
+
+	// Member key with "=>", optionally with a cut "^"
+	if p.isPunct("^") || p.isPunct("=>") {
+		if p.isPunct("^") {
+			p.next()
+			e.Cut = true
+		}
+		if err := p.expect("=>"); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		e.Key, e.Value = first, value
+		return e, nil
+	}
+
+	e.Value, err = p.parseChoiceRest(first)
+	return e, err
+}
+
+// parseType parses a type choice.
+func (p *parser) parseType() (Type, error) {
+	first, err := p.parseType1()
+	if err != nil {
+		return nil, err
+	}
+	return p.parseChoiceRest(first)
+}
+
+// parseChoiceRest parses the remaining options of a type choice.
+func (p *parser) parseChoiceRest(first Type) (Type, error) {
+	if !p.isPunct("/") {
+		return first, nil
+	}
+
+	choice := &Choice{Options: []Type{first}}
+	for p.isPunct("/") {
+		p.next()
+		option, err := p.parseType1()
+		if err != nil {
+			return nil, err
+		}
+		choice.Options = append(choice.Options, option)
+	}
+	return choice, nil
+}
+
+// parseType1 parses a type with an optional range or control operator.
+func (p *parser) parseType1() (Type, error) {
+	t, err := p.parseType2()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok := p.peek(); {
+	case tok.kind == tPunct && (tok.text == ".." || tok.text == "..."):
+		p.next()
+		max, err := p.parseType2()
+		if err != nil {
+			return nil, err
+		}
+		return &Range{Min: t, Max: max, Exclusive: tok.text == "..."}, nil
+
+	case tok.kind == tCtl:
+		p.next()
+		switch tok.text {
+		case "size", "bits", "cbor", "cborseq", "regexp", "lt", "le", "gt", "ge", "eq", "ne", "default":
+		default:
+			return nil, fmt.Errorf("line %d: Unsupported control operator .%s", tok.line, tok.text)
+		}
+
+		controller, err := p.parseType2()
+		if err != nil {
+			return nil, err
+		}
+		return &Control{Op: tok.text, Target: t, Controller: controller}, nil
+	}
+
+	return t, nil
+}
+
+func (p *parser) parseType2() (Type, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tNumber, tText, tBytes:
+		return &Literal{Value: tok.value}, nil
+
+	case tIdent:
+		if p.isPunct("<") {
+			return nil, p.errorf("Generic arguments for %s are not supported", tok.text)
+		}
+		return &Ref{Name: tok.text}, nil
+
+	case tHash:
+		return p.parseHash(tok)
+
+	case tPunct:
+		switch tok.text {
+		case "(":
+			g, err := p.parseGroup(false)
+			if err != nil {
+				return nil, err
+			} else if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+
+			if t := g.asType(); t != nil {
+				return t, nil
+			}
+			return &GroupType{Group: g}, nil
+
+		case "[", "{":
+			g, err := p.parseGroup(false)
+			if err != nil {
+				return nil, err
+			}
+
+			if tok.text == "[" {
+				return &Array{Group: g}, p.expect("]")
+			}
+			return &Map{Group: g}, p.expect("}")
+
+		case "~":
+			name := p.next()
+			if name.kind != tIdent {
+				return nil, fmt.Errorf("line %d: Expected a rule name after ~, got %v", name.line, name)
+			}
+			return &Unwrap{Name: name.text}, nil
+
+		case "&":
+			if p.isPunct("(") {
+				p.next()
+				g, err := p.parseGroup(false)
+				if err != nil {
+					return nil, err
+				}
+				return &Enum{Group: g}, p.expect(")")
+			}
+
+			name := p.next()
+			if name.kind != tIdent {
+				return nil, fmt.Errorf("line %d: Expected a group name after &, got %v", name.line, name)
+			}
+			return &Enum{Group: &Group{Choices: [][]*Entry{{{Min: 1, Max: 1, Value: &Ref{Name: name.text}}}}}}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("line %d: Unexpected %v", tok.line, tok)
+}
+
+// parseHash parses a major type, e.g., "#", "#1", "#7.25", or a tag, e.g.,
+// "#6.24(bstr)".
+func (p *parser) parseHash(tok token) (Type, error) {
+	if tok.text == "" {
+		return &MajorType{Major: -1}, nil
+	}
+
+	majorText, argText, hasArg := strings.Cut(tok.text, ".")
+	major, err := strconv.Atoi(majorText)
+	if err != nil || major > 7 {
+		return nil, fmt.Errorf("line %d: Invalid major type #%s", tok.line, tok.text)
+	}
+
+	var arg *uint64
+	if hasArg {
+		n, err := strconv.ParseUint(argText, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: Invalid argument #%s", tok.line, tok.text)
+		}
+		arg = &n
+	}
+
+	if major == 6 && p.isPunct("(") {
+		p.next()
+		content, err := p.parseType()
+		if err != nil {
+			return nil, err
+		} else if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &TagType{Number: arg, Content: content}, nil
+	}
+
+	return &MajorType{Major: major, Argument: arg}, nil
+}
+
+// checkRefs reports references to undefined rules.
+func (s *Schema) checkRefs() error {
+	var checkType func(t Type) error
+	var checkGroup func(g *Group) error
+
+	checkName := func(name string) error {
+		if _, ok := s.Rules[name]; !ok {
+			return fmt.Errorf("Undefined rule %s", name)
+		}
+		return nil
+	}
+
+	checkType = func(t Type) error {
+		switch t := t.(type) {
+		case *Choice:
+			for _, option := range t.Options {
+				if err := checkType(option); err != nil {
+					return err
+				}
+			}
+		case *Ref:
+			return checkName(t.Name)
+		case *Unwrap:
+			return checkName(t.Name)
+		case *Range:
+			if err := checkType(t.Min); err != nil {
+				return err
+			}
+			return checkType(t.Max)
+		case *Control:
+			if err := checkType(t.Target); err != nil {
+				return err
+			}
+			return checkType(t.Controller)
+		case *Array:
+			return checkGroup(t.Group)
+		case *Map:
+			return checkGroup(t.Group)
+		case *TagType:
+			return checkType(t.Content)
+		case *Enum:
+			return checkGroup(t.Group)
+		case *GroupType:
+			return checkGroup(t.Group)
+		}
+		return nil
+	}
+
+	checkGroup = func(g *Group) error {
+		for _, choice := range g.Choices {
+			for _, e := range choice {
+				if e.Key != nil {
+					if err := checkType(e.Key); err != nil {
+						return err
+					}
+				}
+				if err := checkType(e.Value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, name := range s.Names {
+		rule := s.Rules[name]
+		var err error
+		if rule.Type != nil {
+			err = checkType(rule.Type)
+		} else {
+			err = checkGroup(rule.Group)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: Rule %s: %w", rule.Line, name, err)
+		}
+	}
+	return nil
+}
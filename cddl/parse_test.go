@@ -0,0 +1,107 @@
+package cddl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	src := `
+; A simplified Bundle Protocol Version 7 primary block.
+primary-block = [
+  version: 7,
+  flags: uint .bits flags,
+  crc-type: 0..2,
+  destination: eid,
+  ? crc: bstr .size 2 / bstr .size 4,
+]
+
+flags = &(is-fragment: 0, is-admin: 1)
+
+eid = [0, 0] / [1, tstr]
+eid /= [2, ipn]
+ipn = [uint, uint]
+
+extension = { * (int => any) }
+header = (a: int // b: tstr, + c: float)
+header //= (d: null)
+payload = bstr .cbor primary-block
+`
+
+	expected := map[string]string{
+		"primary-block": `[version: 7, flags: uint .bits flags, crc-type: 0..2, destination: eid, ? crc: bstr .size 2 / bstr .size 4]`,
+		"flags":         `&(is-fragment: 0, is-admin: 1)`,
+		"eid":           `[0, 0] / [1, tstr] / [2, ipn]`,
+		"ipn":           `[uint, uint]`,
+		"extension":     `{* (int => any)}`,
+		"header":        `a: int // b: tstr, + c: float // d: null`,
+		"payload":       `bstr .cbor primary-block`,
+	}
+
+	s, err := Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := []string{"primary-block", "flags", "eid", "ipn", "extension", "header", "payload"}
+	if !reflect.DeepEqual(s.Names, names) {
+		t.Fatalf("Names mismatch: %v != %v", s.Names, names)
+	}
+
+	for name, str := range expected {
+		rule := s.Rules[name]
+
+		var got string
+		if rule.Type != nil {
+			got = rule.Type.String()
+		} else {
+			got = rule.Group.String()
+		}
+		if got != str {
+			t.Fatalf("Rule %s is %s instead of %s", name, got, str)
+		}
+	}
+
+	if s.Rules["header"].Group == nil || s.Rules["eid"].Type == nil {
+		t.Fatalf("Rules have the wrong kind")
+	} else if line := s.Rules["ipn"].Line; line != 15 {
+		t.Fatalf("Rule ipn is in line %d instead of 15", line)
+	} else if s.Rules["uint"] == nil || s.Rules["uint"].Line != 0 {
+		t.Fatalf("Prelude is missing")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{"a = [uint", `line 1: Expected "]", got end of input`},
+		{"a = uint\na = tstr", "line 2: Rule a is already defined in line 1"},
+		{"a /= uint", "line 1: Rule a is extended by /= before being defined"},
+		{"a = foo", "line 1: Rule a: Undefined rule foo"},
+		{"a<T> = [T]", "line 1: Generic rule a is not supported"},
+		{"a = uint .within b\nb = uint", "line 1: Unsupported control operator .within"},
+		{"a = #8", "line 1: Invalid major type #8"},
+		{"a = [1 => ]", "line 1: Unexpected \"]\""},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.src)
+		if err == nil {
+			t.Fatalf("Parsing %q did not error", test.src)
+		} else if !strings.Contains(err.Error(), test.err) {
+			t.Fatalf("Parsing %q errored with %v instead of %s", test.src, err, test.err)
+		}
+	}
+}
+
+func TestParseRedefinePrelude(t *testing.T) {
+	s, err := Parse("uint = 0..255")
+	if err != nil {
+		t.Fatal(err)
+	} else if str := s.Rules["uint"].Type.String(); str != "0..255" {
+		t.Fatalf("Redefined uint is %s", str)
+	}
+}
@@ -0,0 +1,846 @@
+package cddl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"slices"
+
+	"github.com/dtn7/cboring"
+)
+
+// maxRecursion limits the depth of nested rule references, e.g., for a rule
+// referencing itself without a nested data item.
+const maxRecursion = 4096
+
+// ValidationError describes the first mismatch between a data item and a rule.
+type ValidationError struct {
+	// Path to the mismatching data item, e.g., [1]{"name"} for the "name"
+	// value of a map within an array's second element. An embedded CBOR data
+	// item is written as <<>> and a tag's content as #n. The root data item
+	// has an empty path.
+	Path string
+	// Offset of the mismatching data item from the validated input's start.
+	Offset int64
+	// Message describes the mismatch.
+	Message string
+
+	// typeMismatch is set for a data item not matching a type at all, in
+	// contrast to, e.g., a nested data item's mismatch.
+	typeMismatch bool
+}
+
+func (e *ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("Validate: %s at offset %d: %s", path, e.Offset, e.Message)
+}
+
+// Validate reads the next data item from the Reader and validates it against
+// the named rule. For a mismatch, a *ValidationError is returned.
+func (s *Schema) Validate(rule string, r io.Reader) error {
+	item, err := cboring.ReadItem(r)
+	if err != nil {
+		return fmt.Errorf("Validate: %w", err)
+	}
+	return s.ValidateItem(rule, item)
+}
+
+// ValidateItem validates a data item, as returned by cboring.ReadItem, against
+// the named rule. For a mismatch, a *ValidationError is returned.
+func (s *Schema) ValidateItem(rule string, item *cboring.Item) error {
+	r, ok := s.Rules[rule]
+	if !ok {
+		return fmt.Errorf("Validate: Unknown rule %s", rule)
+	} else if r.Type == nil {
+		return fmt.Errorf("Validate: Rule %s is a group, not a type", rule)
+	}
+
+	v := &validator{s: s}
+	if err := v.matchType(r.Type, item, ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+type validator struct {
+	s     *Schema
+	depth int
+}
+
+// mismatch creates a ValidationError for a data item not matching a type.
+func mismatch(t Type, item *cboring.Item, path string) *ValidationError {
+	return &ValidationError{
+		Path:         path,
+		Offset:       item.Offset,
+		Message:      fmt.Sprintf("Expected %v, got %s", t, describe(item)),
+		typeMismatch: true,
+	}
+}
+
+// describe a data item for an error message, e.g., unsigned(23) or "foo".
+func describe(item *cboring.Item) string {
+	switch v := item.Value.(type) {
+	case string:
+		if len(v) <= 32 {
+			return fmt.Sprintf("%q", v)
+		}
+	case float32, float64:
+		return fmt.Sprintf("float(%v)", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	}
+	if item.Major() == cboring.SimpleData && item.Head.Info == 22 {
+		return "null"
+	}
+	return item.Head.String()
+}
+
+// mismatches checks if the error is a data item's mismatch of a type, not one
+// of its nested data items or its control operator.
+func (e *ValidationError) mismatches(item *cboring.Item, path string) bool {
+	return e.typeMismatch && e.Offset == item.Offset && e.Path == path
+}
+
+// deeper returns the error of the mismatch found further within the input,
+// preferring a in case of a tie.
+func deeper(a, b *ValidationError) *ValidationError {
+	if a == nil || (b != nil && b.Offset > a.Offset) {
+		return b
+	}
+	return a
+}
+
+// rule returns the named rule. References are checked by Parse.
+func (v *validator) rule(name string) *Rule {
+	return v.s.Rules[name]
+}
+
+func (v *validator) matchType(t Type, item *cboring.Item, path string) *ValidationError {
+	v.depth++
+	defer func() { v.depth-- }()
+	if v.depth > maxRecursion {
+		return &ValidationError{Path: path, Offset: item.Offset, Message: "Exceeding the maximum rule recursion"}
+	}
+
+	switch t := t.(type) {
+	case *Choice:
+		var best *ValidationError
+		allMismatch := true
+		for _, option := range t.Options {
+			err := v.matchType(option, item, path)
+			if err == nil {
+				return nil
+			}
+			best = deeper(best, err)
+			allMismatch = allMismatch && err.mismatches(item, path)
+		}
+		if allMismatch {
+			return mismatch(t, item, path)
+		}
+		return best
+
+	case *Ref:
+		r := v.rule(t.Name)
+		if r.Type == nil {
+			return &ValidationError{Path: path, Offset: item.Offset,
+				Message: fmt.Sprintf("Group %s is used as a type", t.Name)}
+		}
+
+		// Report a mismatch by the rule's name instead of its definition.
+		err := v.matchType(r.Type, item, path)
+		if err != nil && err.mismatches(item, path) {
+			return mismatch(t, item, path)
+		}
+		return err
+
+	case *Literal:
+		if !literalMatches(t.Value, item) {
+			return mismatch(t, item, path)
+		}
+		return nil
+
+	case *Range:
+		return v.matchRange(t, item, path)
+
+	case *Control:
+		return v.matchControl(t, item, path)
+
+	case *Array:
+		if item.Major() != cboring.Array {
+			return mismatch(t, item, path)
+		}
+		return v.matchArray(t.Group, item, path)
+
+	case *Map:
+		if item.Major() != cboring.Map {
+			return mismatch(t, item, path)
+		}
+		return v.matchMap(t.Group, item, path)
+
+	case *TagType:
+		if item.Major() != cboring.Tag || (t.Number != nil && *t.Number != item.Head.Argument) {
+			return mismatch(t, item, path)
+		}
+		return v.matchType(t.Content, item.Items[0], fmt.Sprintf("%s#%d", path, item.Head.Argument))
+
+	case *MajorType:
+		return matchMajorType(t, item, path)
+
+	case *Unwrap:
+		switch inner := v.rule(t.Name).Type.(type) {
+		case *TagType:
+			return v.matchType(inner.Content, item, path)
+		case *Array:
+			return v.matchType(&Array{Group: inner.Group}, item, path)
+		case *Map:
+			return v.matchType(&Map{Group: inner.Group}, item, path)
+		default:
+			return &ValidationError{Path: path, Offset: item.Offset,
+				Message: fmt.Sprintf("Cannot unwrap %s", t.Name)}
+		}
+
+	case *Enum:
+		var values []Type
+		for _, e := range v.flatten(t.Group) {
+			values = append(values, e.Value)
+		}
+		if len(values) == 0 {
+			return mismatch(t, item, path)
+		}
+		return v.matchType(&Choice{Options: values}, item, path)
+
+	case *GroupType:
+		return &ValidationError{Path: path, Offset: item.Offset,
+			Message: fmt.Sprintf("Group %v is used as a type", t)}
+
+	default:
+		panic(fmt.Sprintf("cddl: unknown type %T", t))
+	}
+}
+
+// flatten returns a group's entries of all choices, splicing nested groups.
+func (v *validator) flatten(g *Group) (entries []*Entry) {
+	for _, choice := range g.Choices {
+		for _, e := range choice {
+			if inner := v.groupOf(e.Value); inner != nil {
+				entries = append(entries, v.flatten(inner)...)
+			} else {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return
+}
+
+// groupOf returns the group of an entry's value to be spliced into the
+// surrounding group, or nil if the value is a type.
+func (v *validator) groupOf(t Type) *Group {
+	switch t := t.(type) {
+	case *GroupType:
+		return t.Group
+	case *Ref:
+		return v.rule(t.Name).Group
+	case *Unwrap:
+		switch inner := v.rule(t.Name).Type.(type) {
+		case *Array:
+			return inner.Group
+		case *Map:
+			return inner.Group
+		}
+	}
+	return nil
+}
+
+func matchMajorType(t *MajorType, item *cboring.Item, path string) *ValidationError {
+	if t.Major < 0 {
+		return nil
+	} else if item.Major() != cboring.MajorType(t.Major<<5) {
+		return mismatch(t, item, path)
+	} else if t.Argument == nil {
+		return nil
+	}
+
+	arg := item.Head.Argument
+	if item.Major() == cboring.SimpleData {
+		// Floating-point values are identified by their additional information,
+		// e.g., #7.25 for a half-precision float.
+		arg = uint64(item.Head.Info)
+		if item.Head.Info == 24 {
+			arg = item.Head.Argument
+		}
+	}
+	if arg != *t.Argument {
+		return mismatch(t, item, path)
+	}
+	return nil
+}
+
+/*** Values ***/
+
+// number returns a numeric data item's value and if it is an integer.
+func number(item *cboring.Item) (n *big.Float, isInt bool, ok bool) {
+	if item.Major() == cboring.Tag {
+		return nil, false, false
+	}
+
+	switch v := item.Value.(type) {
+	case uint64:
+		return new(big.Float).SetUint64(v), true, true
+	case int64:
+		return new(big.Float).SetInt64(v), true, true
+	case cboring.NegativeInt:
+		n := new(big.Float).SetUint64(uint64(v))
+		return n.Neg(n).Sub(n, big.NewFloat(1)), true, true
+	case float32:
+		return floatValue(float64(v))
+	case float64:
+		return floatValue(v)
+	}
+	return nil, false, false
+}
+
+func floatValue(f float64) (*big.Float, bool, bool) {
+	if f != f {
+		// NaN matches no number.
+		return nil, false, false
+	}
+	return big.NewFloat(f), false, true
+}
+
+// literalNumber returns a numeric literal's value and if it is an integer.
+func literalNumber(value any) (n *big.Float, isInt bool, ok bool) {
+	switch v := value.(type) {
+	case uint64:
+		return new(big.Float).SetUint64(v), true, true
+	case int64:
+		return new(big.Float).SetInt64(v), true, true
+	case float64:
+		return floatValue(v)
+	}
+	return nil, false, false
+}
+
+func literalMatches(value any, item *cboring.Item) bool {
+	switch v := value.(type) {
+	case string:
+		s, ok := item.Value.(string)
+		return ok && s == v
+	case []byte:
+		b, ok := item.Value.([]byte)
+		return ok && bytes.Equal(b, v)
+	}
+
+	want, wantInt, ok := literalNumber(value)
+	if !ok {
+		return false
+	}
+	got, gotInt, ok := number(item)
+	return ok && wantInt == gotInt && got.Cmp(want) == 0
+}
+
+// resolveLiteral follows references to a literal, e.g., for a range's bounds.
+func (v *validator) resolveLiteral(t Type) (any, bool) {
+	for i := 0; i < maxRecursion; i++ {
+		switch tt := t.(type) {
+		case *Literal:
+			return tt.Value, true
+		case *Ref:
+			if t = v.rule(tt.Name).Type; t == nil {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+func (v *validator) matchRange(t *Range, item *cboring.Item, path string) *ValidationError {
+	minValue, okMin := v.resolveLiteral(t.Min)
+	maxValue, okMax := v.resolveLiteral(t.Max)
+	if !okMin || !okMax {
+		return &ValidationError{Path: path, Offset: item.Offset, Message: fmt.Sprintf("Invalid range %v", t)}
+	}
+
+	min, minInt, okMin := literalNumber(minValue)
+	max, _, okMax := literalNumber(maxValue)
+	n, isInt, ok := number(item)
+	if !okMin || !okMax || !ok || isInt != minInt {
+		return mismatch(t, item, path)
+	}
+
+	if n.Cmp(min) < 0 || n.Cmp(max) > 0 || (t.Exclusive && n.Cmp(max) == 0) {
+		return mismatch(t, item, path)
+	}
+	return nil
+}
+
+/*** Control Operators ***/
+
+// uintItem creates a synthetic data item for an unsigned integer, e.g., to
+// match a string's length against a controller.
+func uintItem(n uint64, offset int64) *cboring.Item {
+	return &cboring.Item{Head: cboring.Head{Major: cboring.UInt, Argument: n}, Value: n, Offset: offset}
+}
+
+func (v *validator) matchControl(t *Control, item *cboring.Item, path string) *ValidationError {
+	if err := v.matchType(t.Target, item, path); err != nil {
+		return err
+	}
+
+	controlErr := func(format string, args ...any) *ValidationError {
+		return &ValidationError{Path: path, Offset: item.Offset, Message: fmt.Sprintf(format, args...)}
+	}
+
+	switch t.Op {
+	case "size":
+		var size uint64
+		switch value := item.Value.(type) {
+		case []byte:
+			size = uint64(len(value))
+		case string:
+			size = uint64(len(value))
+		case uint64:
+			for ; value > 0; value >>= 8 {
+				size++
+			}
+			// "uint .size n" limits the value to n bytes.
+			if limit, ok := v.resolveLiteral(t.Controller); ok {
+				if n, ok := limit.(uint64); ok && size <= n {
+					return nil
+				}
+			}
+		default:
+			return controlErr("Control .size is not applicable to %s", describe(item))
+		}
+
+		if v.matchType(t.Controller, uintItem(size, item.Offset), path) != nil {
+			return controlErr("Size %d does not match %v", size, t.Controller)
+		}
+
+	case "bits":
+		var bits []uint64
+		switch value := item.Value.(type) {
+		case []byte:
+			for i, b := range value {
+				for j := 0; j < 8; j++ {
+					if b&(1<<j) != 0 {
+						bits = append(bits, uint64(i*8+j))
+					}
+				}
+			}
+		case uint64:
+			for j := uint64(0); value != 0; j, value = j+1, value>>1 {
+				if value&1 != 0 {
+					bits = append(bits, j)
+				}
+			}
+		default:
+			return controlErr("Control .bits is not applicable to %s", describe(item))
+		}
+
+		for _, bit := range bits {
+			if v.matchType(t.Controller, uintItem(bit, item.Offset), path) != nil {
+				return controlErr("Bit %d is not allowed by %v", bit, t.Controller)
+			}
+		}
+
+	case "cbor", "cborseq":
+		data, ok := item.Value.([]byte)
+		if !ok {
+			return controlErr("Control .%s is not applicable to %s", t.Op, describe(item))
+		}
+		return v.matchEmbedded(t, data, item, path)
+
+	case "regexp":
+		pattern, ok := v.resolveLiteral(t.Controller)
+		text, isText := item.Value.(string)
+		if patternText, isPattern := pattern.(string); !ok || !isPattern || !isText {
+			return controlErr("Control .regexp requires text strings")
+		} else if re, err := regexp.Compile("^(?:" + patternText + ")$"); err != nil {
+			return controlErr("Invalid regular expression %q: %v", patternText, err)
+		} else if !re.MatchString(text) {
+			return controlErr("Text %q does not match %q", text, patternText)
+		}
+
+	case "lt", "le", "gt", "ge", "eq", "ne":
+		value, ok := v.resolveLiteral(t.Controller)
+		if !ok {
+			return controlErr("Control .%s requires a literal", t.Op)
+		}
+
+		n, _, isNumber := number(item)
+		want, _, isLiteralNumber := literalNumber(value)
+		var cmp int
+		switch {
+		case isNumber && isLiteralNumber:
+			cmp = n.Cmp(want)
+		case t.Op == "eq" || t.Op == "ne":
+			if !literalMatches(value, item) {
+				cmp = 1
+			}
+		default:
+			return controlErr("Control .%s requires numbers", t.Op)
+		}
+
+		var result bool
+		switch t.Op {
+		case "lt":
+			result = cmp < 0
+		case "le":
+			result = cmp <= 0
+		case "gt":
+			result = cmp > 0
+		case "ge":
+			result = cmp >= 0
+		case "eq":
+			result = cmp == 0
+		case "ne":
+			result = cmp != 0
+		}
+		if !result {
+			return controlErr("Expected %v, got %s", t, describe(item))
+		}
+
+	case "default":
+		// A default value does not restrict the target.
+	}
+	return nil
+}
+
+// matchEmbedded validates the CBOR data item or sequence embedded in a byte
+// string. Offsets within the embedded data are reported relative to the input.
+func (v *validator) matchEmbedded(t *Control, data []byte, item *cboring.Item, path string) *ValidationError {
+	path += "<<>>"
+	base := item.Offset + int64(item.Head.Width)
+
+	var items []*cboring.Item
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		start := int64(len(data) - r.Len())
+		embedded, err := cboring.ReadItem(r)
+		if err != nil {
+			return &ValidationError{Path: path, Offset: base + start,
+				Message: fmt.Sprintf("Invalid embedded CBOR: %v", err)}
+		}
+		shiftOffsets(embedded, base+start)
+		items = append(items, embedded)
+
+		if t.Op == "cbor" {
+			break
+		}
+	}
+
+	if t.Op == "cborseq" {
+		// The sequence is matched as the elements of an array, as in RFC 8742.
+		seq := &cboring.Item{
+			Head:   cboring.Head{Major: cboring.Array, Argument: uint64(len(items))},
+			Items:  items,
+			Offset: base,
+		}
+		return v.matchType(t.Controller, seq, path)
+	}
+
+	if len(items) == 0 {
+		return &ValidationError{Path: path, Offset: base, Message: "Missing embedded CBOR"}
+	} else if r.Len() > 0 {
+		return &ValidationError{Path: path, Offset: base + int64(len(data)-r.Len()),
+			Message: "Unexpected data after embedded CBOR"}
+	}
+	return v.matchType(t.Controller, items[0], path)
+}
+
+// shiftOffsets adds a delta to the offsets of a tree of Items.
+func shiftOffsets(item *cboring.Item, delta int64) {
+	item.Offset += delta
+	for _, child := range item.Items {
+		shiftOffsets(child, delta)
+	}
+}
+
+/*** Arrays ***/
+
+func (v *validator) matchArray(g *Group, item *cboring.Item, path string) *ValidationError {
+	m := &arrayMatch{v: v, item: item, path: path}
+	ends := m.group(g, []int{0})
+	if len(ends) == 0 {
+		return m.best
+	}
+
+	pos := ends[len(ends)-1]
+	if pos == len(item.Items) {
+		return nil
+	}
+	m.fail(&ValidationError{Path: fmt.Sprintf("%s[%d]", path, pos), Offset: item.Items[pos].Offset,
+		Message: fmt.Sprintf("Unexpected array element %s", describe(item.Items[pos]))})
+	return m.best
+}
+
+// arrayMatch matches an array's elements against a group. Instead of
+// backtracking over each occurrence, all positions a group might end at are
+// tracked at once. Thus, each entry is matched at most once per position.
+type arrayMatch struct {
+	v    *validator
+	item *cboring.Item
+	path string
+
+	// best is the mismatch found furthest within the array.
+	best *ValidationError
+}
+
+func (m *arrayMatch) fail(err *ValidationError) {
+	m.best = deeper(m.best, err)
+}
+
+// group matches the elements starting at each of the sorted positions against
+// the group's choices, returning the sorted positions after all matches.
+func (m *arrayMatch) group(g *Group, starts []int) (ends []int) {
+	for _, choice := range g.Choices {
+		ends = append(ends, m.entries(choice, starts)...)
+	}
+	return sortedPositions(ends)
+}
+
+// entries matches the elements starting at each of the positions against a
+// sequence of entries.
+func (m *arrayMatch) entries(entries []*Entry, starts []int) []int {
+	for _, e := range entries {
+		if starts = m.repeat(e, starts); len(starts) == 0 {
+			break
+		}
+	}
+	return starts
+}
+
+// repeat matches between e.Min and e.Max occurrences of an entry. Each round
+// continues only from positions not already reached after enough occurrences,
+// as their further rounds would not reach anything new.
+func (m *arrayMatch) repeat(e *Entry, starts []int) []int {
+	if e.Min <= 1 && e.Max == 1 {
+		var ends []int
+		if e.Min == 0 {
+			ends = append(ends, starts...)
+		}
+		for _, pos := range starts {
+			ends = append(ends, m.occurrence(e, pos)...)
+		}
+		return sortedPositions(ends)
+	}
+
+	// Positions are never before the first start, which is used as an offset
+	// into reached. Only positions of rounds below e.Min might be revisited.
+	base := starts[0]
+	var reached []bool
+	var occurrences map[int][]int
+	var ends []int
+
+	reach := func(pos int) bool {
+		for pos-base >= len(reached) {
+			reached = append(reached, false)
+		}
+		if reached[pos-base] {
+			return false
+		}
+		reached[pos-base] = true
+		ends = append(ends, pos)
+		return true
+	}
+
+	round := starts
+	for count := uint64(0); len(round) > 0; count++ {
+		if count >= e.Min {
+			var fresh []int
+			for _, pos := range round {
+				if reach(pos) {
+					fresh = append(fresh, pos)
+				}
+			}
+			round = fresh
+		}
+		if count >= e.Max {
+			break
+		}
+
+		var next []int
+		for _, pos := range round {
+			after, ok := occurrences[pos]
+			if !ok {
+				after = m.occurrence(e, pos)
+			}
+			if count < e.Min {
+				if occurrences == nil {
+					occurrences = make(map[int][]int)
+				}
+				occurrences[pos] = after
+			}
+
+			for _, end := range after {
+				if end != pos {
+					next = append(next, end)
+				} else {
+					// An empty match would repeat forever, but satisfies
+					// any remaining occurrences.
+					reach(pos)
+				}
+			}
+		}
+		round = sortedPositions(next)
+	}
+	return sortedPositions(ends)
+}
+
+// occurrence matches one occurrence of an entry against the elements starting
+// at pos. Member keys are ignored within arrays.
+func (m *arrayMatch) occurrence(e *Entry, pos int) []int {
+	if g := m.v.groupOf(e.Value); g != nil {
+		m.v.depth++
+		defer func() { m.v.depth-- }()
+		if m.v.depth > maxRecursion {
+			m.fail(&ValidationError{Path: m.path, Offset: m.item.Offset, Message: "Exceeding the maximum rule recursion"})
+			return nil
+		}
+		return m.group(g, []int{pos})
+	}
+
+	if pos >= len(m.item.Items) {
+		m.fail(&ValidationError{Path: m.path, Offset: m.item.Offset,
+			Message: fmt.Sprintf("Missing array element %v after %d elements", e.Value, len(m.item.Items))})
+		return nil
+	}
+
+	if err := m.v.matchType(e.Value, m.item.Items[pos], fmt.Sprintf("%s[%d]", m.path, pos)); err != nil {
+		m.fail(err)
+		return nil
+	}
+	return []int{pos + 1}
+}
+
+// sortedPositions sorts the positions and removes duplicates.
+func sortedPositions(positions []int) []int {
+	slices.Sort(positions)
+	return slices.Compact(positions)
+}
+
+/*** Maps ***/
+
+// mapEntry is an entry of a map's group, where optional is set if it is part
+// of an optional nested group.
+type mapEntry struct {
+	*Entry
+	optional bool
+}
+
+func (v *validator) matchMap(g *Group, item *cboring.Item, path string) *ValidationError {
+	var best *ValidationError
+	for _, choice := range g.Choices {
+		entries, err := v.mapEntries(choice, false, item, path, 0)
+		if err == nil {
+			err = v.matchPairs(entries, item, path)
+		}
+		if err == nil {
+			return nil
+		}
+		best = deeper(best, err)
+	}
+	return best
+}
+
+// mapEntries splices nested groups into a flat list of a map's entries. A
+// choice within a nested group is not supported.
+func (v *validator) mapEntries(entries []*Entry, optional bool, item *cboring.Item, path string,
+	depth int) ([]mapEntry, *ValidationError) {
+	if depth > maxRecursion {
+		return nil, &ValidationError{Path: path, Offset: item.Offset, Message: "Exceeding the maximum rule recursion"}
+	}
+
+	var result []mapEntry
+	for _, e := range entries {
+		g := v.groupOf(e.Value)
+		if g == nil {
+			if e.Key == nil {
+				return nil, &ValidationError{Path: path, Offset: item.Offset,
+					Message: fmt.Sprintf("Map entry %v has no key", e)}
+			}
+			result = append(result, mapEntry{Entry: e, optional: optional})
+			continue
+		}
+
+		if len(g.Choices) != 1 {
+			return nil, &ValidationError{Path: path, Offset: item.Offset,
+				Message: fmt.Sprintf("Group choice %v within a map is not supported", g)}
+		}
+		inner, err := v.mapEntries(g.Choices[0], optional || e.Min == 0, item, path, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, inner...)
+	}
+	return result, nil
+}
+
+// matchPairs greedily assigns the map's key/value pairs to the entries.
+func (v *validator) matchPairs(entries []mapEntry, item *cboring.Item, path string) *ValidationError {
+	pairs := len(item.Items) / 2
+	used := make([]bool, pairs)
+
+	for _, e := range entries {
+		var count uint64
+		for i := 0; i < pairs && count < e.Max; i++ {
+			if used[i] {
+				continue
+			}
+
+			key, value := item.Items[2*i], item.Items[2*i+1]
+			if v.matchType(e.Key, key, path) != nil {
+				continue
+			}
+
+			if err := v.matchType(e.Value, value, path+pathKey(key)); err != nil {
+				if e.Cut {
+					return err
+				}
+				continue
+			}
+
+			used[i] = true
+			count++
+		}
+
+		if count < e.Min && !e.optional {
+			return &ValidationError{Path: path, Offset: item.Offset,
+				Message: fmt.Sprintf("Missing map entry %v", e.Entry)}
+		}
+	}
+
+	for i, isUsed := range used {
+		if !isUsed {
+			key := item.Items[2*i]
+			return &ValidationError{Path: path + pathKey(key), Offset: key.Offset,
+				Message: fmt.Sprintf("Unexpected map key %s", describe(key))}
+		}
+	}
+	return nil
+}
+
+// pathKey returns a map key's path segment, e.g., {"name"} or {1}.
+func pathKey(key *cboring.Item) string {
+	if key.Major() == cboring.Tag {
+		return "{" + key.Head.String() + "}"
+	}
+
+	switch k := key.Value.(type) {
+	case string:
+		return fmt.Sprintf("{%q}", k)
+	case uint64, int64:
+		return fmt.Sprintf("{%d}", k)
+	case cboring.NegativeInt:
+		return fmt.Sprintf("{-1-%d}", uint64(k))
+	default:
+		return "{" + key.Head.String() + "}"
+	}
+}
@@ -0,0 +1,190 @@
+package cddl
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const testSchema = `
+bundle = [primary-block, * block]
+
+primary-block = [
+  version: 7,
+  flags: uint .bits block-flags,
+  crc-type: 0..2,
+  destination: eid,
+  ? crc: bstr .size 2 / bstr .size 4,
+]
+
+block-flags = &(replicate: 0, report: 1, delete: 2)
+
+eid = [0, 0] / [1, tstr] / [2, [uint, uint]]
+
+block = { type: uint .lt 256, ? critical: bool, * int => any }
+
+small = uint .size 1
+float-pair = [float16, float32 / float64]
+sized = tstr .size (1..3)
+embedded = bstr .cbor eid
+sequence = bstr .cborseq [* uint]
+tagged = #6.1(uint) / tdate
+pattern = tstr .regexp "[a-z]+[0-9]?"
+limits = [int .ge -10, int .le 10, number .ne 3]
+list = [* (uint, tstr)]
+counted = [2*3 uint, + (uint, tstr)]
+empty = [* (? uint), tstr]
+`
+
+func TestValidate(t *testing.T) {
+	s := MustParse(testSchema)
+
+	tests := []struct {
+		rule string
+		data string
+	}{
+		// [[7, 5, 1, [1, "dtn"]]]
+		{"bundle", "81 84 07 05 01 82 01 63 64746e"},
+		// [[7, 0, 2, [2, [1, 2]], h'0000'], {"type": 1}, {"type": 2, "critical": true, -1: null}]
+		{"bundle", "83 85 07 00 02 82 02 82 01 02 42 0000 a1 64 74797065 01 a3 64 74797065 02 68 637269746963616c f5 20 f6"},
+		{"small", "18 ff"},
+		{"small", "00"},
+		// [1.0 as float16, 1.0 as float32]
+		{"float-pair", "82 f9 3c00 fa 3f800000"},
+		{"sized", "63 616263"},
+		// h'820000', i.e., the embedded [0, 0]
+		{"embedded", "43 820000"},
+		// h'0102', i.e., the embedded sequence 1, 2
+		{"sequence", "42 0102"},
+		{"tagged", "c1 1a 5f000000"},
+		{"tagged", "c0 61 78"},
+		{"pattern", "64 61626331"},
+		{"limits", "83 29 0a f9 4400"},
+		{"list", "84 01 61 61 02 61 62"},
+		{"list", "9f ff"},
+		// [1, 1, 1, "a"] and [1, 1, 1, 1, "a", 1, "b"]
+		{"counted", "84 01 01 01 61 61"},
+		{"counted", "87 01 01 01 01 61 61 01 61 62"},
+		{"empty", "81 61 61"},
+		{"empty", "83 01 01 61 61"},
+	}
+
+	for _, test := range tests {
+		data, _ := hex.DecodeString(strings.ReplaceAll(test.data, " ", ""))
+		if err := s.Validate(test.rule, bytes.NewBuffer(data)); err != nil {
+			t.Fatalf("Validating %s against %s errored: %v", test.data, test.rule, err)
+		}
+	}
+}
+
+func TestValidateMismatch(t *testing.T) {
+	s := MustParse(testSchema)
+
+	tests := []struct {
+		rule   string
+		data   string
+		path   string
+		offset int64
+		msg    string
+	}{
+		// [6, 0, 0, [0, 0]]
+		{"primary-block", "84 06 00 00 82 00 00", "[0]", 1, "Expected 7, got unsigned(6)"},
+		// [7, 8, 0, [0, 0]], bit 3 is not a block flag
+		{"primary-block", "84 07 08 00 82 00 00", "[1]", 2, "Bit 3 is not allowed"},
+		// [7, 0, 3, [0, 0]]
+		{"primary-block", "84 07 00 03 82 00 00", "[2]", 3, "Expected 0..2, got unsigned(3)"},
+		// [7, 0, 0, [1, 2]]
+		{"primary-block", "84 07 00 00 82 01 02", "[3][1]", 6, "Expected tstr, got unsigned(2)"},
+		// [7, 0, 0, [0, 0], h'00']
+		{"primary-block", "85 07 00 00 82 00 00 41 00", "[4]", 7, "Size 1 does not match 2"},
+		// [7, 0, 0]
+		{"primary-block", "83 07 00 00", "", 0, "Missing array element eid"},
+		// [[7, 0, 0, [0, 0]], {"type": 256}]
+		{"bundle", "82 84 07 00 00 82 00 00 a1 64 74797065 19 0100", `[1]{"type"}`, 14, "Expected uint .lt 256"},
+		// [[7, 0, 0, [0, 0]], {"critical": true}]
+		{"bundle", "82 84 07 00 00 82 00 00 a1 68 637269746963616c f5", "[1]", 8, "Missing map entry type: uint .lt 256"},
+		// [[7, 0, 0, [0, 0]], {"type": 1, "x": 1}]
+		{"bundle", "82 84 07 00 00 82 00 00 a2 64 74797065 01 61 78 01", `[1]{"x"}`, 15, `Unexpected map key "x"`},
+		// [[7, 0, 0, [0, 0]], 1]
+		{"bundle", "82 84 07 00 00 82 00 00 01", "[1]", 8, "Expected block, got unsigned(1)"},
+		{"small", "19 0100", "", 0, "Size 2 does not match 1"},
+		// [1.0 as float32, 1.0 as float32]
+		{"float-pair", "82 fa 3f800000 fa 3f800000", "[0]", 1, "Expected float16, got float(1)"},
+		{"sized", "64 61626364", "", 0, "Size 4 does not match 1..3"},
+		// h'820100', i.e., the embedded [1, 0]
+		{"embedded", "43 820100", "<<>>[1]", 3, "Expected tstr"},
+		{"embedded", "44 820000 00", "<<>>", 4, "Unexpected data after embedded CBOR"},
+		{"sequence", "42 0161", "<<>>", 2, "Invalid embedded CBOR"},
+		{"tagged", "c1 61 78", "#1", 1, "Expected uint"},
+		{"tagged", "c2 40", "", 0, "Expected #6.1(uint) / tdate, got tag(2)"},
+		{"pattern", "62 3132", "", 0, `Text "12" does not match`},
+		{"limits", "83 2a 00 00", "[0]", 1, "Expected int .ge -10, got negative(-1-10)"},
+		{"limits", "83 00 00 03", "[2]", 3, "Expected number .ne 3"},
+		{"list", "83 01 61 61 02", "[2]", 4, "Unexpected array element unsigned(2)"},
+		// [1, 1, 1, 1, 1, "a"]
+		{"counted", "86 01 01 01 01 01 61 61", "[4]", 5, "Expected tstr, got unsigned(1)"},
+	}
+
+	for _, test := range tests {
+		data, _ := hex.DecodeString(strings.ReplaceAll(test.data, " ", ""))
+		err := s.Validate(test.rule, bytes.NewBuffer(data))
+
+		var vErr *ValidationError
+		if !errors.As(err, &vErr) {
+			t.Fatalf("Validating %s against %s resulted in %v", test.data, test.rule, err)
+		} else if vErr.Path != test.path || vErr.Offset != test.offset || !strings.Contains(vErr.Message, test.msg) {
+			t.Fatalf("Validating %s against %s errored with %q at %q, %d instead of %q at %q, %d",
+				test.data, test.rule, vErr.Message, vErr.Path, vErr.Offset, test.msg, test.path, test.offset)
+		}
+	}
+}
+
+func TestValidateError(t *testing.T) {
+	s := MustParse("a = uint\ng = (a, a)\nloop = [loop] / loop")
+
+	if err := s.Validate("a", bytes.NewBuffer(nil)); !errors.Is(err, io.EOF) {
+		t.Fatalf("Validating empty input resulted in %v", err)
+	} else if err := s.Validate("b", bytes.NewBuffer([]byte{0x00})); err == nil {
+		t.Fatalf("Validating an unknown rule did not error")
+	} else if err := s.Validate("g", bytes.NewBuffer([]byte{0x00})); err == nil {
+		t.Fatalf("Validating a group rule did not error")
+	} else if err := s.Validate("loop", bytes.NewBuffer([]byte{0x00})); err == nil {
+		t.Fatalf("Validating a recursive rule did not error")
+	}
+
+	err := s.Validate("a", bytes.NewBuffer([]byte{0x20}))
+	if expected := "Validate: root at offset 0: Expected uint, got negative(-1-0)"; err == nil || err.Error() != expected {
+		t.Fatalf("Error is %v instead of %s", err, expected)
+	}
+}
+
+func TestValidateLargeArray(t *testing.T) {
+	s := MustParse("pairs = [* uint, * uint, tstr]\nlist = [* uint]")
+
+	tests := []struct {
+		rule   string
+		n      int
+		suffix []byte
+		valid  bool
+	}{
+		{"pairs", 3000, []byte{0x60}, true},
+		// Without the final tstr, every split of the elements must fail.
+		{"pairs", 3000, []byte{0x00}, false},
+		{"list", 1_000_000, nil, true},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		buff.Write([]byte{0x9f})
+		buff.Write(bytes.Repeat([]byte{0x01}, test.n))
+		buff.Write(test.suffix)
+		buff.Write([]byte{0xff})
+
+		if err := s.Validate(test.rule, &buff); (err == nil) != test.valid {
+			t.Fatalf("Validating %d elements against %s resulted in %v", test.n, test.rule, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// majorName returns a human readable name for the Major Type.
+func majorName(m MajorType) string {
+	switch m {
+	case UInt:
+		return "unsigned integer"
+	case NInt:
+		return "negative integer"
+	case ByteString:
+		return "byte string"
+	case TextString:
+		return "text string"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	case Tag:
+		return "tag"
+	case SimpleData:
+		return "simple value"
+	default:
+		return fmt.Sprintf("major type 0x%x", m)
+	}
+}
+
+// Choice decodes a data item, which might be one of multiple alternatives, by
+// dispatching on its head. For each accepted Major Type or tag number, a
+// handler must be registered, which stores the decoded alternative into the
+// value of type T passed to Decode, e.g., a pointer to a struct.
+//
+// A Choice is meant to be created once, e.g., as a package-level variable, and
+// then used by Decode, which is safe for concurrent use. As the head is already
+// read, it is passed to the handler. A Major Type's handler reads the data
+// item's remainder, e.g., a text string's content, or ReplayHead allows using
+// the usual functions, e.g., ReadUInt, from the data item's beginning. A tag
+// number's handler reads the tagged data item, after the tag. Tag numbers take
+// precedence over a handler for the Tag Major Type.
+type Choice[T any] struct {
+	// majors are indexed by the Major Type's three bits.
+	majors [8]func(v T, h Head, r io.Reader) error
+	tags   map[uint64]func(v T, h Head, r io.Reader) error
+}
+
+// NewChoice creates a Choice without any alternatives.
+func NewChoice[T any]() *Choice[T] {
+	return &Choice[T]{tags: make(map[uint64]func(v T, h Head, r io.Reader) error)}
+}
+
+// Major registers a handler for data items of the Major Type.
+func (c *Choice[T]) Major(m MajorType, f func(v T, h Head, r io.Reader) error) *Choice[T] {
+	c.majors[m>>5] = f
+	return c
+}
+
+// Tag registers a handler for data items tagged with the tag number.
+func (c *Choice[T]) Tag(n uint64, f func(v T, h Head, r io.Reader) error) *Choice[T] {
+	c.tags[n] = f
+	return c
+}
+
+// Decode reads the next head from the Reader and calls the matching handler
+// for the value. If no handler matches, a ChoiceError is returned.
+func (c *Choice[T]) Decode(v T, r io.Reader) error {
+	h, err := ReadHead(r)
+	if err != nil {
+		return err
+	}
+
+	if h.Major == Tag {
+		if f, ok := c.tags[h.Argument]; ok {
+			return f(v, h, r)
+		}
+	}
+
+	if f := c.majors[h.Major>>5]; f != nil && !h.IsBreak() {
+		return f(v, h, r)
+	}
+
+	return c.error(h)
+}
+
+// error creates a ChoiceError for the unmatched head.
+func (c *Choice[T]) error(h Head) *ChoiceError {
+	ce := &ChoiceError{Head: h}
+
+	for m, f := range c.majors {
+		if f != nil {
+			ce.Majors = append(ce.Majors, MajorType(m<<5))
+		}
+	}
+
+	for n := range c.tags {
+		ce.Tags = append(ce.Tags, n)
+	}
+	sort.Slice(ce.Tags, func(i, j int) bool { return ce.Tags[i] < ce.Tags[j] })
+
+	return ce
+}
+
+// ChoiceError is returned by Choice.Decode if no alternative matched.
+type ChoiceError struct {
+	// Head is the unmatched data item's head.
+	Head Head
+	// Majors are the accepted Major Types.
+	Majors []MajorType
+	// Tags are the accepted tag numbers.
+	Tags []uint64
+}
+
+func (ce *ChoiceError) Error() string {
+	var alternatives []string
+	for _, m := range ce.Majors {
+		alternatives = append(alternatives, majorName(m))
+	}
+	for _, n := range ce.Tags {
+		alternatives = append(alternatives, fmt.Sprintf("tag %d", n))
+	}
+
+	return fmt.Sprintf("Choice: Unexpected %v, expected one of: %s",
+		ce.Head, strings.Join(alternatives, ", "))
+}
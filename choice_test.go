@@ -0,0 +1,86 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestChoice(t *testing.T) {
+	c := NewChoice[*any]().
+		Major(UInt, func(result *any, h Head, _ io.Reader) error {
+			*result = h.Argument
+			return nil
+		}).
+		Major(TextString, func(result *any, h Head, r io.Reader) (err error) {
+			*result, err = ReadTextString(ReplayHead(h, r))
+			return
+		}).
+		Major(Array, func(result *any, h Head, r io.Reader) (err error) {
+			*result, err = ReadArrayOf(ReadUInt, ReplayHead(h, r))
+			return
+		}).
+		Major(Tag, func(result *any, h Head, r io.Reader) (err error) {
+			*result = h.Argument
+			_, err = ReadUInt(r)
+			return
+		}).
+		Tag(1, func(result *any, _ Head, r io.Reader) error {
+			n, err := ReadUInt(r)
+			*result = -int64(n)
+			return err
+		})
+
+	tests := []struct {
+		data   []byte
+		result any
+	}{
+		{[]byte{0x19, 0x03, 0xE8}, uint64(1000)},
+		{[]byte{0x61, 0x61}, "a"},
+		{[]byte{0x7A, 0x00, 0x00, 0x00, 0x01, 0x61}, "a"},
+		{[]byte{0x82, 0x17, 0x18, 0x2A}, []uint64{23, 42}},
+		{[]byte{0xC1, 0x05}, int64(-5)},
+		{[]byte{0xC2, 0x05}, uint64(2)},
+	}
+
+	for _, test := range tests {
+		// Append another item to ensure the Reader is positioned correctly.
+		buff := bytes.NewBuffer(append(test.data, 0x07))
+
+		var result any
+		if err := c.Decode(&result, buff); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(result, test.result) {
+			t.Fatalf("Result %v mismatches %v", result, test.result)
+		}
+
+		if n, err := ReadUInt(buff); err != nil {
+			t.Fatal(err)
+		} else if n != 7 {
+			t.Fatalf("Following item is %d instead of 7", n)
+		}
+	}
+}
+
+func TestChoiceError(t *testing.T) {
+	accept := func(_ *any, _ Head, _ io.Reader) error { return nil }
+	c := NewChoice[*any]().Major(Array, accept).Major(UInt, accept).Tag(1, accept)
+
+	for _, data := range [][]byte{{0x61, 0x61}, {0xC2, 0x01}, {0xFF}} {
+		var ce *ChoiceError
+		if err := c.Decode(nil, bytes.NewBuffer(data)); !errors.As(err, &ce) {
+			t.Fatalf("Input %x returned %v", data, err)
+		} else if !reflect.DeepEqual(ce.Majors, []MajorType{UInt, Array}) {
+			t.Fatalf("Accepted Major Types mismatch: %v", ce.Majors)
+		} else if !reflect.DeepEqual(ce.Tags, []uint64{1}) {
+			t.Fatalf("Accepted tags mismatch: %v", ce.Tags)
+		}
+	}
+
+	expected := "Choice: Unexpected text(1), expected one of: unsigned integer, array, tag 1"
+	if err := c.Decode(nil, bytes.NewBuffer([]byte{0x61, 0x61})); err.Error() != expected {
+		t.Fatalf("Error message %q mismatches %q", err, expected)
+	}
+}
@@ -0,0 +1,96 @@
+// Command cboring-cddl generates Go types together with their MarshalCbor and
+// UnmarshalCbor methods, as cboring-gen does, from the rules of a CDDL
+// specification, RFC 8610.
+//
+// It is meant to be used by go:generate, e.g.,
+//
+//	//go:generate go run github.com/dtn7/cboring/cmd/cboring-cddl -output bundle_cbor.go bundle.cddl
+//
+// Multiple files are concatenated into one specification. Arrays become
+// positional structs, maps with integer keys become structs, type choices
+// become unions and optional members become pointers. Types which cannot be
+// translated, e.g., tags or maps with text keys, are kept as raw CBOR data
+// items and reported on the standard error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dtn7/cboring/cddl"
+	"github.com/dtn7/cboring/internal/cddlgen"
+	"github.com/dtn7/cboring/internal/codegen"
+)
+
+func main() {
+	rules := flag.String("rule", "", "comma-separated list of rule names; all rules if empty")
+	pkg := flag.String("package", os.Getenv("GOPACKAGE"), "package name of the generated code; default is $GOPACKAGE")
+	output := flag.String("output", "", "output file name; default is the standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: cboring-cddl [flags] file...\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*rules, *pkg, *output, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "cboring-cddl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(rules, pkg, output string, files []string) error {
+	if pkg == "" {
+		pkg = "main"
+	}
+
+	var src strings.Builder
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		src.Write(data)
+		src.WriteString("\n")
+	}
+
+	schema, err := cddl.Parse(src.String())
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if rules != "" {
+		names = strings.Split(rules, ",")
+	}
+
+	result, err := cddlgen.Translate(schema, names)
+	if err != nil {
+		return err
+	}
+	for _, report := range result.Reports {
+		fmt.Fprintf(os.Stderr, "cboring-cddl: cannot translate %s\n", report)
+	}
+
+	code, err := codegen.Generate(&codegen.File{
+		Package: pkg,
+		Structs: result.Structs,
+		Unions:  result.Unions,
+		Declare: true,
+	}, "cboring-cddl")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(output, code, 0644)
+}
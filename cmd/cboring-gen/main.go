@@ -0,0 +1,88 @@
+// Command cboring-gen generates reflection-free MarshalCbor and UnmarshalCbor
+// methods for structs annotated with cbor struct tags.
+//
+// It is meant to be used by go:generate, e.g.,
+//
+//	//go:generate go run github.com/dtn7/cboring/cmd/cboring-gen -type PrimaryBlock
+//
+// A field's tag is `cbor:"KEY[,OPTION...]"`. By default, a struct is encoded
+// as a map with KEY as its integer key. A blank field tagged with
+// `cbor:",asarray"` encodes the struct as an array, with KEY being the field's
+// position. The following options are supported:
+//
+//   - omitempty: omits a map field with an empty value. An omitted field is
+//     reset to its zero value, while the keys of all other fields are
+//     required. An omitempty pointer is nil if omitted and never null.
+//   - raw: a []byte field holds an encoded CBOR data item, written as it is.
+//   - unknown: a []cboring.RawPair field preserves the pairs of unknown keys.
+//   - optional: a trailing pointer field of an array might be missing, being
+//     nil then, e.g., `cbor:"6,optional"` for a CRC only present if needed.
+//   - rest: the last slice field of an array holds all remaining elements,
+//     e.g., `cbor:"2,rest"` for [type, flags, entry, entry, ...].
+//   - const=N: a blank integer field of an array is always N, written as it
+//     is and checked while reading, e.g., `_ uint8 cbor:"0,const=7"` for a
+//     version number.
+//
+// Supported field types are integers, bool, string, []byte, float32, float64,
+// named types implementing cboring.CborMarshaler by pointer, slices of these
+// and pointers to these for optional values, encoded as null if nil.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dtn7/cboring/internal/codegen"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of struct names; all tagged structs if empty")
+	output := flag.String("output", "", "output file name; default is <file>_cbor.go")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: cboring-gen [flags] [file]\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "The file defaults to $GOFILE, as set by go generate.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	input := os.Getenv("GOFILE")
+	if flag.NArg() > 0 {
+		input = flag.Arg(0)
+	}
+	if input == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(input, *typeNames, *output); err != nil {
+		fmt.Fprintf(os.Stderr, "cboring-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(input, typeNames, output string) error {
+	var names []string
+	if typeNames != "" {
+		names = strings.Split(typeNames, ",")
+	}
+
+	f, err := codegen.ParseFile(input, nil, names)
+	if err != nil {
+		return err
+	} else if len(f.Structs) == 0 {
+		return fmt.Errorf("%s: no structs with cbor struct tags", input)
+	}
+
+	src, err := codegen.Generate(f, "cboring-gen")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + "_cbor.go"
+	}
+	return os.WriteFile(output, src, 0644)
+}
@@ -0,0 +1,102 @@
+// Command cboring-infer infers Go struct definitions from sample CBOR data
+// items and generates them together with their MarshalCbor and UnmarshalCbor
+// methods, as cboring-gen does.
+//
+// Each input file contains one or more samples as a sequence of CBOR data
+// items. Without files, the samples are read from the standard input. All
+// samples must share the same structure, either arrays of a fixed shape or maps
+// with integer keys.
+//
+// Fields whose types cannot be inferred, e.g., due to conflicting samples, are
+// kept as raw CBOR data items and reported on the standard error.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dtn7/cboring"
+	"github.com/dtn7/cboring/internal/codegen"
+	"github.com/dtn7/cboring/internal/infer"
+)
+
+func main() {
+	name := flag.String("name", "Sample", "name of the root struct")
+	pkg := flag.String("package", "main", "package name of the generated code")
+	output := flag.String("output", "", "output file name; default is the standard output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: cboring-infer [flags] [file...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*name, *pkg, *output, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "cboring-infer: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// readSamples reads all data items of a CBOR sequence.
+func readSamples(r io.Reader) (samples []*cboring.Item, err error) {
+	br := bufio.NewReader(r)
+	for {
+		item, itemErr := cboring.ReadItem(br)
+		if itemErr == io.EOF {
+			return
+		} else if itemErr != nil {
+			return nil, fmt.Errorf("sample %d: %w", len(samples), itemErr)
+		}
+		samples = append(samples, item)
+	}
+}
+
+func run(name, pkg, output string, files []string) error {
+	var samples []*cboring.Item
+	if len(files) == 0 {
+		s, err := readSamples(os.Stdin)
+		if err != nil {
+			return err
+		}
+		samples = s
+	}
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+
+		s, err := readSamples(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		samples = append(samples, s...)
+	}
+
+	result, err := infer.Infer(name, samples)
+	if err != nil {
+		return err
+	}
+	for _, report := range result.Reports {
+		fmt.Fprintf(os.Stderr, "cboring-infer: cannot infer %s\n", report)
+	}
+
+	src, err := codegen.Generate(&codegen.File{
+		Package: pkg,
+		Structs: result.Structs,
+		Declare: true,
+	}, "cboring-infer")
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(output, src, 0644)
+}
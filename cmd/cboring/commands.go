@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/dtn7/cboring"
+	"github.com/dtn7/cboring/cborjson"
+	"github.com/dtn7/cboring/internal/inspect"
+)
+
+// forEachItem reads the data items of a CBOR sequence and calls f with each
+// item, its index and its encoding's bounds within the data.
+func forEachItem(data []byte, f func(item *cboring.Item, index int, start, end int64) error) error {
+	r := bytes.NewReader(data)
+	for index := 0; r.Len() > 0; index++ {
+		start := int64(len(data) - r.Len())
+		item, err := cboring.ReadItem(r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fmt.Errorf("data item %d at offset %d is not well-formed: %w", index, start, err)
+		}
+
+		if err := f(item, index, start, int64(len(data)-r.Len())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diag(_ *flag.FlagSet) func(data []byte, w io.Writer) error {
+	return func(data []byte, w io.Writer) error {
+		return forEachItem(data, func(item *cboring.Item, _ int, _, end int64) error {
+			if end < int64(len(data)) {
+				return fmt.Errorf("trailing data after offset %d, use the seq command for CBOR sequences", end)
+			}
+
+			_, err := fmt.Fprintln(w, inspect.Diagnostic(item))
+			return err
+		})
+	}
+}
+
+func dump(_ *flag.FlagSet) func(data []byte, w io.Writer) error {
+	return func(data []byte, w io.Writer) error {
+		return cboring.Dump(bytes.NewReader(data), w)
+	}
+}
+
+func check(_ *flag.FlagSet) func(data []byte, w io.Writer) error {
+	return func(data []byte, w io.Writer) error {
+		var items, problems int
+		err := forEachItem(data, func(item *cboring.Item, index int, start, end int64) error {
+			items++
+			for _, p := range inspect.Check(item, data[start:end]) {
+				problems++
+				fmt.Fprintf(w, "data item %d, offset %d: %s\n", index, start+p.Offset, p.Message)
+			}
+			return nil
+		})
+
+		switch {
+		case err != nil:
+			return err
+		case items == 0:
+			return fmt.Errorf("no data item")
+		case problems > 0:
+			return errReported
+		}
+
+		_, err = fmt.Fprintf(w, "%d data item(s) well-formed, valid and deterministically encoded\n", items)
+		return err
+	}
+}
+
+func convert(fs *flag.FlagSet) func(data []byte, w io.Writer) error {
+	out := fs.String("out", inspect.Hex, "output format: binary, hex or base64")
+	return func(data []byte, w io.Writer) error {
+		converted, err := inspect.Encode(data, *out)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(converted)
+		return err
+	}
+}
+
+func seq(_ *flag.FlagSet) func(data []byte, w io.Writer) error {
+	return func(data []byte, w io.Writer) error {
+		return forEachItem(data, func(item *cboring.Item, index int, start, end int64) error {
+			_, err := fmt.Fprintf(w, "%d\t@%d+%d\t%s\n", index, start, end-start, inspect.Diagnostic(item))
+			return err
+		})
+	}
+}
+
+func diff(fs *flag.FlagSet) func(data []byte, w io.Writer) error {
+	ignoreEncoding := fs.Bool("ignore-encoding", false, "ignore differences of the encoding alone")
+	return func(data []byte, w io.Writer) error {
+		r := bytes.NewReader(data)
+		diffs, err := cboring.Diff(r, r, *ignoreEncoding)
+		if err != nil {
+			return err
+		} else if r.Len() > 0 {
+			return fmt.Errorf("trailing data after the second data item")
+		}
+
+		for _, d := range diffs {
+			if _, err := fmt.Fprintln(w, d); err != nil {
+				return err
+			}
+		}
+		if len(diffs) > 0 {
+			return errReported
+		}
+		return nil
+	}
+}
+
+func toJSON(_ *flag.FlagSet) func(data []byte, w io.Writer) error {
+	return func(data []byte, w io.Writer) error {
+		r := bytes.NewReader(data)
+		for {
+			err := cborjson.ToJSON(r, w)
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func fromJSON(fs *flag.FlagSet) func(data []byte, w io.Writer) error {
+	out := fs.String("out", inspect.Hex, "output format: binary, hex or base64")
+	shortestFloat := fs.Bool("shortest-float", false, "encode numbers with a fraction in the shortest floating-point encoding")
+	return func(data []byte, w io.Writer) error {
+		var buff bytes.Buffer
+		if err := cborjson.FromJSON(bytes.NewReader(data), &buff, *shortestFloat); err != nil {
+			return err
+		}
+
+		converted, err := inspect.Encode(buff.Bytes(), *out)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(converted)
+		return err
+	}
+}
+
+func query(fs *flag.FlagSet) func(data []byte, w io.Writer) error {
+	pathFlag := fs.String("path", "", `path of the data item, e.g., [0]{"k"}#24<<>>[1]`)
+	out := fs.String("out", "diag", "output format: diag, binary, hex or base64")
+	return func(data []byte, w io.Writer) error {
+		path, err := cboring.ParsePath(*pathFlag)
+		if err != nil {
+			return err
+		}
+
+		raw, err := cboring.Query(bytes.NewReader(data), path)
+		if err == io.EOF {
+			return fmt.Errorf("no data item")
+		} else if err != nil {
+			return err
+		}
+
+		if *out == "diag" {
+			item, err := cboring.ReadItem(bytes.NewReader(raw))
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(w, inspect.Diagnostic(item))
+			return err
+		}
+
+		converted, err := inspect.Encode(raw, *out)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(converted)
+		return err
+	}
+}
@@ -0,0 +1,122 @@
+// Command cboring inspects CBOR data with the cboring library's own decoder.
+//
+// Usage:
+//
+//	cboring <command> [flags] [file...]
+//
+// The data is read from the concatenated files or, without files, from the
+// standard input. The commands are:
+//
+//	diag     print a single data item in diagnostic notation
+//	dump     print an annotated hex dump of each head and string
+//	check    check well-formedness, validity and deterministic encoding
+//	convert  convert between the binary, hex and base64 formats
+//	seq      print the data items of a CBOR sequence one by one
+//	diff     print the structural differences of two data items
+//	query    print the data item nested at a path
+//	tojson   convert each data item into a line of JSON
+//	fromjson convert JSON values into CBOR data items
+//
+// Each command accepts the -in flag to read hex or base64 instead of binary
+// data. The hex input might contain comments starting with "#", e.g., as
+// printed by the dump command. The diff command compares the first two data
+// items of the input, e.g., of two files. The fromjson command reads JSON
+// input, where -in should be left as binary. The query command's -path flag
+// takes a path such as [0][3], {"k"}, {1}, {0x8201}, #24 or <<>>, as printed
+// by the diff command.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dtn7/cboring/internal/inspect"
+)
+
+// command of the cboring tool. Its setup registers the command's flags and
+// returns the function processing the input data.
+type command struct {
+	usage string
+	setup func(fs *flag.FlagSet) func(data []byte, w io.Writer) error
+}
+
+var commands = map[string]command{
+	"diag":     {"print a single data item in diagnostic notation", diag},
+	"dump":     {"print an annotated hex dump of each head and string", dump},
+	"check":    {"check well-formedness, validity and deterministic encoding", check},
+	"convert":  {"convert between the binary, hex and base64 formats", convert},
+	"seq":      {"print the data items of a CBOR sequence one by one", seq},
+	"diff":     {"print the structural differences of two data items", diff},
+	"query":    {"print the data item nested at a path", query},
+	"tojson":   {"convert each data item into a line of JSON", toJSON},
+	"fromjson": {"convert JSON values into CBOR data items", fromJSON},
+}
+
+// errReported signals a failed check or found differences, which were already
+// reported.
+var errReported = errors.New("reported")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: cboring <command> [flags] [file...]\n\nCommands:\n")
+	for _, name := range []string{"diag", "dump", "check", "convert", "seq", "diff", "query", "tojson", "fromjson"} {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, commands[name].usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		usage()
+		os.Exit(2)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	in := fs.String("in", inspect.Binary, "input format: binary, hex or base64")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cboring %s [flags] [file...]\n\n", name)
+		fs.PrintDefaults()
+	}
+
+	process := cmd.setup(fs)
+	_ = fs.Parse(os.Args[2:])
+
+	err := run(fs.Args(), *in, process)
+	if errors.Is(err, errReported) {
+		os.Exit(1)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "cboring %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func run(files []string, in string, process func(data []byte, w io.Writer) error) error {
+	var input bytes.Buffer
+	if len(files) == 0 {
+		if _, err := io.Copy(&input, os.Stdin); err != nil {
+			return err
+		}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		input.Write(data)
+	}
+
+	data, err := inspect.Decode(input.Bytes(), in)
+	if err != nil {
+		return err
+	}
+	return process(data, os.Stdout)
+}
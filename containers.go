@@ -0,0 +1,213 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// MaxContainerLength limits the amount of elements of an array or pairs of a
+// map to be read by ReadArrayOf, ReadArrayOfMarshaler and ReadMapOf, as well
+// as by the reflectcbor package and code generated by cboring-gen. This is a
+// mitigation against resource exhaustion by crafted input.
+//
+// The limit is shared by all callers and is read without synchronization.
+// Thus, it must only be changed during the program's initialization, e.g., in
+// an init function, and not after any decoding has started.
+var MaxContainerLength uint64 = 1 << 20
+
+// readContainer expects an array or map, based on major, of definite or
+// indefinite length at the Reader's position and calls the function f for each
+// element or pair. The function's reader must be used for reading.
+func readContainer(major MajorType, r io.Reader, f func(i uint64, r io.Reader) error) error {
+	h, err := ReadHead(r)
+	if err != nil {
+		return err
+	} else if flag := nullFlag(h); flag != nil {
+		return flag
+	} else if h.Major != major || h.IsBreak() {
+		return fmt.Errorf("Wrong Major Type: 0x%x instead of 0x%x", h.Major, major)
+	}
+
+	if !h.Indefinite {
+		if h.Argument > MaxContainerLength {
+			return fmt.Errorf("Length %d exceeds the maximum of %d", h.Argument, MaxContainerLength)
+		}
+
+		for i := uint64(0); i < h.Argument; i++ {
+			if err := f(i, r); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	pr := &peekReader{r: r}
+	for i := uint64(0); ; i++ {
+		if b, err := pr.peek(); err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		} else if b == BreakCode {
+			pr.discard()
+			return nil
+		}
+
+		if i >= MaxContainerLength {
+			return fmt.Errorf("Length exceeds the maximum of %d", MaxContainerLength)
+		}
+		if err := f(i, pr); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadArrayOf expects an array of definite or indefinite length at the Reader's
+// position and reads each element by the readElem function, e.g., ReadUInt.
+func ReadArrayOf[T any](readElem func(r io.Reader) (T, error), r io.Reader) ([]T, error) {
+	elems, err := readArray(readElem, r)
+	if err != nil {
+		return nil, fmt.Errorf("ReadArrayOf: %w", err)
+	}
+	return elems, nil
+}
+
+// readArray implements ReadArrayOf without prefixing its errors, which is left
+// to the exported function being called.
+func readArray[T any](readElem func(r io.Reader) (T, error), r io.Reader) ([]T, error) {
+	elems := []T{}
+	err := readContainer(Array, r, func(i uint64, r io.Reader) error {
+		elem, err := readElem(r)
+		if err != nil {
+			return fmt.Errorf("Element %d: %w", i, err)
+		}
+
+		elems = append(elems, elem)
+		return nil
+	})
+	return elems, err
+}
+
+// ReadArrayOfMarshaler expects an array of definite or indefinite length at the
+// Reader's position and unmarshals each element into a new CborMarshaler,
+// created by the newElem function.
+func ReadArrayOfMarshaler[T CborMarshaler](newElem func() T, r io.Reader) ([]T, error) {
+	elems, err := readArray(func(r io.Reader) (T, error) {
+		elem := newElem()
+		return elem, elem.UnmarshalCbor(r)
+	}, r)
+
+	if err != nil {
+		return nil, fmt.Errorf("ReadArrayOfMarshaler: %w", err)
+	}
+	return elems, nil
+}
+
+// WriteArrayOf writes an array of definite length into the Writer and writes
+// each element by the writeElem function, e.g., WriteUInt.
+func WriteArrayOf[T any](elems []T, writeElem func(elem T, w io.Writer) error, w io.Writer) error {
+	if err := WriteArrayLength(uint64(len(elems)), w); err != nil {
+		return err
+	}
+
+	if i, err := writeElems(elems, writeElem, w); err != nil {
+		return fmt.Errorf("WriteArrayOf: Element %d: %w", i, err)
+	}
+	return nil
+}
+
+// writeElems writes each element by the writeElem function, returning the
+// failing element's index.
+func writeElems[T any](elems []T, writeElem func(elem T, w io.Writer) error, w io.Writer) (int, error) {
+	for i, elem := range elems {
+		if err := writeElem(elem, w); err != nil {
+			return i, err
+		}
+	}
+	return 0, nil
+}
+
+// WriteArrayOfMarshaler writes an array of definite length of CborMarshalers
+// into the Writer.
+func WriteArrayOfMarshaler[T CborMarshaler](elems []T, w io.Writer) error {
+	if err := WriteArrayLength(uint64(len(elems)), w); err != nil {
+		return err
+	}
+
+	marshal := func(elem T, w io.Writer) error { return elem.MarshalCbor(w) }
+	if i, err := writeElems(elems, marshal, w); err != nil {
+		return fmt.Errorf("WriteArrayOfMarshaler: Element %d: %w", i, err)
+	}
+	return nil
+}
+
+// ReadMapOf expects a map of definite or indefinite length at the Reader's
+// position and reads each pair by the readKey and readValue functions. An
+// error is returned for duplicate keys.
+func ReadMapOf[K comparable, V any](
+	readKey func(r io.Reader) (K, error), readValue func(r io.Reader) (V, error), r io.Reader,
+) (m map[K]V, err error) {
+	m = make(map[K]V)
+	err = readContainer(Map, r, func(i uint64, r io.Reader) error {
+		key, keyErr := readKey(r)
+		if keyErr != nil {
+			return fmt.Errorf("Key of pair %d: %w", i, keyErr)
+		} else if _, exists := m[key]; exists {
+			return fmt.Errorf("Duplicate key %v of pair %d", key, i)
+		}
+
+		value, valueErr := readValue(r)
+		if valueErr != nil {
+			return fmt.Errorf("Value of pair %d, key %v: %w", i, key, valueErr)
+		}
+
+		m[key] = value
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("ReadMapOf: %w", err)
+	}
+	return
+}
+
+// WriteMapOf writes a map of definite length into the Writer and writes each
+// pair by the writeKey and writeValue functions. The pairs are sorted by the
+// bytewise lexicographic order of their encoded keys, as required for the
+// deterministic encoding in RFC 8949, section 4.2.1.
+func WriteMapOf[K comparable, V any](
+	m map[K]V, writeKey func(key K, w io.Writer) error, writeValue func(value V, w io.Writer) error, w io.Writer,
+) error {
+	type pair struct {
+		encodedKey []byte
+		key        K
+	}
+
+	pairs := make([]pair, 0, len(m))
+	for key := range m {
+		var buff bytes.Buffer
+		if err := writeKey(key, &buff); err != nil {
+			return fmt.Errorf("WriteMapOf: Key %v: %w", key, err)
+		}
+		pairs = append(pairs, pair{buff.Bytes(), key})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].encodedKey, pairs[j].encodedKey) < 0
+	})
+
+	if err := WriteMapPairLength(uint64(len(pairs)), w); err != nil {
+		return err
+	}
+
+	for _, p := range pairs {
+		if _, err := w.Write(p.encodedKey); err != nil {
+			return err
+		}
+		if err := writeValue(m[p.key], w); err != nil {
+			return fmt.Errorf("WriteMapOf: Value of key %v: %w", p.key, err)
+		}
+	}
+	return nil
+}
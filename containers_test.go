@@ -0,0 +1,184 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestArrayOf(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		elems []uint64
+	}{
+		{[]byte{0x80}, []uint64{}},
+		{[]byte{0x83, 0x01, 0x02, 0x18, 0x64}, []uint64{1, 2, 100}},
+	}
+
+	for _, test := range tests {
+		// Read
+		if elems, err := ReadArrayOf(ReadUInt, bytes.NewBuffer(test.data)); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(elems, test.elems) {
+			t.Fatalf("Elements mismatch: %v != %v", elems, test.elems)
+		}
+
+		// Write
+		var buff bytes.Buffer
+		if err := WriteArrayOf(test.elems, WriteUInt, &buff); err != nil {
+			t.Fatal(err)
+		} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.data)
+		}
+	}
+}
+
+func TestReadArrayOfIndefinite(t *testing.T) {
+	// [_ "a", "b"] 7
+	data := []byte{0x9F, 0x61, 0x61, 0x61, 0x62, 0xFF, 0x07}
+	buff := bytes.NewBuffer(data)
+
+	if elems, err := ReadArrayOf(ReadTextString, buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(elems, []string{"a", "b"}) {
+		t.Fatalf("Elements mismatch: %v", elems)
+	}
+
+	if n, err := ReadUInt(buff); err != nil {
+		t.Fatal(err)
+	} else if n != 7 {
+		t.Fatalf("Following item is %d instead of 7", n)
+	}
+}
+
+func TestReadArrayOfError(t *testing.T) {
+	tests := []struct {
+		data []byte
+		msg  string
+	}{
+		{[]byte{0x82, 0x01, 0x61, 0x61}, "Element 1"},
+		{[]byte{0x9F, 0x01, 0x02}, "unexpected EOF"},
+		{[]byte{0xA0}, "Wrong Major Type"},
+		{[]byte{0x9A, 0xFF, 0xFF, 0xFF, 0xFF}, "maximum"},
+	}
+
+	for _, test := range tests {
+		if _, err := ReadArrayOf(ReadUInt, bytes.NewBuffer(test.data)); err == nil {
+			t.Fatalf("Illegal input %x did not error", test.data)
+		} else if !strings.Contains(err.Error(), test.msg) {
+			t.Fatalf("Error %q does not contain %q", err, test.msg)
+		}
+	}
+}
+
+func TestArrayOfMarshaler(t *testing.T) {
+	elems := []*embeddedTestItem{{A: 1, B: 2}, {A: 3, B: 4}}
+	data := []byte{0x82, 0x82, 0x01, 0x02, 0x82, 0x03, 0x04}
+
+	var buff bytes.Buffer
+	if err := WriteArrayOfMarshaler(elems, &buff); err != nil {
+		t.Fatal(err)
+	} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, data) {
+		t.Fatalf("Serialized data mismatches: %x != %x", bb, data)
+	}
+
+	newElem := func() *embeddedTestItem { return &embeddedTestItem{} }
+	if readElems, err := ReadArrayOfMarshaler(newElem, &buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(readElems, elems) {
+		t.Fatalf("Elements mismatch: %v != %v", readElems, elems)
+	}
+}
+
+func TestMapOf(t *testing.T) {
+	m := map[string]uint64{"b": 2, "a": 1, "aa": 3}
+	data := []byte{0xA3, 0x61, 0x61, 0x01, 0x61, 0x62, 0x02, 0x62, 0x61, 0x61, 0x03}
+
+	var buff bytes.Buffer
+	if err := WriteMapOf(m, WriteTextString, WriteUInt, &buff); err != nil {
+		t.Fatal(err)
+	} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, data) {
+		t.Fatalf("Serialized data mismatches: %x != %x", bb, data)
+	}
+
+	if readMap, err := ReadMapOf(ReadTextString, ReadUInt, &buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(readMap, m) {
+		t.Fatalf("Maps mismatch: %v != %v", readMap, m)
+	}
+
+	// {_ 1: true}
+	indefinite := []byte{0xBF, 0x01, 0xF5, 0xFF}
+	if readMap, err := ReadMapOf(ReadUInt, ReadBoolean, bytes.NewBuffer(indefinite)); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(readMap, map[uint64]bool{1: true}) {
+		t.Fatalf("Map mismatches: %v", readMap)
+	}
+}
+
+func TestReadMapOfError(t *testing.T) {
+	tests := [][]byte{
+		// Duplicate key
+		{0xA2, 0x01, 0x01, 0x01, 0x02},
+		// Wrong value
+		{0xA1, 0x01, 0x61, 0x61},
+		// Odd indefinite-length map
+		{0xBF, 0x01, 0xFF},
+	}
+
+	for _, test := range tests {
+		if _, err := ReadMapOf(ReadUInt, ReadUInt, bytes.NewBuffer(test)); err == nil {
+			t.Fatalf("Illegal input %x did not error", test)
+		}
+	}
+}
+
+func TestWriteArrayOfError(t *testing.T) {
+	failing := func(n uint64, w io.Writer) error {
+		if n == 2 {
+			return io.ErrShortWrite
+		}
+		return WriteUInt(n, w)
+	}
+
+	if err := WriteArrayOf([]uint64{1, 2}, failing, io.Discard); err == nil {
+		t.Fatal("Failing element did not error")
+	} else if !strings.Contains(err.Error(), "Element 1") {
+		t.Fatalf("Error %q does not contain the element's index", err)
+	}
+}
+
+// failingWriter fails after accepting n bytes.
+type failingWriter struct {
+	n int
+}
+
+func (fw *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.n {
+		return 0, io.ErrShortWrite
+	}
+	fw.n -= len(p)
+	return len(p), nil
+}
+
+// TestArrayOfMarshalerError ensures errors are prefixed once by the called
+// function, not additionally by the wrapped one.
+func TestArrayOfMarshalerError(t *testing.T) {
+	newElem := func() *embeddedTestItem { return &embeddedTestItem{} }
+	if _, err := ReadArrayOfMarshaler(newElem, bytes.NewBuffer([]byte{0x81, 0x01})); err == nil {
+		t.Fatal("Wrong element did not error")
+	} else if msg := err.Error(); !strings.HasPrefix(msg, "ReadArrayOfMarshaler: Element 0: ") ||
+		strings.Contains(msg, "ReadArrayOf:") {
+		t.Fatalf("Error %q is not prefixed once", msg)
+	}
+
+	elems := []*embeddedTestItem{{A: 1, B: 2}}
+	if err := WriteArrayOfMarshaler(elems, &failingWriter{n: 1}); err == nil {
+		t.Fatal("Failing element did not error")
+	} else if msg := err.Error(); !strings.HasPrefix(msg, "WriteArrayOfMarshaler: Element 0: ") ||
+		strings.Contains(msg, "WriteArrayOf:") {
+		t.Fatalf("Error %q is not prefixed once", msg)
+	}
+}
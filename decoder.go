@@ -0,0 +1,352 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Token holds a value of one of these types:
+//
+//	ArrayStart, ArrayEnd, MapStart, MapEnd, TagNumber,
+//	uint64, for unsigned integers,
+//	int64, for negative integers fitting into an int64, otherwise NegativeInt,
+//	[]byte, for byte strings,
+//	string, for text strings,
+//	bool, nil, for null, UndefinedValue or SimpleValue, for other simple values,
+//	float32, for half-precision and single-precision floating-point values,
+//	float64, for double-precision floating-point values.
+//
+// Indefinite-length strings are concatenated into a single token.
+type Token any
+
+// ArrayStart starts an array. For an indefinite-length array, Length is zero.
+type ArrayStart struct {
+	Length     uint64
+	Indefinite bool
+}
+
+// ArrayEnd ends the innermost array.
+type ArrayEnd struct{}
+
+// MapStart starts a map. For an indefinite-length map, Length is zero. In the
+// following, keys and values alternate.
+type MapStart struct {
+	Length     uint64
+	Indefinite bool
+}
+
+// MapEnd ends the innermost map.
+type MapEnd struct{}
+
+// TagNumber is a tag, which is followed by the tokens of the tagged data item.
+type TagNumber uint64
+
+// NegativeInt is a negative integer -1-n, which does not fit into an int64.
+type NegativeInt uint64
+
+// UndefinedValue is the simple value undefined.
+type UndefinedValue struct{}
+
+// SimpleValue is an unassigned simple value.
+type SimpleValue byte
+
+// peekReader allows peeking one byte of the underlying Reader without reading
+// any further and counts the consumed bytes.
+type peekReader struct {
+	r      io.Reader
+	b      byte
+	peeked bool
+	offset int64
+}
+
+func (pr *peekReader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return
+	}
+
+	if pr.peeked {
+		p[0] = pr.b
+		pr.peeked = false
+		n = 1
+	} else {
+		n, err = pr.r.Read(p)
+	}
+
+	pr.offset += int64(n)
+	return
+}
+
+// peek returns the next byte without consuming it.
+func (pr *peekReader) peek() (byte, error) {
+	if !pr.peeked {
+		var buff [1]byte
+		if _, err := io.ReadFull(pr.r, buff[:]); err != nil {
+			return 0, err
+		}
+
+		pr.b = buff[0]
+		pr.peeked = true
+	}
+	return pr.b, nil
+}
+
+// discard consumes the peeked byte.
+func (pr *peekReader) discard() {
+	if pr.peeked {
+		pr.peeked = false
+		pr.offset++
+	}
+}
+
+// decoderFrame is an open container or tag on the Decoder's stack.
+type decoderFrame struct {
+	major      MajorType
+	indefinite bool
+	// remaining data items for a definite-length container or a tag
+	remaining uint64
+	// items read so far, to check the parity of an indefinite-length map
+	items uint64
+}
+
+// Decoder reads a stream of CBOR data items token by token, similar to
+// encoding/json's Decoder.Token. The Decoder keeps track of the nesting, so
+// that containers are finished with ArrayEnd or MapEnd tokens.
+//
+// The Decoder reads at most one byte beyond the current token, which is only
+// necessary to detect the end of an indefinite-length container.
+type Decoder struct {
+	r     *peekReader
+	stack []decoderFrame
+	depth int
+}
+
+// NewDecoder creates a Decoder reading from the Reader.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: &peekReader{r: r}}
+}
+
+// Depth returns the amount of open arrays and maps.
+func (d *Decoder) Depth() int {
+	return d.depth
+}
+
+// InputOffset returns the amount of bytes consumed by all tokens so far.
+func (d *Decoder) InputOffset() int64 {
+	return d.r.offset
+}
+
+// More checks if there is another data item in the current array or map or, at
+// the top level, in the input stream.
+func (d *Decoder) More() bool {
+	if len(d.stack) == 0 {
+		_, err := d.r.peek()
+		return err == nil
+	}
+
+	switch top := d.stack[len(d.stack)-1]; {
+	case top.major == Tag:
+		return true
+	case top.indefinite:
+		b, err := d.r.peek()
+		return err == nil && b != BreakCode
+	default:
+		return top.remaining > 0
+	}
+}
+
+// itemDone marks a data item as completed for the innermost container. A tag
+// is completed by its data item as well.
+func (d *Decoder) itemDone() {
+	for len(d.stack) > 0 {
+		top := &d.stack[len(d.stack)-1]
+		if top.major == Tag {
+			d.stack = d.stack[:len(d.stack)-1]
+			continue
+		}
+
+		if !top.indefinite {
+			top.remaining--
+		}
+		top.items++
+		return
+	}
+}
+
+// end pops the innermost container and returns its end token.
+func (d *Decoder) end() (Token, error) {
+	top := d.stack[len(d.stack)-1]
+	if top.major == Map && top.items%2 != 0 {
+		return nil, fmt.Errorf("Decoder: Map with an odd number of %d data items", top.items)
+	}
+
+	d.stack = d.stack[:len(d.stack)-1]
+	d.depth--
+	d.itemDone()
+
+	if top.major == Array {
+		return ArrayEnd{}, nil
+	}
+	return MapEnd{}, nil
+}
+
+// Token returns the next Token of the input stream. At the end of the input
+// stream, io.EOF is returned. If the input ends within a data item,
+// io.ErrUnexpectedEOF is returned.
+func (d *Decoder) Token() (Token, error) {
+	if len(d.stack) > 0 {
+		if top := d.stack[len(d.stack)-1]; top.major != Tag {
+			if !top.indefinite && top.remaining == 0 {
+				return d.end()
+			} else if top.indefinite {
+				if b, err := d.r.peek(); err != nil {
+					return nil, d.unexpectedEOF(err)
+				} else if b == BreakCode {
+					d.r.discard()
+					return d.end()
+				}
+			}
+		}
+	}
+
+	h, err := ReadHead(d.r)
+	if err != nil {
+		return nil, d.unexpectedEOF(err)
+	}
+
+	var token Token
+	switch h.Major {
+	case UInt:
+		token = h.Argument
+
+	case NInt:
+		if h.Argument > math.MaxInt64 {
+			token = NegativeInt(h.Argument)
+		} else {
+			token = ^int64(h.Argument)
+		}
+
+	case ByteString, TextString:
+		data, err := d.readString(h)
+		if err != nil {
+			return nil, err
+		}
+
+		if h.Major == ByteString {
+			token = data
+		} else {
+			token = string(data)
+		}
+
+	case Array, Map:
+		remaining := h.Argument
+		if h.Major == Map {
+			if remaining > math.MaxUint64/2 {
+				return nil, fmt.Errorf("Decoder: Map of %d pairs is too large", remaining)
+			}
+			remaining *= 2
+		}
+
+		d.stack = append(d.stack, decoderFrame{
+			major:      h.Major,
+			indefinite: h.Indefinite,
+			remaining:  remaining,
+		})
+		d.depth++
+
+		if h.Major == Array {
+			return ArrayStart{Length: h.Argument, Indefinite: h.Indefinite}, nil
+		}
+		return MapStart{Length: h.Argument, Indefinite: h.Indefinite}, nil
+
+	case Tag:
+		d.stack = append(d.stack, decoderFrame{major: Tag, remaining: 1})
+		return TagNumber(h.Argument), nil
+
+	default:
+		if token, err = simpleToken(h); err != nil {
+			return nil, err
+		}
+	}
+
+	d.itemDone()
+	return token, nil
+}
+
+// unexpectedEOF converts an io.EOF within a data item to io.ErrUnexpectedEOF.
+func (d *Decoder) unexpectedEOF(err error) error {
+	if err == io.EOF && len(d.stack) > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readString reads a definite-length or indefinite-length string's content.
+func (d *Decoder) readString(h Head) (data []byte, err error) {
+	return readStringContent(h, d.r)
+}
+
+// readStringContent reads the content of a string after its head. The chunks
+// of an indefinite-length string are concatenated.
+func readStringContent(h Head, r io.Reader) (data []byte, err error) {
+	if !h.Indefinite {
+		data, err = ReadRawBytes(h.Argument, r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return
+	}
+
+	data = []byte{}
+	for {
+		chunk, chunkErr := ReadHead(r)
+		if chunkErr == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if chunkErr != nil {
+			return nil, chunkErr
+		}
+
+		if chunk.IsBreak() {
+			return
+		} else if chunk.Major != h.Major || chunk.Indefinite {
+			return nil, fmt.Errorf("Decoder: Illegal chunk %v in indefinite-length string", chunk)
+		}
+
+		chunkData, chunkErr := ReadRawBytes(chunk.Argument, r)
+		if chunkErr == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if chunkErr != nil {
+			return nil, chunkErr
+		}
+		data = append(data, chunkData...)
+	}
+}
+
+// simpleToken converts the head of a simple value or floating-point value.
+func simpleToken(h Head) (Token, error) {
+	switch h.Info {
+	case simpleFalse:
+		return false, nil
+	case simpleTrue:
+		return true, nil
+	case simpleNull:
+		return nil, nil
+	case simpleUndefined:
+		return UndefinedValue{}, nil
+	case 25:
+		return halfToFloat32(uint16(h.Argument)), nil
+	case 26:
+		return math.Float32frombits(uint32(h.Argument)), nil
+	case 27:
+		return math.Float64frombits(h.Argument), nil
+	case 24:
+		if h.Argument < 32 {
+			return nil, fmt.Errorf("Decoder: Simple value %d in two bytes is not well-formed", h.Argument)
+		}
+		return SimpleValue(h.Argument), nil
+	case 31:
+		return nil, fmt.Errorf("Decoder: Unexpected break stop code")
+	default:
+		return SimpleValue(h.Argument), nil
+	}
+}
@@ -0,0 +1,138 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func readAllTokens(d *Decoder) (tokens []Token, err error) {
+	for {
+		token, tokenErr := d.Token()
+		if tokenErr == io.EOF {
+			return
+		} else if tokenErr != nil {
+			err = tokenErr
+			return
+		}
+
+		tokens = append(tokens, token)
+	}
+}
+
+func TestDecoderToken(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		tokens []Token
+	}{
+		{[]byte{0x00, 0x20, 0x3B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+			[]Token{uint64(0), int64(-1), NegativeInt(math.MaxUint64)}},
+		{[]byte{0x43, 0x01, 0x02, 0x03, 0x64, 0x49, 0x45, 0x54, 0x46},
+			[]Token{[]byte{0x01, 0x02, 0x03}, "IETF"}},
+		{[]byte{0x5F, 0x42, 0x01, 0x02, 0x41, 0x03, 0xFF, 0x7F, 0x61, 0x61, 0x61, 0x62, 0xFF},
+			[]Token{[]byte{0x01, 0x02, 0x03}, "ab"}},
+		{[]byte{0xF4, 0xF5, 0xF6, 0xF7, 0xF0, 0xF8, 0xFF},
+			[]Token{false, true, nil, UndefinedValue{}, SimpleValue(16), SimpleValue(255)}},
+		{[]byte{0xF9, 0x3C, 0x00, 0xF9, 0x00, 0x01, 0xFA, 0x47, 0xC3, 0x50, 0x00,
+			0xFB, 0xC0, 0x10, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66},
+			[]Token{float32(1.0), float32(5.960464477539063e-8), float32(100000.0), -4.1}},
+		// [1, [2, 3], [4, 5]]
+		{[]byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x82, 0x04, 0x05},
+			[]Token{ArrayStart{Length: 3}, uint64(1),
+				ArrayStart{Length: 2}, uint64(2), uint64(3), ArrayEnd{},
+				ArrayStart{Length: 2}, uint64(4), uint64(5), ArrayEnd{}, ArrayEnd{}}},
+		// [_ 1, [2, 3], [_ ]]
+		{[]byte{0x9F, 0x01, 0x82, 0x02, 0x03, 0x9F, 0xFF, 0xFF},
+			[]Token{ArrayStart{Indefinite: true}, uint64(1),
+				ArrayStart{Length: 2}, uint64(2), uint64(3), ArrayEnd{},
+				ArrayStart{Indefinite: true}, ArrayEnd{}, ArrayEnd{}}},
+		// {_ "a": 1, "b": [2, 3]}
+		{[]byte{0xBF, 0x61, 0x61, 0x01, 0x61, 0x62, 0x9F, 0x02, 0x03, 0xFF, 0xFF},
+			[]Token{MapStart{Indefinite: true}, "a", uint64(1),
+				"b", ArrayStart{Indefinite: true}, uint64(2), uint64(3), ArrayEnd{}, MapEnd{}}},
+		// [1(2), 24(<<[]>>), {}]
+		{[]byte{0x83, 0xC1, 0x02, 0xD8, 0x18, 0x41, 0x80, 0xA0},
+			[]Token{ArrayStart{Length: 3}, TagNumber(1), uint64(2),
+				TagNumber(24), []byte{0x80}, MapStart{}, MapEnd{}, ArrayEnd{}}},
+		// 1(2(["a"]))
+		{[]byte{0xC1, 0xC2, 0x81, 0x61, 0x61},
+			[]Token{TagNumber(1), TagNumber(2), ArrayStart{Length: 1}, "a", ArrayEnd{}}},
+	}
+
+	for _, test := range tests {
+		tokens, err := readAllTokens(NewDecoder(bytes.NewBuffer(test.data)))
+		if err != nil {
+			t.Fatalf("Decoding %x errored: %v", test.data, err)
+		} else if !reflect.DeepEqual(tokens, test.tokens) {
+			t.Fatalf("Tokens of %x mismatch: %v != %v", test.data, tokens, test.tokens)
+		}
+	}
+}
+
+func TestDecoderTokenError(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{0x82, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x9F, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0xC1}, io.ErrUnexpectedEOF},
+		{[]byte{0x62, 0x61}, io.ErrUnexpectedEOF},
+		{[]byte{0x5F, 0x41, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x5F, 0x61, 0x61, 0xFF}, nil},
+		{[]byte{0xBF, 0x01, 0xFF}, nil},
+		{[]byte{0xFF}, nil},
+		{[]byte{0xF8, 0x10}, nil},
+		{[]byte{0x1C}, nil},
+	}
+
+	for _, test := range tests {
+		_, err := readAllTokens(NewDecoder(bytes.NewBuffer(test.data)))
+		if err == nil {
+			t.Fatalf("Illegal input %x did not error", test.data)
+		} else if test.err != nil && err != test.err {
+			t.Fatalf("Illegal input %x errored with %v instead of %v", test.data, err, test.err)
+		}
+	}
+}
+
+func TestDecoderMoreDepth(t *testing.T) {
+	// [1, [_ 2], 3] 4
+	data := []byte{0x83, 0x01, 0x9F, 0x02, 0xFF, 0x03, 0x04}
+	d := NewDecoder(bytes.NewBuffer(data))
+
+	steps := []struct {
+		more   bool
+		depth  int
+		offset int64
+	}{
+		// Before each token
+		{true, 0, 0},  // [
+		{true, 1, 1},  // 1
+		{true, 1, 2},  // [_
+		{true, 2, 3},  // 2
+		{false, 2, 4}, // ]
+		{true, 1, 5},  // 3
+		{false, 1, 6}, // ]
+		{true, 0, 6},  // 4
+		{false, 0, 7},
+	}
+
+	for i, step := range steps {
+		if more := d.More(); more != step.more {
+			t.Fatalf("Step %d: More is %t", i, more)
+		} else if depth := d.Depth(); depth != step.depth {
+			t.Fatalf("Step %d: Depth is %d", i, depth)
+		} else if offset := d.InputOffset(); offset != step.offset {
+			t.Fatalf("Step %d: InputOffset is %d", i, offset)
+		}
+
+		if _, err := d.Token(); i < len(steps)-1 && err != nil {
+			t.Fatalf("Step %d: %v", i, err)
+		} else if i == len(steps)-1 && err != io.EOF {
+			t.Fatalf("Expected io.EOF, got %v", err)
+		}
+	}
+}
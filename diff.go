@@ -0,0 +1,348 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// DiffKind is the kind of a Difference.
+type DiffKind int
+
+const (
+	// DiffValue marks different values, including different types.
+	DiffValue DiffKind = iota
+	// DiffEncoding marks the same value in different encodings, e.g., of
+	// another head width, definite and indefinite length, floating-point
+	// precision or map key order.
+	DiffEncoding
+	// DiffRemoved marks an array element or a map entry only present in the
+	// first data item.
+	DiffRemoved
+	// DiffAdded marks an array element or a map entry only present in the
+	// second data item.
+	DiffAdded
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffValue:
+		return "value"
+	case DiffEncoding:
+		return "encoding"
+	case DiffRemoved:
+		return "removed"
+	case DiffAdded:
+		return "added"
+	default:
+		return fmt.Sprintf("DiffKind(%d)", int(k))
+	}
+}
+
+// Difference between two data items at a Path.
+type Difference struct {
+	Kind DiffKind
+	Path Path
+	// A and B are the differing data items of the first and the second input.
+	// A is nil for DiffAdded and B is nil for DiffRemoved. Their Offsets are
+	// relative to the start of the input or of the embedding byte string.
+	A, B *Item
+	// Message describes the difference.
+	Message string
+}
+
+func (d Difference) String() string {
+	path := d.Path.String()
+	if path == "" {
+		path = "root"
+	}
+	return fmt.Sprintf("%s: %s: %s", path, d.Kind, d.Message)
+}
+
+// Diff reads the next data item of each Reader and returns their structural
+// differences, ordered by their Paths' appearance. Arrays are compared by
+// their elements' positions and maps by their keys' values. The content of a
+// tag 24 is compared as embedded data items.
+//
+// If ignoreEncoding is set, differences of the encoding alone are not
+// reported, only values of the kinds DiffValue, DiffRemoved and DiffAdded.
+func Diff(a, b io.Reader, ignoreEncoding bool) ([]Difference, error) {
+	rawA, err := ReadRawItem(a)
+	if err != nil {
+		return nil, fmt.Errorf("Diff: first data item: %w", err)
+	}
+	rawB, err := ReadRawItem(b)
+	if err != nil {
+		return nil, fmt.Errorf("Diff: second data item: %w", err)
+	}
+
+	itemA, err := ReadItem(bytes.NewReader(rawA))
+	if err != nil {
+		return nil, fmt.Errorf("Diff: first data item: %w", err)
+	}
+	itemB, err := ReadItem(bytes.NewReader(rawB))
+	if err != nil {
+		return nil, fmt.Errorf("Diff: second data item: %w", err)
+	}
+
+	d := differ{ignoreEncoding: ignoreEncoding}
+	d.diff(side{itemA, rawA}, side{itemB, rawB}, nil)
+	return d.diffs, nil
+}
+
+// side is a data item together with the encoding its Offsets refer to.
+type side struct {
+	item *Item
+	raw  []byte
+}
+
+type differ struct {
+	ignoreEncoding bool
+	diffs          []Difference
+}
+
+func (d *differ) report(kind DiffKind, path Path, a, b *Item, format string, args ...any) {
+	if kind == DiffEncoding && d.ignoreEncoding {
+		return
+	}
+	d.diffs = append(d.diffs, Difference{Kind: kind, Path: path, A: a, B: b, Message: fmt.Sprintf(format, args...)})
+}
+
+func (d *differ) diff(a, b side, path Path) {
+	ia, ib := a.item, b.item
+
+	if ia.Major() != ib.Major() ||
+		(ia.Major() == Tag && ia.Head.Argument != ib.Head.Argument) ||
+		(ia.Major() != Array && ia.Major() != Map && ia.Major() != Tag && !sameValue(ia, ib)) {
+		// Embedded data items within tag 24 are compared structurally.
+		if ia.Major() == ByteString && ib.Major() == ByteString && len(path) > 0 &&
+			path[len(path)-1].Kind == PathTag && path[len(path)-1].Tag == 24 {
+			if ea, eb, ok := embeddedSides(ia, ib); ok {
+				d.encoding(ia, ib, path)
+				d.diff(ea, eb, path.Append(PathElement{Kind: PathEmbedded}))
+				return
+			}
+		}
+
+		d.report(DiffValue, path, ia, ib, "%s != %s", describeItem(ia), describeItem(ib))
+		return
+	}
+
+	d.encoding(ia, ib, path)
+
+	switch ia.Major() {
+	case Array:
+		n := min(len(ia.Items), len(ib.Items))
+		for i := 0; i < n; i++ {
+			d.diff(side{ia.Items[i], a.raw}, side{ib.Items[i], b.raw}, path.Append(PathElement{Kind: PathIndex, Index: uint64(i)}))
+		}
+		for i := n; i < len(ia.Items); i++ {
+			d.report(DiffRemoved, path.Append(PathElement{Kind: PathIndex, Index: uint64(i)}), ia.Items[i], nil,
+				"%s only in the first data item", describeItem(ia.Items[i]))
+		}
+		for i := n; i < len(ib.Items); i++ {
+			d.report(DiffAdded, path.Append(PathElement{Kind: PathIndex, Index: uint64(i)}), nil, ib.Items[i],
+				"%s only in the second data item", describeItem(ib.Items[i]))
+		}
+
+	case Map:
+		d.diffMap(a, b, path)
+
+	case Tag:
+		d.diff(side{ia.Items[0], a.raw}, side{ib.Items[0], b.raw}, path.Append(PathElement{Kind: PathTag, Tag: ia.Head.Argument}))
+	}
+}
+
+// encoding reports differences of two data items' heads, whose values are
+// considered to be the same.
+func (d *differ) encoding(ia, ib *Item, path Path) {
+	ha, hb := ia.Head, ib.Head
+	switch {
+	case ha.Indefinite != hb.Indefinite:
+		d.report(DiffEncoding, path, ia, ib, "%s != %s", describeLength(ha), describeLength(hb))
+	case ha.Width != hb.Width && ha.Major == SimpleData:
+		d.report(DiffEncoding, path, ia, ib, "%s != %s", describeValue(ha), describeValue(hb))
+	case ha.Width != hb.Width:
+		d.report(DiffEncoding, path, ia, ib, "head of %d != %d bytes", ha.Width, hb.Width)
+	}
+}
+
+// diffMap compares two maps by their keys' values.
+func (d *differ) diffMap(a, b side, path Path) {
+	ia, ib := a.item, b.item
+
+	// matches maps each key index of b to its matching key index of a.
+	matches := make([]int, len(ib.Items)/2)
+	matchedA := make([]bool, len(ia.Items)/2)
+	for j := range matches {
+		matches[j] = -1
+		for i := range matchedA {
+			if !matchedA[i] && sameValue(ia.Items[2*i], ib.Items[2*j]) {
+				matches[j] = i
+				matchedA[i] = true
+				break
+			}
+		}
+	}
+
+	// The matching keys of b must appear in the same order.
+	last := -1
+	for _, i := range matches {
+		if i < 0 {
+			continue
+		} else if i < last {
+			d.report(DiffEncoding, path, ia, ib, "different key order")
+			break
+		}
+		last = i
+	}
+
+	for i := range matchedA {
+		key := ia.Items[2*i]
+		keyPath := path.Append(PathElement{Kind: PathKey, Key: rawKey(a, i)})
+
+		j := indexOf(matches, i)
+		if j < 0 {
+			d.report(DiffRemoved, keyPath, ia.Items[2*i+1], nil, "key %s only in the first data item", describeItem(key))
+			continue
+		}
+
+		d.diffKey(side{key, a.raw}, side{ib.Items[2*j], b.raw}, keyPath)
+		d.diff(side{ia.Items[2*i+1], a.raw}, side{ib.Items[2*j+1], b.raw}, keyPath)
+	}
+
+	for j, i := range matches {
+		if i < 0 {
+			keyPath := path.Append(PathElement{Kind: PathKey, Key: rawKey(b, j)})
+			d.report(DiffAdded, keyPath, nil, ib.Items[2*j+1], "key %s only in the second data item", describeItem(ib.Items[2*j]))
+		}
+	}
+}
+
+// diffKey reports differences of the encoding of two matching map keys.
+func (d *differ) diffKey(a, b side, path Path) {
+	before := len(d.diffs)
+	d.diff(a, b, path)
+	for i := before; i < len(d.diffs); i++ {
+		d.diffs[i].Message = "key " + d.diffs[i].Message
+	}
+}
+
+// rawKey returns the encoding of a map's key by its index.
+func rawKey(s side, index int) []byte {
+	return s.raw[s.item.Items[2*index].Offset:s.item.Items[2*index+1].Offset]
+}
+
+func indexOf(s []int, v int) int {
+	for i, w := range s {
+		if w == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// embeddedSides decodes the data items embedded in two byte strings.
+func embeddedSides(ia, ib *Item) (a, b side, ok bool) {
+	for _, s := range []struct {
+		item *Item
+		side *side
+	}{{ia, &a}, {ib, &b}} {
+		raw := s.item.Value.([]byte)
+		r := bytes.NewReader(raw)
+		item, err := ReadItem(r)
+		if err != nil || r.Len() > 0 {
+			return side{}, side{}, false
+		}
+		*s.side = side{item, raw}
+	}
+	return a, b, true
+}
+
+// sameValue checks if two data items have the same value, regardless of their
+// encoding.
+func sameValue(a, b *Item) bool {
+	if a.Major() != b.Major() || len(a.Items) != len(b.Items) {
+		return false
+	}
+
+	switch va := a.Value.(type) {
+	case []byte:
+		vb, ok := b.Value.([]byte)
+		if !ok || !bytes.Equal(va, vb) {
+			return false
+		}
+	case float32, float64:
+		fa, okA := floatValue(a.Value)
+		fb, okB := floatValue(b.Value)
+		if !okA || !okB || (fa != fb && !(math.IsNaN(fa) && math.IsNaN(fb))) {
+			return false
+		}
+	default:
+		if _, isFloat := floatValue(b.Value); isFloat || a.Value != b.Value {
+			return false
+		}
+	}
+
+	if a.Major() == Tag && a.Head.Argument != b.Head.Argument {
+		return false
+	}
+	for i := range a.Items {
+		if !sameValue(a.Items[i], b.Items[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// floatValue returns a floating-point Token as a float64.
+func floatValue(v Token) (float64, bool) {
+	switch f := v.(type) {
+	case float32:
+		return float64(f), true
+	case float64:
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+// describeItem describes a data item, e.g., `text(3) "foo"` or "array(2)".
+func describeItem(item *Item) string {
+	switch v := item.Value.(type) {
+	case string:
+		if len(v) > 32 {
+			v = v[:32] + "..."
+		}
+		return fmt.Sprintf("%s %q", describeLength(item.Head), v)
+	case []byte:
+		if len(v) > 32 {
+			return fmt.Sprintf("%s h'%x...'", describeLength(item.Head), v[:32])
+		}
+		return fmt.Sprintf("%s h'%x'", describeLength(item.Head), v)
+	}
+
+	if item.Head.Indefinite {
+		return describeLength(item.Head)
+	}
+	return describeValue(item.Head)
+}
+
+// describeLength describes a head, where an indefinite-length head is named
+// explicitly.
+func describeLength(h Head) string {
+	if h.Indefinite {
+		switch h.Major {
+		case ByteString:
+			return "indefinite-length bytes"
+		case TextString:
+			return "indefinite-length text"
+		case Array:
+			return "indefinite-length array"
+		default:
+			return "indefinite-length map"
+		}
+	}
+	return h.String()
+}
@@ -0,0 +1,101 @@
+package cboring
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b     []byte
+		expected []string
+	}{
+		// Equal data items
+		{[]byte{0x82, 0x01, 0x61, 0x61}, []byte{0x82, 0x01, 0x61, 0x61}, nil},
+		// Changed values and types
+		{[]byte{0x82, 0x01, 0x02}, []byte{0x82, 0x01, 0x03}, []string{"[1]: value: unsigned(2) != unsigned(3)"}},
+		{[]byte{0x01}, []byte{0x61, 0x31}, []string{`root: value: unsigned(1) != text(1) "1"`}},
+		{[]byte{0xC1, 0x00}, []byte{0xC2, 0x00}, []string{"root: value: tag(1) != tag(2)"}},
+		{[]byte{0xC1, 0x00}, []byte{0xC1, 0x01}, []string{"#1: value: unsigned(0) != unsigned(1)"}},
+		// Different encodings of the same value
+		{[]byte{0x81, 0x01}, []byte{0x81, 0x18, 0x01}, []string{"[0]: encoding: head of 1 != 2 bytes"}},
+		{[]byte{0x81, 0x01}, []byte{0x9F, 0x01, 0xFF}, []string{"root: encoding: array(1) != indefinite-length array"}},
+		{[]byte{0x62, 0x61, 0x62}, []byte{0x7F, 0x61, 0x61, 0x61, 0x62, 0xFF}, []string{"root: encoding: text(2) != indefinite-length text"}},
+		{[]byte{0xF9, 0x3E, 0x00}, []byte{0xFB, 0x3F, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, []string{"root: encoding: float16(1.5) != float64(1.5)"}},
+		// Extra and missing array elements
+		{[]byte{0x82, 0x01, 0x02}, []byte{0x81, 0x01}, []string{"[1]: removed: unsigned(2) only in the first data item"}},
+		{[]byte{0x81, 0x01}, []byte{0x82, 0x01, 0xF5}, []string{"[1]: added: true only in the second data item"}},
+		// Map keys
+		{
+			[]byte{0xA2, 0x01, 0x02, 0x61, 0x6B, 0x03},
+			[]byte{0xA2, 0x61, 0x6B, 0x04, 0x18, 0x01, 0x02},
+			[]string{
+				"root: encoding: different key order",
+				"{1}: encoding: key head of 1 != 2 bytes",
+				`{"k"}: value: unsigned(3) != unsigned(4)`,
+			},
+		},
+		{
+			[]byte{0xA2, 0x01, 0x02, 0x02, 0x03},
+			[]byte{0xA2, 0x02, 0x03, 0x20, 0x04},
+			[]string{
+				"{1}: removed: key unsigned(1) only in the first data item",
+				"{-1}: added: key negative(-1-0) only in the second data item",
+			},
+		},
+		// Embedded data items
+		{
+			[]byte{0xD8, 0x18, 0x43, 0x82, 0x01, 0x02},
+			[]byte{0xD8, 0x18, 0x43, 0x82, 0x01, 0x03},
+			[]string{"#24<<>>[1]: value: unsigned(2) != unsigned(3)"},
+		},
+	}
+
+	for _, test := range tests {
+		diffs, err := Diff(bytes.NewReader(test.a), bytes.NewReader(test.b), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var msgs []string
+		for _, d := range diffs {
+			msgs = append(msgs, d.String())
+		}
+		if len(msgs) != len(test.expected) {
+			t.Fatalf("Diff of %x and %x is %q, expected %q", test.a, test.b, msgs, test.expected)
+		}
+		for i := range msgs {
+			if msgs[i] != test.expected[i] {
+				t.Fatalf("Diff of %x and %x is %q, expected %q", test.a, test.b, msgs, test.expected)
+			}
+		}
+	}
+}
+
+func TestDiffIgnoreEncoding(t *testing.T) {
+	a := []byte{0xA2, 0x01, 0x82, 0x01, 0x02, 0x02, 0xF9, 0x3E, 0x00}
+	b := []byte{0xBF, 0x02, 0xFA, 0x3F, 0xC0, 0x00, 0x00, 0x18, 0x01, 0x9F, 0x01, 0x03, 0xFF, 0xFF}
+
+	diffs, err := Diff(bytes.NewReader(a), bytes.NewReader(b), true)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(diffs) != 1 || diffs[0].Kind != DiffValue || diffs[0].Path.String() != "{1}[1]" {
+		t.Fatalf("Diff ignoring the encoding is %v", diffs)
+	}
+}
+
+func TestDiffError(t *testing.T) {
+	tests := []struct {
+		a, b []byte
+	}{
+		{[]byte{}, []byte{0x01}},
+		{[]byte{0x01}, []byte{0x82, 0x01}},
+		{[]byte{0xFF}, []byte{0x01}},
+	}
+
+	for _, test := range tests {
+		if _, err := Diff(bytes.NewReader(test.a), bytes.NewReader(test.b), false); err == nil {
+			t.Fatalf("Diff of %x and %x did not error", test.a, test.b)
+		}
+	}
+}
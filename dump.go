@@ -0,0 +1,296 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// dumpLineLength is the number of a string's content bytes per line of Dump.
+const dumpLineLength = 16
+
+// Dump writes an annotated hex dump of the CBOR sequence of the Reader into the
+// Writer, similar to cbor.me. Each head is written on its own line, indented by
+// its depth and followed by a comment, e.g.,
+//
+//	82  # array(2)
+//	  01  # unsigned(1)
+//	  63 666f6f  # text(3) "foo"
+//
+// A string's content follows its head on the same line or, if it is longer
+// than 16 bytes, on the following lines. Non-minimal heads are marked, as
+// are indefinite-length data items and their break stop codes.
+//
+// If the input is truncated or not well-formed, the data is dumped as far as
+// it could be decoded, followed by a comment line with the problem, and the
+// error is returned, e.g., io.ErrUnexpectedEOF. Otherwise, Dump returns nil at
+// the end of the Reader.
+func Dump(r io.Reader, w io.Writer) error {
+	d := &dumper{r: r, w: w}
+	for {
+		d.depth = 0
+		h, err := ReadHead(d)
+		if err == io.EOF && len(d.pending) == 0 {
+			return d.err
+		} else if err != nil {
+			return d.fail(err)
+		}
+
+		if err := d.item(h, 0); err != nil {
+			return d.fail(err)
+		}
+	}
+}
+
+// dumper reads the input and keeps the bytes read since the last written line.
+type dumper struct {
+	r       io.Reader
+	w       io.Writer
+	pending []byte
+	// depth of the pending bytes, to indent a failure
+	depth int
+	// err is the first error of writing, which ends the Dump.
+	err error
+}
+
+func (d *dumper) Read(p []byte) (n int, err error) {
+	n, err = d.r.Read(p)
+	d.pending = append(d.pending, p[:n]...)
+	return
+}
+
+// line writes the bytes read since the last line, grouped by the head's width,
+// followed by the comment.
+func (d *dumper) line(depth, width int, comment string) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Repeat("  ", depth))
+
+	data := d.pending
+	if width > 0 && width <= len(data) {
+		// The initial byte, the argument and the string's content
+		fmt.Fprintf(&b, "%02x", data[0])
+		if width > 1 {
+			fmt.Fprintf(&b, " %x", data[1:width])
+		}
+		data = data[width:]
+		if len(data) > 0 {
+			b.WriteString(" ")
+		}
+	}
+	fmt.Fprintf(&b, "%x", data)
+	switch {
+	case comment == "":
+	case len(d.pending) > 0:
+		fmt.Fprintf(&b, "  # %s", comment)
+	default:
+		fmt.Fprintf(&b, "# %s", comment)
+	}
+	b.WriteString("\n")
+
+	d.pending = d.pending[:0]
+	_, d.err = io.WriteString(d.w, b.String())
+	return d.err
+}
+
+// fail writes the pending bytes together with the problem and returns the
+// error.
+func (d *dumper) fail(err error) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	comment := "error: " + err.Error()
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = io.ErrUnexpectedEOF
+		comment = "truncated"
+	}
+
+	if lineErr := d.line(d.depth, 0, comment); lineErr != nil {
+		return lineErr
+	}
+	return err
+}
+
+// describe returns a head's comment, marking non-minimal heads.
+func describe(h Head) string {
+	desc := describeValue(h)
+	if !h.IsMinimal() {
+		desc += fmt.Sprintf(", non-minimal head of %d bytes", h.Width)
+	}
+	return desc
+}
+
+// describeValue describes a head, naming simple values and floating-point
+// values by their values.
+func describeValue(h Head) string {
+	var desc string
+	switch {
+	case h.Major != SimpleData:
+		desc = h.String()
+	case h.Info == simpleFalse:
+		desc = "false"
+	case h.Info == simpleTrue:
+		desc = "true"
+	case h.Info == simpleNull:
+		desc = "null"
+	case h.Info == simpleUndefined:
+		desc = "undefined"
+	case h.Info == 25:
+		desc = "float16(" + formatDumpFloat(float64(halfToFloat32(uint16(h.Argument))), 32) + ")"
+	case h.Info == 26:
+		desc = "float32(" + formatDumpFloat(float64(math.Float32frombits(uint32(h.Argument))), 32) + ")"
+	case h.Info == 27:
+		desc = "float64(" + formatDumpFloat(math.Float64frombits(h.Argument), 64) + ")"
+	default:
+		desc = h.String()
+	}
+	return desc
+}
+
+// formatDumpFloat formats a floating-point value for a comment.
+func formatDumpFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, bits)
+}
+
+// item dumps the data item of the already read head.
+func (d *dumper) item(h Head, depth int) error {
+	d.depth = depth
+	if depth > MaxNestingDepth {
+		return fmt.Errorf("Dump: Exceeded maximum nesting depth of %d", MaxNestingDepth)
+	}
+
+	switch h.Major {
+	case ByteString, TextString:
+		if h.Indefinite {
+			if err := d.line(depth, h.Width, describe(h)); err != nil {
+				return err
+			}
+			return d.indefinite(depth, func(chunk Head) error {
+				if chunk.Major != h.Major || chunk.Indefinite {
+					return fmt.Errorf("Dump: Illegal chunk %v in indefinite-length string", chunk)
+				}
+				return d.item(chunk, depth+1)
+			})
+		}
+		return d.string(h, depth)
+
+	case Array, Map:
+		if err := d.line(depth, h.Width, describe(h)); err != nil {
+			return err
+		}
+		if h.Indefinite {
+			return d.indefinite(depth, func(next Head) error {
+				return d.item(next, depth+1)
+			})
+		}
+
+		itemsPerElement := uint64(1)
+		if h.Major == Map {
+			itemsPerElement = 2
+		}
+		for i := uint64(0); i < h.Argument; i++ {
+			for j := uint64(0); j < itemsPerElement; j++ {
+				if err := d.nested(depth); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case Tag:
+		if err := d.line(depth, h.Width, describe(h)); err != nil {
+			return err
+		}
+		return d.nested(depth)
+
+	case SimpleData:
+		if h.IsBreak() {
+			return fmt.Errorf("Dump: Unexpected break stop code")
+		} else if h.Info == 24 && h.Argument < 32 {
+			return fmt.Errorf("Dump: Simple value %d in two bytes is not well-formed", h.Argument)
+		}
+		return d.line(depth, h.Width, describe(h))
+
+	default:
+		return d.line(depth, h.Width, describe(h))
+	}
+}
+
+// nested dumps the next data item within another one.
+func (d *dumper) nested(depth int) error {
+	d.depth = depth + 1
+	next, err := readNestedHead(d)
+	if err != nil {
+		return err
+	}
+	return d.item(next, depth+1)
+}
+
+// indefinite dumps the nested data items of an indefinite-length data item
+// until its break stop code.
+func (d *dumper) indefinite(depth int, f func(Head) error) error {
+	for {
+		d.depth = depth + 1
+		next, err := readNestedHead(d)
+		if err != nil {
+			return err
+		}
+
+		if next.IsBreak() {
+			return d.line(depth, next.Width, "break")
+		} else if err := f(next); err != nil {
+			return err
+		}
+	}
+}
+
+// string dumps a definite-length string, whose head was already read. A short
+// string's content is written on the head's line, a longer one on the
+// following lines.
+func (d *dumper) string(h Head, depth int) error {
+	desc := describe(h)
+
+	if h.Argument <= dumpLineLength {
+		data, err := ReadRawBytes(h.Argument, d)
+		if err != nil {
+			return err
+		}
+
+		if h.Major == TextString {
+			desc += " " + strconv.Quote(string(data))
+		}
+		return d.line(depth, h.Width, desc)
+	}
+
+	if err := d.line(depth, h.Width, desc); err != nil {
+		return err
+	}
+
+	d.depth = depth + 1
+	for remaining := h.Argument; remaining > 0; {
+		n := min(remaining, dumpLineLength)
+		if _, err := ReadRawBytes(n, d); err != nil {
+			return err
+		}
+		remaining -= n
+
+		if err := d.line(depth+1, 0, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
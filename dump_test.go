@@ -0,0 +1,116 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// errAny is expected for any error.
+var errAny = errors.New("any error")
+
+func TestDump(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+		err      error
+	}{
+		{
+			[]byte{0x82, 0x01, 0x63, 0x66, 0x6F, 0x6F},
+			"82  # array(2)\n" +
+				"  01  # unsigned(1)\n" +
+				"  63 666f6f  # text(3) \"foo\"\n",
+			nil,
+		},
+		{
+			// A CBOR sequence with non-minimal heads
+			[]byte{0x18, 0x01, 0x39, 0x00, 0x01, 0xD8, 0x01, 0x41, 0x00},
+			"18 01  # unsigned(1), non-minimal head of 2 bytes\n" +
+				"39 0001  # negative(-1-1), non-minimal head of 3 bytes\n" +
+				"d8 01  # tag(1), non-minimal head of 2 bytes\n" +
+				"  41 00  # bytes(1)\n",
+			nil,
+		},
+		{
+			// Indefinite-length data items
+			[]byte{0xBF, 0x61, 0x61, 0x9F, 0xFF, 0x5F, 0x41, 0x00, 0x40, 0xFF, 0xF6, 0xFF},
+			"bf  # map(*)\n" +
+				"  61 61  # text(1) \"a\"\n" +
+				"  9f  # array(*)\n" +
+				"  ff  # break\n" +
+				"  5f  # bytes(*)\n" +
+				"    41 00  # bytes(1)\n" +
+				"    40  # bytes(0)\n" +
+				"  ff  # break\n" +
+				"  f6  # null\n" +
+				"ff  # break\n",
+			nil,
+		},
+		{
+			// Simple and floating-point values
+			[]byte{0x84, 0xF4, 0xF9, 0x3E, 0x00, 0xFB, 0x7F, 0xF0, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xF8, 0xFF},
+			"84  # array(4)\n" +
+				"  f4  # false\n" +
+				"  f9 3e00  # float16(1.5)\n" +
+				"  fb 7ff0000000000000  # float64(Infinity)\n" +
+				"  f8 ff  # simple(255)\n",
+			nil,
+		},
+		{
+			// Long strings span multiple lines
+			append([]byte{0x54}, bytes.Repeat([]byte{0xAB}, 20)...),
+			"54  # bytes(20)\n" +
+				"  abababababababababababababababab\n" +
+				"  abababab\n",
+			nil,
+		},
+		{
+			// Truncated within a string's content
+			[]byte{0x82, 0x01, 0x63, 0x66, 0x6F},
+			"82  # array(2)\n" +
+				"  01  # unsigned(1)\n" +
+				"  63666f  # truncated\n",
+			io.ErrUnexpectedEOF,
+		},
+		{
+			// Truncated within a head
+			[]byte{0x81, 0x19, 0x01},
+			"81  # array(1)\n" +
+				"  1901  # truncated\n",
+			io.ErrUnexpectedEOF,
+		},
+		{
+			// Truncated before a nested data item
+			[]byte{0x9F, 0x01},
+			"9f  # array(*)\n" +
+				"  01  # unsigned(1)\n" +
+				"  # truncated\n",
+			io.ErrUnexpectedEOF,
+		},
+		{
+			// Not well-formed
+			[]byte{0x01, 0xFF},
+			"01  # unsigned(1)\n" +
+				"ff  # error: Dump: Unexpected break stop code\n",
+			errAny,
+		},
+	}
+
+	for _, test := range tests {
+		var buff strings.Builder
+		err := Dump(bytes.NewReader(test.data), &buff)
+
+		if buff.String() != test.expected {
+			t.Fatalf("Dump of %x mismatches:\n%s\nexpected:\n%s", test.data, buff.String(), test.expected)
+		}
+
+		switch {
+		case test.err == errAny && err == nil:
+			t.Fatalf("Dump of %x did not error", test.data)
+		case test.err != errAny && !errors.Is(err, test.err):
+			t.Fatalf("Dump of %x errored with %v, expected %v", test.data, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// TagEncodedCBOR is the tag number for an embedded CBOR data item, which is
+// enclosed in a byte string, as specified in RFC 8949, section 3.4.5.1.
+const TagEncodedCBOR uint64 = 24
+
+// WriteEmbedded writes a CborMarshaler's CBOR representation as a byte string
+// into the Writer.
+//
+// If the CborMarshaler is a SizedMarshaler, its reported size is used for the
+// byte string's length and its data is written directly. Otherwise, the CBOR
+// representation is buffered in memory first.
+func WriteEmbedded(data CborMarshaler, w io.Writer) error {
+	sm, ok := data.(SizedMarshaler)
+	if !ok {
+		var buff bytes.Buffer
+		if err := data.MarshalCbor(&buff); err != nil {
+			return err
+		}
+		return WriteByteString(buff.Bytes(), w)
+	}
+
+	size := sm.CborSize()
+	if err := WriteByteStringLen(size, w); err != nil {
+		return err
+	}
+
+	cw := &embeddedWriter{w: w, n: size}
+	if err := sm.MarshalCbor(cw); err != nil {
+		return err
+	} else if cw.n != 0 {
+		return fmt.Errorf("WriteEmbedded: Wrote %d bytes less than the reported size %d",
+			cw.n, size)
+	}
+	return nil
+}
+
+// WriteEmbeddedTagged writes a CborMarshaler's CBOR representation as a byte
+// string, tagged as encoded CBOR data item, into the Writer. This function
+// wraps WriteEmbedded.
+func WriteEmbeddedTagged(data CborMarshaler, w io.Writer) error {
+	if err := WriteTag(TagEncodedCBOR, w); err != nil {
+		return err
+	}
+	return WriteEmbedded(data, w)
+}
+
+// ReadEmbedded expects a byte string at the Reader's position, optionally
+// tagged as encoded CBOR data item, and unmarshals its content into the
+// CborMarshaler. An error is returned if the CborMarshaler does not consume the
+// whole byte string.
+func ReadEmbedded(data CborMarshaler, r io.Reader) error {
+	m, n, err := ReadMajors(r)
+	if err != nil {
+		return err
+	}
+
+	if m == Tag {
+		if n != TagEncodedCBOR {
+			return fmt.Errorf("ReadEmbedded: Wrong tag %d instead of %d", n, TagEncodedCBOR)
+		}
+
+		if n, err = ReadByteStringLen(r); err != nil {
+			return err
+		}
+	} else if m != ByteString {
+		return fmt.Errorf("ReadEmbedded: Wrong Major Type: 0x%x instead of 0x%x",
+			m, ByteString)
+	}
+
+	sr := &stringReader{r: r, n: n}
+	if err := data.UnmarshalCbor(sr); err != nil {
+		return err
+	} else if sr.n != 0 {
+		return fmt.Errorf("ReadEmbedded: %d trailing bytes in byte string", sr.n)
+	}
+	return nil
+}
+
+// embeddedWriter passes at most n bytes through to the underlying Writer.
+type embeddedWriter struct {
+	w io.Writer
+	n uint64
+}
+
+func (ew *embeddedWriter) Write(p []byte) (int, error) {
+	if uint64(len(p)) > ew.n {
+		return 0, fmt.Errorf("WriteEmbedded: Exceeded the reported size by %d bytes",
+			uint64(len(p))-ew.n)
+	}
+
+	n, err := ew.w.Write(p)
+	ew.n -= uint64(n)
+	return n, err
+}
@@ -0,0 +1,103 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type embeddedTestItem struct {
+	A, B uint64
+}
+
+func (eti *embeddedTestItem) MarshalCbor(w io.Writer) error {
+	if err := WriteArrayLength(2, w); err != nil {
+		return err
+	}
+	if err := WriteUInt(eti.A, w); err != nil {
+		return err
+	}
+	return WriteUInt(eti.B, w)
+}
+
+func (eti *embeddedTestItem) UnmarshalCbor(r io.Reader) (err error) {
+	if _, err = ReadArrayLength(r); err != nil {
+		return
+	}
+	if eti.A, err = ReadUInt(r); err != nil {
+		return
+	}
+	eti.B, err = ReadUInt(r)
+	return
+}
+
+type sizedEmbeddedTestItem struct {
+	embeddedTestItem
+	size uint64
+}
+
+func (seti *sizedEmbeddedTestItem) CborSize() uint64 {
+	return seti.size
+}
+
+func TestEmbedded(t *testing.T) {
+	item := embeddedTestItem{A: 1, B: 1000}
+	cbor := []byte{0x45, 0x82, 0x01, 0x19, 0x03, 0xE8}
+
+	tests := []struct {
+		data  CborMarshaler
+		write func(CborMarshaler, io.Writer) error
+		cbor  []byte
+	}{
+		{&item, WriteEmbedded, cbor},
+		{&sizedEmbeddedTestItem{item, 5}, WriteEmbedded, cbor},
+		{&item, WriteEmbeddedTagged, append([]byte{0xD8, 0x18}, cbor...)},
+		{&sizedEmbeddedTestItem{item, 5}, WriteEmbeddedTagged, append([]byte{0xD8, 0x18}, cbor...)},
+	}
+
+	for _, test := range tests {
+		buff := &bytes.Buffer{}
+		if err := test.write(test.data, buff); err != nil {
+			t.Fatal(err)
+		} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.cbor) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.cbor)
+		}
+
+		var readItem embeddedTestItem
+		if err := ReadEmbedded(&readItem, buff); err != nil {
+			t.Fatal(err)
+		} else if readItem != item {
+			t.Fatalf("Deserialized data mismatches: %v != %v", readItem, item)
+		}
+	}
+}
+
+func TestWriteEmbeddedWrongSize(t *testing.T) {
+	for _, size := range []uint64{4, 6} {
+		item := sizedEmbeddedTestItem{embeddedTestItem{A: 1, B: 1000}, size}
+		if err := WriteEmbedded(&item, io.Discard); err == nil {
+			t.Fatalf("Wrong size %d did not error", size)
+		}
+	}
+}
+
+func TestReadEmbeddedError(t *testing.T) {
+	tests := [][]byte{
+		// Trailing data within the byte string
+		{0x46, 0x82, 0x01, 0x19, 0x03, 0xE8, 0x00},
+		// Truncated byte string
+		{0x46, 0x82, 0x01, 0x19, 0x03},
+		// Wrong tag
+		{0xD8, 0x19, 0x45, 0x82, 0x01, 0x19, 0x03, 0xE8},
+		// Not a byte string
+		{0x82, 0x01, 0x19, 0x03, 0xE8},
+	}
+
+	for _, test := range tests {
+		var item embeddedTestItem
+		if err := ReadEmbedded(&item, bytes.NewBuffer(test)); err == nil {
+			t.Fatalf("Illegal input %x did not error", test)
+		}
+	}
+}
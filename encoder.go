@@ -0,0 +1,280 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+)
+
+// encoderFrame is an open container or tag on the Encoder's stack.
+type encoderFrame struct {
+	major      MajorType
+	indefinite bool
+	// expected is the amount of data items, i.e., twice the pairs for a map.
+	expected uint64
+	items    uint64
+}
+
+func (ef encoderFrame) String() string {
+	switch ef.major {
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	default:
+		return "tag"
+	}
+}
+
+// Encoder writes CBOR data items into a Writer and keeps track of the open
+// containers. Each array or map must be finished by End, which also writes
+// the break stop code for indefinite-length containers.
+//
+// A checked Encoder, created by NewCheckedEncoder, verifies the well-formedness
+// while writing. It errors immediately if a container gets too many data
+// items, if End is called while data items are still missing, if a map would
+// hold an odd number of data items or if a break stop code has no matching
+// start. This is intended to be used in tests or for debugging, while the
+// unchecked Encoder, created by NewEncoder, can be used in production.
+type Encoder struct {
+	w       io.Writer
+	checked bool
+	stack   []encoderFrame
+}
+
+// NewEncoder creates an unchecked Encoder for the Writer.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// NewCheckedEncoder creates an Encoder for the Writer, which checks the
+// well-formedness of the written data.
+func NewCheckedEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, checked: true}
+}
+
+// Depth returns the amount of open containers and tags.
+func (e *Encoder) Depth() int {
+	return len(e.stack)
+}
+
+// room checks if the innermost container can hold another data item.
+func (e *Encoder) room() error {
+	if !e.checked || len(e.stack) == 0 {
+		return nil
+	}
+
+	top := e.stack[len(e.stack)-1]
+	if !top.indefinite && top.items >= top.expected {
+		return fmt.Errorf("Encoder: Too many data items for %v of %d data items",
+			top, top.expected)
+	}
+	return nil
+}
+
+// item counts one data item for the innermost container. A completed tag is
+// closed, its tagged data item counts for the enclosing container.
+func (e *Encoder) item() error {
+	if err := e.room(); err != nil {
+		return err
+	}
+
+	for len(e.stack) > 0 {
+		top := &e.stack[len(e.stack)-1]
+		top.items++
+
+		if top.major != Tag {
+			break
+		}
+		e.stack = e.stack[:len(e.stack)-1]
+	}
+	return nil
+}
+
+// write counts one data item and writes it through the function f.
+func (e *Encoder) write(f func(w io.Writer) error) error {
+	if err := e.item(); err != nil {
+		return err
+	}
+	return f(e.w)
+}
+
+// WriteUInt writes an unsigned integer, as WriteUInt.
+func (e *Encoder) WriteUInt(n uint64) error {
+	return e.write(func(w io.Writer) error { return WriteUInt(n, w) })
+}
+
+// WriteNInt writes a negative integer, as WriteNInt.
+func (e *Encoder) WriteNInt(n uint64) error {
+	return e.write(func(w io.Writer) error { return WriteNInt(n, w) })
+}
+
+// WriteInt writes an integer, as WriteInt.
+func (e *Encoder) WriteInt(n int64) error {
+	return e.write(func(w io.Writer) error { return WriteInt(n, w) })
+}
+
+// WriteByteString writes a byte string, as WriteByteString.
+func (e *Encoder) WriteByteString(data []byte) error {
+	return e.write(func(w io.Writer) error { return WriteByteString(data, w) })
+}
+
+// WriteTextString writes a text string, as WriteTextString.
+func (e *Encoder) WriteTextString(data string) error {
+	return e.write(func(w io.Writer) error { return WriteTextString(data, w) })
+}
+
+// WriteBoolean writes a bool, as WriteBoolean.
+func (e *Encoder) WriteBoolean(b bool) error {
+	return e.write(func(w io.Writer) error { return WriteBoolean(b, w) })
+}
+
+// WriteFloat16 writes a float32 as a half-precision value, as the package-level
+// WriteFloat16 function. An unrepresentable value is rejected before writing.
+func (e *Encoder) WriteFloat16(f float32) error {
+	if _, ok := Float32ToHalf(f); !ok {
+		return fmt.Errorf("Encoder: %v is not representable as half-precision value", f)
+	}
+	return e.write(func(w io.Writer) error { return WriteFloat16(f, w) })
+}
+
+// WriteFloat32 writes a float32, as WriteFloat32.
+func (e *Encoder) WriteFloat32(f float32) error {
+	return e.write(func(w io.Writer) error { return WriteFloat32(f, w) })
+}
+
+// WriteFloat64 writes a float64, as WriteFloat64.
+func (e *Encoder) WriteFloat64(f float64) error {
+	return e.write(func(w io.Writer) error { return WriteFloat64(f, w) })
+}
+
+// WriteNull writes a null, as WriteNull.
+func (e *Encoder) WriteNull() error {
+	return e.write(WriteNull)
+}
+
+// Marshal writes a CborMarshaler, which is counted as exactly one data item.
+// Its content is not checked.
+func (e *Encoder) Marshal(data CborMarshaler) error {
+	return e.write(data.MarshalCbor)
+}
+
+// WriteTag writes a tag. The next data item is the tagged one.
+func (e *Encoder) WriteTag(n uint64) error {
+	if err := e.room(); err != nil {
+		return err
+	}
+	if err := WriteTag(n, e.w); err != nil {
+		return err
+	}
+
+	e.stack = append(e.stack, encoderFrame{major: Tag, expected: 1})
+	return nil
+}
+
+// open counts the new container as a data item, writes its head through the
+// function f and pushes it onto the stack.
+func (e *Encoder) open(frame encoderFrame, f func(w io.Writer) error) error {
+	if err := e.write(f); err != nil {
+		return err
+	}
+
+	e.stack = append(e.stack, frame)
+	return nil
+}
+
+// WriteArrayLength starts an array of the given length, which must be
+// finished by End.
+func (e *Encoder) WriteArrayLength(n uint64) error {
+	return e.open(
+		encoderFrame{major: Array, expected: n},
+		func(w io.Writer) error { return WriteArrayLength(n, w) })
+}
+
+// WriteMapPairLength starts a map with the given amount of pairs, which must
+// be finished by End.
+func (e *Encoder) WriteMapPairLength(n uint64) error {
+	if e.checked && n > (1<<63)-1 {
+		return fmt.Errorf("Encoder: Map of %d pairs is too large", n)
+	}
+
+	return e.open(
+		encoderFrame{major: Map, expected: 2 * n},
+		func(w io.Writer) error { return WriteMapPairLength(n, w) })
+}
+
+// WriteIndefiniteArray starts an indefinite-length array, which must be
+// finished by End or WriteBreak.
+func (e *Encoder) WriteIndefiniteArray() error {
+	return e.open(
+		encoderFrame{major: Array, indefinite: true},
+		func(w io.Writer) error { return writeByte(IndefiniteArray, w) })
+}
+
+// WriteIndefiniteMap starts an indefinite-length map, which must be finished by
+// End or WriteBreak.
+func (e *Encoder) WriteIndefiniteMap() error {
+	return e.open(
+		encoderFrame{major: Map, indefinite: true},
+		func(w io.Writer) error { return writeByte(IndefiniteMap, w) })
+}
+
+// WriteBreak writes the break stop code, which finishes the innermost
+// indefinite-length container.
+func (e *Encoder) WriteBreak() error {
+	var top *encoderFrame
+	if len(e.stack) > 0 {
+		top = &e.stack[len(e.stack)-1]
+	}
+
+	if e.checked {
+		if top == nil || !top.indefinite {
+			return fmt.Errorf("Encoder: Break stop code without an indefinite-length container")
+		} else if top.major == Map && top.items%2 != 0 {
+			return fmt.Errorf("Encoder: Indefinite-length map with an odd number of %d data items",
+				top.items)
+		}
+	}
+
+	if err := writeByte(BreakCode, e.w); err != nil {
+		return err
+	}
+
+	if top != nil && top.indefinite {
+		e.stack = e.stack[:len(e.stack)-1]
+	}
+	return nil
+}
+
+// End finishes the innermost container. For an indefinite-length container,
+// the break stop code is written.
+func (e *Encoder) End() error {
+	if len(e.stack) == 0 {
+		if e.checked {
+			return fmt.Errorf("Encoder: End without an open container")
+		}
+		return nil
+	}
+
+	top := e.stack[len(e.stack)-1]
+	if top.indefinite {
+		return e.WriteBreak()
+	}
+
+	if e.checked && top.items != top.expected {
+		return fmt.Errorf("Encoder: End of %v with %d instead of %d data items",
+			top, top.items, top.expected)
+	}
+
+	e.stack = e.stack[:len(e.stack)-1]
+	return nil
+}
+
+// Close checks that all containers were finished. It does not close the
+// underlying Writer.
+func (e *Encoder) Close() error {
+	if e.checked && len(e.stack) > 0 {
+		return fmt.Errorf("Encoder: Close with %d unfinished containers, innermost %v",
+			len(e.stack), e.stack[len(e.stack)-1])
+	}
+	return nil
+}
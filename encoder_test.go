@@ -0,0 +1,150 @@
+package cboring
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	for _, checked := range []bool{false, true} {
+		var buff bytes.Buffer
+
+		var enc *Encoder
+		if checked {
+			enc = NewCheckedEncoder(&buff)
+		} else {
+			enc = NewEncoder(&buff)
+		}
+
+		steps := []func() error{
+			func() error { return enc.WriteArrayLength(3) },
+			func() error { return enc.WriteUInt(1) },
+			func() error { return enc.WriteIndefiniteMap() },
+			func() error { return enc.WriteTextString("a") },
+			func() error { return enc.WriteTag(1) },
+			func() error { return enc.WriteInt(-1) },
+			func() error { return enc.End() },
+			func() error { return enc.WriteIndefiniteArray() },
+			func() error { return enc.WriteNull() },
+			func() error { return enc.WriteBreak() },
+			func() error { return enc.End() },
+			func() error { return enc.Close() },
+		}
+		for i, step := range steps {
+			if err := step(); err != nil {
+				t.Fatalf("Step %d errored: %v", i, err)
+			}
+		}
+
+		expected := []byte{0x83, 0x01, 0xBF, 0x61, 0x61, 0xC1, 0x20, 0xFF, 0x9F, 0xF6, 0xFF}
+		if bb := buff.Bytes(); !reflect.DeepEqual(bb, expected) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, expected)
+		}
+	}
+}
+
+func TestCheckedEncoderError(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(enc *Encoder) error
+	}{
+		{"too many items", func(enc *Encoder) error {
+			_ = enc.WriteArrayLength(1)
+			_ = enc.WriteUInt(1)
+			return enc.WriteUInt(2)
+		}},
+		{"too many items after tag", func(enc *Encoder) error {
+			_ = enc.WriteArrayLength(1)
+			_ = enc.WriteTag(1)
+			_ = enc.WriteUInt(1)
+			return enc.WriteTag(2)
+		}},
+		{"missing items", func(enc *Encoder) error {
+			_ = enc.WriteMapPairLength(1)
+			_ = enc.WriteUInt(1)
+			return enc.End()
+		}},
+		{"odd map", func(enc *Encoder) error {
+			_ = enc.WriteIndefiniteMap()
+			_ = enc.WriteUInt(1)
+			return enc.End()
+		}},
+		{"break without start", func(enc *Encoder) error {
+			return enc.WriteBreak()
+		}},
+		{"break in definite array", func(enc *Encoder) error {
+			_ = enc.WriteArrayLength(1)
+			return enc.WriteBreak()
+		}},
+		{"end without start", func(enc *Encoder) error {
+			return enc.End()
+		}},
+		{"tag without content", func(enc *Encoder) error {
+			_ = enc.WriteArrayLength(1)
+			_ = enc.WriteTag(1)
+			return enc.End()
+		}},
+		{"unfinished container", func(enc *Encoder) error {
+			_ = enc.WriteArrayLength(1)
+			_ = enc.WriteUInt(1)
+			return enc.Close()
+		}},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		if err := test.steps(NewCheckedEncoder(&buff)); err == nil {
+			t.Fatalf("Checked Encoder did not error for %s", test.name)
+		}
+
+		buff.Reset()
+		if err := test.steps(NewEncoder(&buff)); err != nil {
+			t.Fatalf("Unchecked Encoder errored for %s: %v", test.name, err)
+		}
+	}
+}
+
+func TestEncoderFloats(t *testing.T) {
+	var buff bytes.Buffer
+	enc := NewCheckedEncoder(&buff)
+
+	steps := []func() error{
+		func() error { return enc.WriteArrayLength(4) },
+		func() error { return enc.WriteFloat32(0) },
+		func() error { return enc.WriteFloat64(0) },
+		func() error { return enc.WriteFloat64(math.Copysign(0, -1)) },
+		func() error { return enc.WriteFloat64(math.SmallestNonzeroFloat64) },
+		func() error { return enc.End() },
+		func() error { return enc.Close() },
+	}
+	for i, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Step %d errored: %v", i, err)
+		}
+	}
+
+	item, err := ReadItem(&buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		value Token
+		width int
+	}{
+		{float32(0), 5},
+		{float64(0), 9},
+		{math.Copysign(0, -1), 9},
+		{math.SmallestNonzeroFloat64, 9},
+	}
+	for i, elem := range item.Items {
+		if elem.Major() != SimpleData || elem.Head.Width != expected[i].width || elem.Value != expected[i].value {
+			t.Fatalf("Element %d is %v of %v, expected %v", i, elem.Head, elem.Value, expected[i].value)
+		}
+	}
+	if f := item.Items[2].Value.(float64); !math.Signbit(f) {
+		t.Fatalf("Negative zero lost its sign: %v", f)
+	}
+}
@@ -0,0 +1,6 @@
+// Package bundle contains Go types for a simplified Bundle Protocol Version 7
+// bundle, whose declarations and CBOR methods are generated by cboring-cddl
+// from the CDDL specification in bundle.cddl.
+package bundle
+
+//go:generate go run github.com/dtn7/cboring/cmd/cboring-cddl -output bundle_cbor.go bundle.cddl
@@ -0,0 +1,763 @@
+// Code generated by cboring-cddl. DO NOT EDIT.
+
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/dtn7/cboring"
+)
+
+// Bundle is generated from the CDDL rule bundle.
+type Bundle struct {
+	_ struct{} `cbor:",asarray"`
+
+	PrimaryBlock   PrimaryBlock     `cbor:"0"`
+	CanonicalBlock []CanonicalBlock `cbor:"1,rest"`
+}
+
+// MarshalCbor writes Bundle's CBOR representation.
+func (x *Bundle) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(uint64(1+len(x.CanonicalBlock)), w); err != nil {
+		return err
+	}
+
+	if err := x.PrimaryBlock.MarshalCbor(w); err != nil {
+		return err
+	}
+	for _, e0 := range x.CanonicalBlock {
+		if err := e0.MarshalCbor(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCbor reads Bundle's CBOR representation.
+func (x *Bundle) UnmarshalCbor(r io.Reader) error {
+	l, err := cboring.ReadArrayLength(r)
+	if err != nil {
+		return err
+	} else if l < 1 || l > cboring.MaxContainerLength {
+		return fmt.Errorf("Bundle: Expected array of at least length 1, got %d", l)
+	}
+
+	if err := x.PrimaryBlock.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	x.CanonicalBlock = nil
+	for i := uint64(1); i < l; i++ {
+		var e0 CanonicalBlock
+		if err := e0.UnmarshalCbor(r); err != nil {
+			return err
+		}
+		x.CanonicalBlock = append(x.CanonicalBlock, e0)
+	}
+	return nil
+}
+
+// PrimaryBlock is generated from the CDDL rule primary-block.
+type PrimaryBlock struct {
+	_ struct{} `cbor:",asarray"`
+
+	_                  uint64            `cbor:"0,const=7"`
+	BundleControlFlags uint64            `cbor:"1"`
+	CrcType            uint8             `cbor:"2"`
+	Destination        Eid               `cbor:"3"`
+	SourceNode         Eid               `cbor:"4"`
+	ReportTo           Eid               `cbor:"5"`
+	CreationTimestamp  CreationTimestamp `cbor:"6"`
+	Lifetime           uint64            `cbor:"7"`
+	Crc                *[]byte           `cbor:"8,optional"`
+}
+
+// MarshalCbor writes PrimaryBlock's CBOR representation.
+func (pb *PrimaryBlock) MarshalCbor(w io.Writer) error {
+	l := 8
+	if pb.Crc != nil {
+		l = 9
+	}
+	if err := cboring.WriteArrayLength(uint64(l), w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(7, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(pb.BundleControlFlags, w); err != nil {
+		return err
+	}
+	if !(pb.CrcType <= 2) {
+		return fmt.Errorf("PrimaryBlock.CrcType: Value %v is not allowed", pb.CrcType)
+	}
+	if err := cboring.WriteUInt(uint64(pb.CrcType), w); err != nil {
+		return err
+	}
+	if err := pb.Destination.MarshalCbor(w); err != nil {
+		return err
+	}
+	if err := pb.SourceNode.MarshalCbor(w); err != nil {
+		return err
+	}
+	if err := pb.ReportTo.MarshalCbor(w); err != nil {
+		return err
+	}
+	if err := pb.CreationTimestamp.MarshalCbor(w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(pb.Lifetime, w); err != nil {
+		return err
+	}
+	if l > 8 {
+		if pb.Crc == nil {
+			return fmt.Errorf("PrimaryBlock.Crc: Missing value before a later optional element")
+		}
+		if !(len(*pb.Crc) == 2 || len(*pb.Crc) == 4) {
+			return fmt.Errorf("PrimaryBlock.Crc: Value %v is not allowed", *pb.Crc)
+		}
+		if err := cboring.WriteByteString(*pb.Crc, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCbor reads PrimaryBlock's CBOR representation.
+func (pb *PrimaryBlock) UnmarshalCbor(r io.Reader) error {
+	l, err := cboring.ReadArrayLength(r)
+	if err != nil {
+		return err
+	} else if l < 8 || l > 9 {
+		return fmt.Errorf("PrimaryBlock: Expected array of length 8 to 9, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val != 7 {
+		return fmt.Errorf("PrimaryBlock: Expected 7 at position 0, got %d", val)
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		pb.BundleControlFlags = val
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val > math.MaxUint8 {
+		return fmt.Errorf("PrimaryBlock.CrcType: Value %d overflows uint8", val)
+	} else {
+		pb.CrcType = uint8(val)
+	}
+	if !(pb.CrcType <= 2) {
+		return fmt.Errorf("PrimaryBlock.CrcType: Value %v is not allowed", pb.CrcType)
+	}
+	if err := pb.Destination.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	if err := pb.SourceNode.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	if err := pb.ReportTo.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	if err := pb.CreationTimestamp.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		pb.Lifetime = val
+	}
+	if l > 8 {
+		pb.Crc = new([]byte)
+		if val, err := cboring.ReadByteString(r); err != nil {
+			return err
+		} else {
+			*pb.Crc = val
+		}
+		if !(len(*pb.Crc) == 2 || len(*pb.Crc) == 4) {
+			return fmt.Errorf("PrimaryBlock.Crc: Value %v is not allowed", *pb.Crc)
+		}
+	} else {
+		pb.Crc = nil
+	}
+	return nil
+}
+
+// DtnEid is generated from the CDDL rule dtn-eid.
+type DtnEid struct {
+	_ struct{} `cbor:",asarray"`
+
+	_      uint64 `cbor:"0,const=1"`
+	DtnSsp DtnSsp `cbor:"1"`
+}
+
+// MarshalCbor writes DtnEid's CBOR representation.
+func (de *DtnEid) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(2, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(1, w); err != nil {
+		return err
+	}
+	if err := de.DtnSsp.MarshalCbor(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads DtnEid's CBOR representation.
+func (de *DtnEid) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 2 {
+		return fmt.Errorf("DtnEid: Expected array of length 2, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val != 1 {
+		return fmt.Errorf("DtnEid: Expected 1 at position 0, got %d", val)
+	}
+	if err := de.DtnSsp.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+// IpnEid is generated from the CDDL rule ipn-eid.
+type IpnEid struct {
+	_ struct{} `cbor:",asarray"`
+
+	_      uint64 `cbor:"0,const=2"`
+	IpnSsp IpnSsp `cbor:"1"`
+}
+
+// MarshalCbor writes IpnEid's CBOR representation.
+func (ie *IpnEid) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(2, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(2, w); err != nil {
+		return err
+	}
+	if err := ie.IpnSsp.MarshalCbor(w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads IpnEid's CBOR representation.
+func (ie *IpnEid) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 2 {
+		return fmt.Errorf("IpnEid: Expected array of length 2, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val != 2 {
+		return fmt.Errorf("IpnEid: Expected 2 at position 0, got %d", val)
+	}
+	if err := ie.IpnSsp.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	return nil
+}
+
+// IpnSsp is generated from the CDDL rule ipn-ssp.
+type IpnSsp struct {
+	_ struct{} `cbor:",asarray"`
+
+	Node    uint64 `cbor:"0"`
+	Service uint64 `cbor:"1"`
+}
+
+// MarshalCbor writes IpnSsp's CBOR representation.
+func (is *IpnSsp) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(2, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(is.Node, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(is.Service, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads IpnSsp's CBOR representation.
+func (is *IpnSsp) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 2 {
+		return fmt.Errorf("IpnSsp: Expected array of length 2, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		is.Node = val
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		is.Service = val
+	}
+	return nil
+}
+
+// CreationTimestamp is generated from the CDDL rule creation-timestamp.
+type CreationTimestamp struct {
+	_ struct{} `cbor:",asarray"`
+
+	Time     uint64 `cbor:"0"`
+	Sequence uint64 `cbor:"1"`
+}
+
+// MarshalCbor writes CreationTimestamp's CBOR representation.
+func (ct *CreationTimestamp) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(2, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(ct.Time, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(ct.Sequence, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads CreationTimestamp's CBOR representation.
+func (ct *CreationTimestamp) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 2 {
+		return fmt.Errorf("CreationTimestamp: Expected array of length 2, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		ct.Time = val
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		ct.Sequence = val
+	}
+	return nil
+}
+
+// CanonicalBlock is generated from the CDDL rule canonical-block.
+type CanonicalBlock struct {
+	_ struct{} `cbor:",asarray"`
+
+	BlockType         uint64  `cbor:"0"`
+	BlockNumber       uint64  `cbor:"1"`
+	BlockControlFlags uint64  `cbor:"2"`
+	CrcType           uint8   `cbor:"3"`
+	Data              []byte  `cbor:"4"`
+	Crc               *[]byte `cbor:"5,optional"`
+}
+
+// MarshalCbor writes CanonicalBlock's CBOR representation.
+func (cb *CanonicalBlock) MarshalCbor(w io.Writer) error {
+	l := 5
+	if cb.Crc != nil {
+		l = 6
+	}
+	if err := cboring.WriteArrayLength(uint64(l), w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(cb.BlockType, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(cb.BlockNumber, w); err != nil {
+		return err
+	}
+	if !(cb.BlockControlFlags&^0x17 == 0) {
+		return fmt.Errorf("CanonicalBlock.BlockControlFlags: Value %v is not allowed", cb.BlockControlFlags)
+	}
+	if err := cboring.WriteUInt(cb.BlockControlFlags, w); err != nil {
+		return err
+	}
+	if !(cb.CrcType <= 2) {
+		return fmt.Errorf("CanonicalBlock.CrcType: Value %v is not allowed", cb.CrcType)
+	}
+	if err := cboring.WriteUInt(uint64(cb.CrcType), w); err != nil {
+		return err
+	}
+	if err := cboring.WriteByteString(cb.Data, w); err != nil {
+		return err
+	}
+	if l > 5 {
+		if cb.Crc == nil {
+			return fmt.Errorf("CanonicalBlock.Crc: Missing value before a later optional element")
+		}
+		if !(len(*cb.Crc) == 2 || len(*cb.Crc) == 4) {
+			return fmt.Errorf("CanonicalBlock.Crc: Value %v is not allowed", *cb.Crc)
+		}
+		if err := cboring.WriteByteString(*cb.Crc, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCbor reads CanonicalBlock's CBOR representation.
+func (cb *CanonicalBlock) UnmarshalCbor(r io.Reader) error {
+	l, err := cboring.ReadArrayLength(r)
+	if err != nil {
+		return err
+	} else if l < 5 || l > 6 {
+		return fmt.Errorf("CanonicalBlock: Expected array of length 5 to 6, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		cb.BlockType = val
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		cb.BlockNumber = val
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		cb.BlockControlFlags = val
+	}
+	if !(cb.BlockControlFlags&^0x17 == 0) {
+		return fmt.Errorf("CanonicalBlock.BlockControlFlags: Value %v is not allowed", cb.BlockControlFlags)
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val > math.MaxUint8 {
+		return fmt.Errorf("CanonicalBlock.CrcType: Value %d overflows uint8", val)
+	} else {
+		cb.CrcType = uint8(val)
+	}
+	if !(cb.CrcType <= 2) {
+		return fmt.Errorf("CanonicalBlock.CrcType: Value %v is not allowed", cb.CrcType)
+	}
+	if val, err := cboring.ReadByteString(r); err != nil {
+		return err
+	} else {
+		cb.Data = val
+	}
+	if l > 5 {
+		cb.Crc = new([]byte)
+		if val, err := cboring.ReadByteString(r); err != nil {
+			return err
+		} else {
+			*cb.Crc = val
+		}
+		if !(len(*cb.Crc) == 2 || len(*cb.Crc) == 4) {
+			return fmt.Errorf("CanonicalBlock.Crc: Value %v is not allowed", *cb.Crc)
+		}
+	} else {
+		cb.Crc = nil
+	}
+	return nil
+}
+
+// HopCount is generated from the CDDL rule hop-count.
+type HopCount struct {
+	_ struct{} `cbor:",asarray"`
+
+	Limit uint8 `cbor:"0"`
+	Count uint8 `cbor:"1"`
+}
+
+// MarshalCbor writes HopCount's CBOR representation.
+func (hc *HopCount) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(2, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(uint64(hc.Limit), w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(uint64(hc.Count), w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads HopCount's CBOR representation.
+func (hc *HopCount) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 2 {
+		return fmt.Errorf("HopCount: Expected array of length 2, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val > math.MaxUint8 {
+		return fmt.Errorf("HopCount.Limit: Value %d overflows uint8", val)
+	} else {
+		hc.Limit = uint8(val)
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val > math.MaxUint8 {
+		return fmt.Errorf("HopCount.Count: Value %d overflows uint8", val)
+	} else {
+		hc.Count = uint8(val)
+	}
+	return nil
+}
+
+// Metadata is generated from the CDDL rule metadata.
+type Metadata struct {
+	Key1 string    `cbor:"1"`
+	Key2 *uint64   `cbor:"2,omitempty"`
+	Key3 *[]string `cbor:"3,omitempty"`
+
+	Unknown []cboring.RawPair `cbor:",unknown"`
+}
+
+// MarshalCbor writes Metadata's CBOR representation.
+func (m *Metadata) MarshalCbor(w io.Writer) error {
+	fields := make([]cboring.IntMapField, 0, 3)
+	fields = append(fields, cboring.IntMapField{Key: 1, Write: func(w io.Writer) error {
+		if err := cboring.WriteTextString(m.Key1, w); err != nil {
+			return err
+		}
+		return nil
+	}})
+	if m.Key2 != nil {
+		fields = append(fields, cboring.IntMapField{Key: 2, Write: func(w io.Writer) error {
+			if err := cboring.WriteOptional(m.Key2, func(e0 uint64, w io.Writer) error {
+				if err := cboring.WriteUInt(e0, w); err != nil {
+					return err
+				}
+				return nil
+			}, w); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+	if m.Key3 != nil {
+		fields = append(fields, cboring.IntMapField{Key: 3, Write: func(w io.Writer) error {
+			if err := cboring.WriteOptional(m.Key3, func(e0 []string, w io.Writer) error {
+				if err := cboring.WriteArrayOf(e0, func(e1 string, w io.Writer) error {
+					if err := cboring.WriteTextString(e1, w); err != nil {
+						return err
+					}
+					return nil
+				}, w); err != nil {
+					return err
+				}
+				return nil
+			}, w); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+
+	return cboring.WriteIntMap(fields, m.Unknown, w)
+}
+
+// UnmarshalCbor reads Metadata's CBOR representation.
+func (m *Metadata) UnmarshalCbor(r io.Reader) (err error) {
+	var seen [3]bool
+	m.Unknown, err = cboring.NewIntMap().
+		Field(1, func(r io.Reader) error {
+			seen[0] = true
+			if val, err := cboring.ReadTextString(r); err != nil {
+				return err
+			} else {
+				m.Key1 = val
+			}
+			return nil
+		}).
+		Field(2, func(r io.Reader) error {
+			seen[1] = true
+			m.Key2 = new(uint64)
+			if val, err := cboring.ReadUInt(r); err != nil {
+				return err
+			} else {
+				*m.Key2 = val
+			}
+			return nil
+		}).
+		Field(3, func(r io.Reader) error {
+			seen[2] = true
+			m.Key3 = new([]string)
+			if val, err := cboring.ReadArrayOf(func(r io.Reader) (e0 string, err error) {
+				if val, err := cboring.ReadTextString(r); err != nil {
+					return e0, err
+				} else {
+					e0 = val
+				}
+				return e0, nil
+			}, r); err != nil {
+				return err
+			} else {
+				*m.Key3 = val
+			}
+			return nil
+		}).
+		Decode(r)
+	if err != nil {
+		return
+	}
+
+	if !seen[0] {
+		return fmt.Errorf("Metadata: Missing required key 1")
+	}
+	if !seen[1] {
+		m.Key2 = nil
+	}
+	if !seen[2] {
+		m.Key3 = nil
+	}
+	return nil
+}
+
+// Eid is generated from the CDDL rule eid.
+type Eid struct {
+	// Value is one of *DtnEid or *IpnEid.
+	Value any
+}
+
+// MarshalCbor writes Eid's CBOR representation.
+func (x *Eid) MarshalCbor(w io.Writer) error {
+	switch v := x.Value.(type) {
+	case *DtnEid:
+		if err := v.MarshalCbor(w); err != nil {
+			return err
+		}
+		return nil
+	case *IpnEid:
+		if err := v.MarshalCbor(w); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("Eid: Invalid value of type %T", v)
+	}
+}
+
+// UnmarshalCbor reads Eid's CBOR representation.
+func (x *Eid) UnmarshalCbor(r io.Reader) error {
+	return eidChoice.Decode(x, r)
+}
+
+// eidChoice decodes Eid's options.
+var eidChoice *cboring.Choice[*Eid]
+
+func init() {
+	eidChoice = cboring.NewChoice[*Eid]().
+		Major(cboring.Array, func(x *Eid, h cboring.Head, r io.Reader) error {
+			r = cboring.ReplayHead(h, r)
+			raw, err := cboring.ReadRawItem(r)
+			if err != nil {
+				return err
+			}
+
+			for _, f := range []func(r io.Reader) (any, error){
+				func(r io.Reader) (any, error) {
+					var v DtnEid
+					if err := v.UnmarshalCbor(r); err != nil {
+						return nil, err
+					}
+					return &v, nil
+				},
+				func(r io.Reader) (any, error) {
+					var v IpnEid
+					if err := v.UnmarshalCbor(r); err != nil {
+						return nil, err
+					}
+					return &v, nil
+				},
+			} {
+				if v, err := f(bytes.NewReader(raw)); err == nil {
+					x.Value = v
+					return nil
+				}
+			}
+			return fmt.Errorf("Eid: No option matches the data item %x", raw)
+		})
+}
+
+// DtnSsp is generated from the CDDL rule dtn-ssp.
+type DtnSsp struct {
+	// Value is one of uint64 or string.
+	Value any
+}
+
+// MarshalCbor writes DtnSsp's CBOR representation.
+func (ds *DtnSsp) MarshalCbor(w io.Writer) error {
+	switch v := ds.Value.(type) {
+	case uint64:
+		if !(v == 0) {
+			return fmt.Errorf("DtnSsp: Value %v is not allowed", v)
+		}
+		if err := cboring.WriteUInt(v, w); err != nil {
+			return err
+		}
+		return nil
+	case string:
+		if err := cboring.WriteTextString(v, w); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return fmt.Errorf("DtnSsp: Invalid value of type %T", v)
+	}
+}
+
+// UnmarshalCbor reads DtnSsp's CBOR representation.
+func (ds *DtnSsp) UnmarshalCbor(r io.Reader) error {
+	return dtnSspChoice.Decode(ds, r)
+}
+
+// dtnSspChoice decodes DtnSsp's options.
+var dtnSspChoice *cboring.Choice[*DtnSsp]
+
+func init() {
+	dtnSspChoice = cboring.NewChoice[*DtnSsp]().
+		Major(cboring.UInt, func(ds *DtnSsp, h cboring.Head, r io.Reader) error {
+			r = cboring.ReplayHead(h, r)
+			var v uint64
+			if val, err := cboring.ReadUInt(r); err != nil {
+				return err
+			} else {
+				v = val
+			}
+			if !(v == 0) {
+				return fmt.Errorf("DtnSsp: Value %v is not allowed", v)
+			}
+			ds.Value = v
+			return nil
+		}).
+		Major(cboring.TextString, func(ds *DtnSsp, h cboring.Head, r io.Reader) error {
+			r = cboring.ReplayHead(h, r)
+			var v string
+			if val, err := cboring.ReadTextString(r); err != nil {
+				return err
+			} else {
+				v = val
+			}
+			ds.Value = v
+			return nil
+		})
+}
@@ -0,0 +1,159 @@
+package bundle
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/dtn7/cboring"
+	"github.com/dtn7/cboring/cddl"
+)
+
+func TestBundleRoundTrip(t *testing.T) {
+	crc := []byte{0xAB, 0xCD}
+	b := Bundle{
+		PrimaryBlock: PrimaryBlock{
+			BundleControlFlags: 4,
+			CrcType:            1,
+			Destination:        Eid{Value: &IpnEid{IpnSsp: IpnSsp{Node: 23, Service: 42}}},
+			SourceNode:         Eid{Value: &DtnEid{DtnSsp: DtnSsp{Value: "//src/"}}},
+			ReportTo:           Eid{Value: &DtnEid{DtnSsp: DtnSsp{Value: uint64(0)}}},
+			CreationTimestamp:  CreationTimestamp{Time: 1, Sequence: 2},
+			Lifetime:           3600,
+			Crc:                &crc,
+		},
+		CanonicalBlock: []CanonicalBlock{
+			{BlockType: 1, BlockNumber: 1, Data: []byte("hello")},
+		},
+	}
+
+	buff := new(bytes.Buffer)
+	if err := cboring.Marshal(&b, buff); err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte{}, buff.Bytes()...)
+
+	var b2 Bundle
+	if err := cboring.Unmarshal(&b2, buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(b, b2) {
+		t.Fatalf("Bundle differs: %v != %v", b2, b)
+	}
+
+	// The generated code must match its specification.
+	spec, err := os.ReadFile("bundle.cddl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := cddl.Parse(string(spec))
+	if err != nil {
+		t.Fatal(err)
+	} else if err := schema.Validate("bundle", bytes.NewBuffer(data)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBundleInvalid(t *testing.T) {
+	tests := [][]byte{
+		// Version 6 instead of 7
+		{0x81, 0x88, 0x06, 0x00, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00, 0x00},
+		// Unknown EID scheme 3
+		{0x81, 0x88, 0x07, 0x00, 0x00, 0x82, 0x03, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00, 0x00},
+		// Missing lifetime
+		{0x81, 0x87, 0x07, 0x00, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00},
+		// CRC type 3 is out of range
+		{0x81, 0x88, 0x07, 0x00, 0x03, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00, 0x00},
+		// DTN SSP 1 instead of 0
+		{0x81, 0x88, 0x07, 0x00, 0x00, 0x82, 0x01, 0x01, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00, 0x00},
+		// CRC of three bytes
+		{0x81, 0x89, 0x07, 0x00, 0x01, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00, 0x00,
+			0x43, 0x00, 0x00, 0x00},
+		// Block control flag 3 is not defined
+		{0x82, 0x88, 0x07, 0x00, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x01, 0x00, 0x82, 0x00, 0x00, 0x00,
+			0x85, 0x01, 0x01, 0x08, 0x00, 0x40},
+	}
+
+	spec, err := os.ReadFile("bundle.cddl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := cddl.Parse(string(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range tests {
+		var b Bundle
+		if err := cboring.Unmarshal(&b, bytes.NewBuffer(data)); err == nil {
+			t.Fatalf("Invalid bundle %x did not error", data)
+		} else if err := schema.Validate("bundle", bytes.NewBuffer(data)); err == nil {
+			t.Fatalf("Invalid bundle %x is valid for its specification", data)
+		}
+	}
+
+	// Values violating the specification are not written either.
+	for _, b := range []Bundle{
+		{PrimaryBlock: PrimaryBlock{CrcType: 3}},
+		{PrimaryBlock: PrimaryBlock{Destination: Eid{Value: &DtnEid{DtnSsp: DtnSsp{Value: uint64(1)}}}}},
+		{PrimaryBlock: PrimaryBlock{Crc: &[]byte{}}},
+		{CanonicalBlock: []CanonicalBlock{{BlockControlFlags: 1 << 3}}},
+	} {
+		if err := cboring.Marshal(&b, io.Discard); err == nil {
+			t.Fatalf("Invalid bundle %v was written", b)
+		}
+	}
+}
+
+func TestMetadataRoundTrip(t *testing.T) {
+	n := uint64(5)
+	m := Metadata{
+		Key1:    "a",
+		Key2:    &n,
+		Unknown: []cboring.RawPair{{Key: []byte{0x09}, Value: []byte{0xF5}}},
+	}
+
+	buff := new(bytes.Buffer)
+	if err := cboring.Marshal(&m, buff); err != nil {
+		t.Fatal(err)
+	} else if data := []byte{0xA3, 0x01, 0x61, 0x61, 0x02, 0x05, 0x09, 0xF5}; !bytes.Equal(buff.Bytes(), data) {
+		t.Fatalf("CBOR differs: %x != %x", buff.Bytes(), data)
+	}
+
+	var m2 Metadata
+	if err := cboring.Unmarshal(&m2, buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(m, m2) {
+		t.Fatalf("Metadata differs: %v != %v", m2, m)
+	}
+}
+
+func TestMetadataInvalid(t *testing.T) {
+	tests := [][]byte{
+		// Missing required key 1
+		{0xA0},
+		{0xA1, 0x02, 0x05},
+		// Optional members are not nullable
+		{0xA2, 0x01, 0x61, 0x61, 0x02, 0xF6},
+		{0xA2, 0x01, 0x61, 0x61, 0x03, 0xF6},
+	}
+
+	for _, data := range tests {
+		var m Metadata
+		if err := cboring.Unmarshal(&m, bytes.NewBuffer(data)); err == nil {
+			t.Fatalf("Invalid metadata %x did not error", data)
+		}
+	}
+}
+
+func TestMetadataReuse(t *testing.T) {
+	n := uint64(5)
+	m := Metadata{Key1: "a", Key2: &n, Key3: &[]string{"b"}}
+
+	if err := cboring.Unmarshal(&m, bytes.NewBuffer([]byte{0xA1, 0x01, 0x61, 0x63})); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(m, Metadata{Key1: "c"}) {
+		t.Fatalf("Reused metadata resulted in %v", m)
+	}
+}
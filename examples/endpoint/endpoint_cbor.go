@@ -65,40 +65,47 @@ func (eid *endpointID) UnmarshalCbor(r io.Reader) error {
 	}
 
 	// SSP
-	if m, n, err := cboring.ReadMajors(r); err != nil {
-		return err
-	} else {
-		switch m {
-		case cboring.UInt:
-			// dtn:none
-			eid.SchemeSpecificPart = n
-
-		case cboring.TextString:
-			// dtn:whatsoever
-			if tmp, err := cboring.ReadRawBytes(n, r); err != nil {
-				return err
-			} else {
-				eid.SchemeSpecificPart = string(tmp)
-			}
+	return sspChoice.Decode(eid, r)
+}
 
-		case cboring.Array:
-			// ipn:23.42
-			if n != 2 {
-				return fmt.Errorf("Expected array with length 2, got %d", n)
-			}
+// sspChoice decodes an endpoint's scheme-specific part, depending on its Major
+// Type. Indefinite-length text strings and arrays are not allowed.
+var sspChoice = cboring.NewChoice[*endpointID]().
+	Major(cboring.UInt, func(eid *endpointID, h cboring.Head, _ io.Reader) error {
+		// dtn:none
+		eid.SchemeSpecificPart = h.Argument
+		return nil
+	}).
+	Major(cboring.TextString, func(eid *endpointID, h cboring.Head, r io.Reader) error {
+		// dtn:whatsoever
+		if h.Indefinite {
+			return fmt.Errorf("Indefinite-length text string is not allowed")
+		}
 
-			var ssps [2]uint64
-			for i := 0; i < 2; i++ {
-				if n, err := cboring.ReadUInt(r); err != nil {
-					return err
-				} else {
-					ssps[i] = n
-				}
-			}
+		if tmp, err := cboring.ReadRawBytes(h.Argument, r); err != nil {
+			return err
+		} else {
+			eid.SchemeSpecificPart = string(tmp)
+		}
+		return nil
+	}).
+	Major(cboring.Array, func(eid *endpointID, h cboring.Head, r io.Reader) error {
+		// ipn:23.42
+		if h.Indefinite {
+			return fmt.Errorf("Indefinite-length array is not allowed")
+		} else if h.Argument != 2 {
+			return fmt.Errorf("Expected array with length 2, got %d", h.Argument)
+		}
 
-			eid.SchemeSpecificPart = ssps
+		var ssps [2]uint64
+		for i := 0; i < 2; i++ {
+			if n, err := cboring.ReadUInt(r); err != nil {
+				return err
+			} else {
+				ssps[i] = n
+			}
 		}
-	}
 
-	return nil
-}
+		eid.SchemeSpecificPart = ssps
+		return nil
+	})
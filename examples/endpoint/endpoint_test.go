@@ -2,8 +2,10 @@ package endpoint
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/dtn7/cboring"
@@ -50,6 +52,19 @@ func TestEndpoint(t *testing.T) {
 	}
 }
 
+func TestEndpointUnmarshalInvalidSSP(t *testing.T) {
+	// SSP is a byte string, which is no alternative.
+	data := []byte{0x82, 0x01, 0x41, 0x00}
+
+	e := endpointID{}
+	var ce *cboring.ChoiceError
+	if err := cboring.Unmarshal(&e, bytes.NewBuffer(data)); !errors.As(err, &ce) {
+		t.Fatalf("Unmarshaling an invalid SSP returned %v: %v", err, e)
+	} else if len(ce.Majors) != 3 {
+		t.Fatalf("Accepted Major Types mismatch: %v", ce.Majors)
+	}
+}
+
 func BenchmarkEndpoint(b *testing.B) {
 	for _, test := range endpointTests {
 		b.Run(fmt.Sprintf("marshal-%s", test.eid), func(b *testing.B) {
@@ -75,3 +90,24 @@ func BenchmarkEndpoint(b *testing.B) {
 		})
 	}
 }
+
+func TestEndpointUnmarshalIndefiniteSSP(t *testing.T) {
+	tests := [][]byte{
+		// Indefinite-length text string "foo"
+		{0x82, 0x01, 0x7F, 0x63, 0x66, 0x6F, 0x6F, 0xFF},
+		// Indefinite-length array [0, 0]
+		{0x82, 0x02, 0x9F, 0x00, 0x00, 0xFF},
+	}
+
+	for _, test := range tests {
+		e := endpointID{}
+		var ce *cboring.ChoiceError
+		if err := cboring.Unmarshal(&e, bytes.NewBuffer(test)); err == nil {
+			t.Fatalf("Unmarshaling an indefinite-length SSP %x did not error", test)
+		} else if errors.As(err, &ce) {
+			t.Fatalf("Unmarshaling an indefinite-length SSP %x returned a ChoiceError: %v", test, err)
+		} else if !strings.Contains(err.Error(), "Indefinite-length") {
+			t.Fatalf("Error does not name the indefinite length: %v", err)
+		}
+	}
+}
@@ -46,6 +46,17 @@ func (pb *payloadBlock) MarshalCbor(w io.Writer) error {
 	return nil
 }
 
+func (pb *payloadBlock) CborSize() uint64 {
+	size := cboring.ArrayLengthSize(5)
+
+	fields := []uint64{pb.BlockType, pb.BlockNumber, pb.BlockControlFlags, pb.CRCType}
+	for _, f := range fields {
+		size += cboring.UIntSize(f)
+	}
+
+	return size + cboring.ByteStringSize(pb.Data)
+}
+
 func (pb *payloadBlock) UnmarshalCbor(r io.Reader) error {
 	// Start of an array with five elements
 	if l, err := cboring.ReadArrayLength(r); err != nil {
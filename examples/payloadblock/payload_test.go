@@ -38,6 +38,12 @@ func TestPayload(t *testing.T) {
 			t.Fatalf("PayloadBlock differs: %v != %v", pbTmp, pb)
 		}
 	})
+
+	t.Run("size", func(t *testing.T) {
+		if size := pb.CborSize(); size != uint64(len(pbData)) {
+			t.Fatalf("Size differs: %d != %d", size, len(pbData))
+		}
+	})
 }
 
 func BenchmarkPayload(b *testing.B) {
@@ -0,0 +1,4 @@
+// Package primaryblock contains a simplified version of the PrimaryBlock from
+// the dtn7-go <https://github.com/dtn7/dtn7-go> application, whose CBOR
+// methods are generated by cboring-gen.
+package primaryblock
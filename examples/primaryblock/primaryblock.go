@@ -0,0 +1,40 @@
+package primaryblock
+
+import "github.com/dtn7/cboring"
+
+//go:generate go run github.com/dtn7/cboring/cmd/cboring-gen -type primaryBlock,creationTimestamp,extension
+
+type primaryBlock struct {
+	_ struct{} `cbor:",asarray"`
+
+	Version        uint8             `cbor:"0"`
+	Flags          uint64            `cbor:"1"`
+	Destination    string            `cbor:"2"`
+	Source         string            `cbor:"3"`
+	Timestamp      creationTimestamp `cbor:"4"`
+	Lifetime       uint64            `cbor:"5"`
+	FragmentOffset *uint64           `cbor:"6"`
+	Extensions     []extension       `cbor:"7"`
+	CRC            []byte            `cbor:"8"`
+
+	// cached is not part of the CBOR representation.
+	cached bool
+}
+
+type creationTimestamp struct {
+	_ struct{} `cbor:",asarray"`
+
+	Time     uint64 `cbor:"0"`
+	Sequence uint64 `cbor:"1"`
+}
+
+// extension is encoded as a map with integer keys.
+type extension struct {
+	Type     int16             `cbor:"1"`
+	Critical bool              `cbor:"2,omitempty"`
+	Comment  *string           `cbor:"3,omitempty"`
+	Data     []byte            `cbor:"-1,omitempty"`
+	Raw      []byte            `cbor:"4,raw,omitempty"`
+	Labels   [][]string        `cbor:"5,omitempty"`
+	Unknown  []cboring.RawPair `cbor:",unknown"`
+}
@@ -0,0 +1,336 @@
+// Code generated by cboring-gen. DO NOT EDIT.
+
+package primaryblock
+
+import (
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/dtn7/cboring"
+)
+
+// MarshalCbor writes primaryBlock's CBOR representation.
+func (pb *primaryBlock) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(9, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(uint64(pb.Version), w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(pb.Flags, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteTextString(pb.Destination, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteTextString(pb.Source, w); err != nil {
+		return err
+	}
+	if err := pb.Timestamp.MarshalCbor(w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(pb.Lifetime, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteOptional(pb.FragmentOffset, func(e0 uint64, w io.Writer) error {
+		if err := cboring.WriteUInt(e0, w); err != nil {
+			return err
+		}
+		return nil
+	}, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteArrayOf(pb.Extensions, func(e0 extension, w io.Writer) error {
+		if err := e0.MarshalCbor(w); err != nil {
+			return err
+		}
+		return nil
+	}, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteByteString(pb.CRC, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads primaryBlock's CBOR representation.
+func (pb *primaryBlock) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 9 {
+		return fmt.Errorf("primaryBlock: Expected array of length 9, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else if val > math.MaxUint8 {
+		return fmt.Errorf("primaryBlock.Version: Value %d overflows uint8", val)
+	} else {
+		pb.Version = uint8(val)
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		pb.Flags = val
+	}
+	if val, err := cboring.ReadTextString(r); err != nil {
+		return err
+	} else {
+		pb.Destination = val
+	}
+	if val, err := cboring.ReadTextString(r); err != nil {
+		return err
+	} else {
+		pb.Source = val
+	}
+	if err := pb.Timestamp.UnmarshalCbor(r); err != nil {
+		return err
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		pb.Lifetime = val
+	}
+	if val, present, err := cboring.ReadOptional(func(r io.Reader) (e0 uint64, err error) {
+		if val, err := cboring.ReadUInt(r); err != nil {
+			return e0, err
+		} else {
+			e0 = val
+		}
+		return e0, nil
+	}, r); err != nil {
+		return err
+	} else if present {
+		pb.FragmentOffset = &val
+	} else {
+		pb.FragmentOffset = nil
+	}
+	if val, err := cboring.ReadArrayOf(func(r io.Reader) (e0 extension, err error) {
+		if err := e0.UnmarshalCbor(r); err != nil {
+			return e0, err
+		}
+		return e0, nil
+	}, r); err != nil {
+		return err
+	} else {
+		pb.Extensions = val
+	}
+	if val, err := cboring.ReadByteString(r); err != nil {
+		return err
+	} else {
+		pb.CRC = val
+	}
+	return nil
+}
+
+// MarshalCbor writes creationTimestamp's CBOR representation.
+func (ct *creationTimestamp) MarshalCbor(w io.Writer) error {
+	if err := cboring.WriteArrayLength(2, w); err != nil {
+		return err
+	}
+
+	if err := cboring.WriteUInt(ct.Time, w); err != nil {
+		return err
+	}
+	if err := cboring.WriteUInt(ct.Sequence, w); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UnmarshalCbor reads creationTimestamp's CBOR representation.
+func (ct *creationTimestamp) UnmarshalCbor(r io.Reader) error {
+	if l, err := cboring.ReadArrayLength(r); err != nil {
+		return err
+	} else if l != 2 {
+		return fmt.Errorf("creationTimestamp: Expected array of length 2, got %d", l)
+	}
+
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		ct.Time = val
+	}
+	if val, err := cboring.ReadUInt(r); err != nil {
+		return err
+	} else {
+		ct.Sequence = val
+	}
+	return nil
+}
+
+// MarshalCbor writes extension's CBOR representation.
+func (x *extension) MarshalCbor(w io.Writer) error {
+	fields := make([]cboring.IntMapField, 0, 6)
+	if len(x.Data) > 0 {
+		fields = append(fields, cboring.IntMapField{Key: -1, Write: func(w io.Writer) error {
+			if err := cboring.WriteByteString(x.Data, w); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+	fields = append(fields, cboring.IntMapField{Key: 1, Write: func(w io.Writer) error {
+		if err := cboring.WriteInt(int64(x.Type), w); err != nil {
+			return err
+		}
+		return nil
+	}})
+	if x.Critical {
+		fields = append(fields, cboring.IntMapField{Key: 2, Write: func(w io.Writer) error {
+			if err := cboring.WriteBoolean(x.Critical, w); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+	if x.Comment != nil {
+		fields = append(fields, cboring.IntMapField{Key: 3, Write: func(w io.Writer) error {
+			if err := cboring.WriteOptional(x.Comment, func(e0 string, w io.Writer) error {
+				if err := cboring.WriteTextString(e0, w); err != nil {
+					return err
+				}
+				return nil
+			}, w); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+	if len(x.Raw) > 0 {
+		fields = append(fields, cboring.IntMapField{Key: 4, Write: func(w io.Writer) error {
+			if len(x.Raw) == 0 {
+				if err := cboring.WriteNull(w); err != nil {
+					return err
+				}
+			} else if _, err := w.Write(x.Raw); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+	if len(x.Labels) > 0 {
+		fields = append(fields, cboring.IntMapField{Key: 5, Write: func(w io.Writer) error {
+			if err := cboring.WriteArrayOf(x.Labels, func(e0 []string, w io.Writer) error {
+				if err := cboring.WriteArrayOf(e0, func(e1 string, w io.Writer) error {
+					if err := cboring.WriteTextString(e1, w); err != nil {
+						return err
+					}
+					return nil
+				}, w); err != nil {
+					return err
+				}
+				return nil
+			}, w); err != nil {
+				return err
+			}
+			return nil
+		}})
+	}
+
+	return cboring.WriteIntMap(fields, x.Unknown, w)
+}
+
+// UnmarshalCbor reads extension's CBOR representation.
+func (x *extension) UnmarshalCbor(r io.Reader) (err error) {
+	var seen [6]bool
+	x.Unknown, err = cboring.NewIntMap().
+		Field(-1, func(r io.Reader) error {
+			seen[0] = true
+			if val, err := cboring.ReadByteString(r); err != nil {
+				return err
+			} else {
+				x.Data = val
+			}
+			return nil
+		}).
+		Field(1, func(r io.Reader) error {
+			seen[1] = true
+			if val, err := cboring.ReadInt(r); err != nil {
+				return err
+			} else if val < math.MinInt16 || val > math.MaxInt16 {
+				return fmt.Errorf("extension.Type: Value %d overflows int16", val)
+			} else {
+				x.Type = int16(val)
+			}
+			return nil
+		}).
+		Field(2, func(r io.Reader) error {
+			seen[2] = true
+			if val, err := cboring.ReadBoolean(r); err != nil {
+				return err
+			} else {
+				x.Critical = val
+			}
+			return nil
+		}).
+		Field(3, func(r io.Reader) error {
+			seen[3] = true
+			x.Comment = new(string)
+			if val, err := cboring.ReadTextString(r); err != nil {
+				return err
+			} else {
+				*x.Comment = val
+			}
+			return nil
+		}).
+		Field(4, func(r io.Reader) error {
+			seen[4] = true
+			if val, err := cboring.ReadRawItem(r); err != nil {
+				return err
+			} else {
+				x.Raw = val
+			}
+			return nil
+		}).
+		Field(5, func(r io.Reader) error {
+			seen[5] = true
+			if val, err := cboring.ReadArrayOf(func(r io.Reader) (e0 []string, err error) {
+				if val, err := cboring.ReadArrayOf(func(r io.Reader) (e1 string, err error) {
+					if val, err := cboring.ReadTextString(r); err != nil {
+						return e1, err
+					} else {
+						e1 = val
+					}
+					return e1, nil
+				}, r); err != nil {
+					return e0, err
+				} else {
+					e0 = val
+				}
+				return e0, nil
+			}, r); err != nil {
+				return err
+			} else {
+				x.Labels = val
+			}
+			return nil
+		}).
+		Decode(r)
+	if err != nil {
+		return
+	}
+
+	if !seen[0] {
+		x.Data = nil
+	}
+	if !seen[1] {
+		return fmt.Errorf("extension: Missing required key 1")
+	}
+	if !seen[2] {
+		x.Critical = false
+	}
+	if !seen[3] {
+		x.Comment = nil
+	}
+	if !seen[4] {
+		x.Raw = nil
+	}
+	if !seen[5] {
+		x.Labels = nil
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package primaryblock
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/dtn7/cboring"
+	"github.com/dtn7/cboring/reflectcbor"
+)
+
+func TestPrimaryBlock(t *testing.T) {
+	comment := "x"
+	pb := primaryBlock{
+		Version:     7,
+		Destination: "dtn:a",
+		Source:      "dtn:b",
+		Timestamp:   creationTimestamp{Time: 1, Sequence: 2},
+		Lifetime:    24,
+		Extensions:  []extension{{Type: -2, Comment: &comment}},
+		CRC:         []byte{0xAB},
+	}
+	data := []byte{
+		0x89, 0x07, 0x00,
+		0x65, 0x64, 0x74, 0x6E, 0x3A, 0x61,
+		0x65, 0x64, 0x74, 0x6E, 0x3A, 0x62,
+		0x82, 0x01, 0x02,
+		0x18, 0x18,
+		0xF6,
+		0x81, 0xA2, 0x01, 0x21, 0x03, 0x61, 0x78,
+		0x41, 0xAB,
+	}
+
+	buff := new(bytes.Buffer)
+	if err := cboring.Marshal(&pb, buff); err != nil {
+		t.Fatal(err)
+	} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, data) {
+		t.Fatalf("CBOR differs: %x != %x", bb, data)
+	}
+
+	var pb2 primaryBlock
+	if err := cboring.Unmarshal(&pb2, bytes.NewBuffer(data)); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(pb, pb2) {
+		t.Fatalf("Primary block differs: %v != %v", pb2, pb)
+	}
+}
+
+func TestExtensionRoundTrip(t *testing.T) {
+	offset := uint64(23)
+	pb := primaryBlock{
+		FragmentOffset: &offset,
+		Extensions: []extension{{
+			Type:     1,
+			Critical: true,
+			Data:     []byte{0x01, 0x02},
+			Raw:      []byte{0x82, 0x01, 0xF5},
+			Labels:   [][]string{{"a", "b"}, {}},
+			Unknown:  []cboring.RawPair{{Key: []byte{0x18, 0x2A}, Value: []byte{0xF6}}},
+		}},
+		CRC: []byte{},
+	}
+
+	buff := new(bytes.Buffer)
+	if err := cboring.Marshal(&pb, buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var pb2 primaryBlock
+	if err := cboring.Unmarshal(&pb2, buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(pb, pb2) {
+		t.Fatalf("Primary block differs: %v != %v", pb2, pb)
+	}
+}
+
+func TestPrimaryBlockInvalid(t *testing.T) {
+	tests := [][]byte{
+		// Array too short
+		{0x82, 0x07, 0x00},
+		// Version overflows uint8
+		{0x89, 0x19, 0x01, 0x00},
+		// Extension type overflows int16
+		{0x89, 0x07, 0x00, 0x60, 0x60, 0x82, 0x01, 0x02, 0x00, 0xF6,
+			0x81, 0xA1, 0x01, 0x1A, 0x00, 0x01, 0x00, 0x00, 0x40},
+	}
+
+	for _, test := range tests {
+		var pb primaryBlock
+		if err := cboring.Unmarshal(&pb, bytes.NewBuffer(test)); err == nil {
+			t.Fatalf("Illegal input %x did not error", test)
+		}
+	}
+}
+
+func TestExtensionReuse(t *testing.T) {
+	comment := "x"
+	ext := extension{
+		Type:     1,
+		Critical: true,
+		Comment:  &comment,
+		Data:     []byte{0x01},
+		Raw:      []byte{0xF5},
+		Labels:   [][]string{{"a"}},
+	}
+
+	// Omitted fields are reset when unmarshaling into a used extension.
+	if err := ext.UnmarshalCbor(bytes.NewReader([]byte{0xA1, 0x01, 0x21})); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(ext, extension{Type: -2}) {
+		t.Fatalf("Reused extension resulted in %v", ext)
+	}
+
+	// The Type is required, even if its value is zero.
+	if err := ext.UnmarshalCbor(bytes.NewReader([]byte{0xA1, 0x02, 0xF5})); err == nil {
+		t.Fatal("Missing required key did not error")
+	}
+	if err := ext.UnmarshalCbor(bytes.NewReader([]byte{0xA1, 0x01, 0x00})); err != nil {
+		t.Fatal(err)
+	}
+
+	// An omitted comment is nil, but null is never written for it.
+	if err := ext.UnmarshalCbor(bytes.NewReader([]byte{0xA2, 0x01, 0x00, 0x03, 0xF6})); err == nil {
+		t.Fatal("Null comment did not error")
+	}
+}
+
+// Without the generated methods, the same struct tags are used by reflectcbor.
+type (
+	reflectedPrimaryBlock primaryBlock
+	reflectedExtension    extension
+)
+
+func TestReflectCompatibility(t *testing.T) {
+	comment := "x"
+	offset := uint64(23)
+	ext := extension{
+		Type:     1,
+		Critical: true,
+		Comment:  &comment,
+		Data:     []byte{0x01, 0x02},
+		Raw:      []byte{0x82, 0x01, 0xF5},
+		Labels:   [][]string{{"a", "b"}, {}},
+	}
+	pb := primaryBlock{
+		Version:        7,
+		Destination:    "dtn:a",
+		Source:         "dtn:b",
+		Timestamp:      creationTimestamp{Time: 1, Sequence: 2},
+		FragmentOffset: &offset,
+		Extensions:     []extension{ext, {Type: -2}},
+		CRC:            []byte{0xAB},
+	}
+
+	tests := []struct {
+		generated cboring.CborMarshaler
+		reflected any
+	}{
+		{&ext, (*reflectedExtension)(&ext)},
+		{&extension{Type: -2}, &reflectedExtension{Type: -2}},
+		{&pb, (*reflectedPrimaryBlock)(&pb)},
+	}
+
+	for _, test := range tests {
+		var generated, reflected bytes.Buffer
+		if err := test.generated.MarshalCbor(&generated); err != nil {
+			t.Fatal(err)
+		} else if err := reflectcbor.Marshal(test.reflected, &reflected); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(generated.Bytes(), reflected.Bytes()) {
+			t.Fatalf("Reflected CBOR differs: %x != %x", reflected.Bytes(), generated.Bytes())
+		}
+
+		v := reflect.New(reflect.TypeOf(test.reflected).Elem())
+		if err := reflectcbor.Unmarshal(v.Interface(), &generated); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(v.Interface(), test.reflected) {
+			t.Fatalf("Reflected value differs: %v != %v", v.Interface(), test.reflected)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+)
+
+// Head is the initial byte of a data item together with its argument, as
+// specified in RFC 8949, section 3.
+type Head struct {
+	// Major is the data item's Major Type.
+	Major MajorType
+	// Info is the raw additional information, the lower five bits of the
+	// initial byte.
+	Info byte
+	// Argument is the value, length or count, depending on the Major Type. For
+	// floating-point values, it contains their raw bits.
+	Argument uint64
+	// Indefinite is set for the start of an indefinite-length string, array or
+	// map. The break stop code is not indefinite, see IsBreak.
+	Indefinite bool
+	// Width is the encoded length of the head in bytes, 1, 2, 3, 5 or 9.
+	Width int
+}
+
+// Initial returns the head's initial byte.
+func (h Head) Initial() byte {
+	return writeMajorType(h.Major, h.Info)
+}
+
+// IsBreak checks if this head is a break stop code.
+func (h Head) IsBreak() bool {
+	return h.Initial() == BreakCode
+}
+
+// IsMinimal checks if the head's argument is encoded in its shortest form, as
+// it would be written by WriteMajors. Floating-point values and
+// indefinite-length heads are always minimal. A simple value below 32 in two
+// bytes is not, as it is not even well-formed, RFC 8949, section 3.3.
+func (h Head) IsMinimal() bool {
+	if h.Major == SimpleData && h.Info == 24 {
+		return h.Argument >= 32
+	} else if h.Major == SimpleData || h.Info > 27 {
+		return true
+	}
+	return uint64(h.Width) == HeadSize(h.Argument)
+}
+
+// String describes the head, e.g., "array(2)" or "bytes(*)".
+func (h Head) String() string {
+	var name string
+	switch h.Major {
+	case UInt:
+		return fmt.Sprintf("unsigned(%d)", h.Argument)
+	case NInt:
+		return fmt.Sprintf("negative(-1-%d)", h.Argument)
+	case ByteString:
+		name = "bytes"
+	case TextString:
+		name = "text"
+	case Array:
+		name = "array"
+	case Map:
+		name = "map"
+	case Tag:
+		return fmt.Sprintf("tag(%d)", h.Argument)
+	default:
+		switch {
+		case h.IsBreak():
+			return "break"
+		case h.Info >= 25 && h.Info <= 27:
+			return fmt.Sprintf("float%d(0x%x)", 8<<(h.Info-24), h.Argument)
+		default:
+			return fmt.Sprintf("simple(%d)", h.Argument)
+		}
+	}
+
+	if h.Indefinite {
+		return name + "(*)"
+	}
+	return fmt.Sprintf("%s(%d)", name, h.Argument)
+}
+
+// ReservedInfoError is returned for a head with one of the reserved additional
+// information values 28, 29 or 30. Such a data item is not well-formed.
+type ReservedInfoError struct {
+	Initial byte
+}
+
+func (rie ReservedInfoError) Error() string {
+	return fmt.Sprintf("ReadHead: Reserved additional information %d in initial byte 0x%x",
+		rie.Initial&0x1F, rie.Initial)
+}
+
+// ReadHead parses the next head from the Reader.
+//
+// In contrast to ReadMajors, no special values are reported as errors. Both
+// indefinite-length heads and the break stop code are returned as a Head. An
+// error is only returned if reading failed, for the reserved additional
+// information values, as ReservedInfoError, or for an indefinite-length head
+// for a Major Type not supporting one. If the head was truncated after its
+// initial byte, io.ErrUnexpectedEOF is returned.
+func ReadHead(r io.Reader) (h Head, err error) {
+	var buff [1]byte
+	if _, err = io.ReadFull(r, buff[:]); err != nil {
+		return
+	}
+
+	if h, err = headFromInitial(buff[0]); err != nil || h.Width == 1 {
+		return
+	}
+
+	h.Argument, err = readArgument(h.Info, r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return
+}
+
+// headFromInitial creates a Head from its initial byte. Its Width is set, but
+// the Argument is only set if it is part of the initial byte.
+func headFromInitial(b byte) (h Head, err error) {
+	h.Major, h.Info = readMajorType(b)
+	h.Width = 1
+
+	switch {
+	case h.Info <= 23:
+		h.Argument = uint64(h.Info)
+
+	case h.Info <= 27:
+		h.Width += 1 << (h.Info - 24)
+
+	case h.Info <= 30:
+		err = ReservedInfoError{b}
+
+	default:
+		switch h.Major {
+		case ByteString, TextString, Array, Map:
+			h.Indefinite = true
+		case SimpleData:
+			// break stop code
+		default:
+			err = fmt.Errorf("ReadHead: Indefinite length for Major Type 0x%x", h.Major)
+		}
+	}
+
+	return
+}
+
+// appendHead appends the head's encoding, respecting its additional
+// information, to the byte slice.
+func appendHead(buff []byte, h Head) []byte {
+	buff = append(buff, h.Initial())
+	if h.Info >= 24 && h.Info <= 27 {
+		for i := (1 << (h.Info - 24)) - 1; i >= 0; i-- {
+			buff = append(buff, byte(h.Argument>>(8*i)))
+		}
+	}
+	return buff
+}
+
+// ReplayHead returns a Reader which reads the encoding of an already read
+// head, respecting its additional information, followed by the Reader's
+// remaining data. Thus, the whole data item can be read again, e.g., within a
+// Choice's handler.
+func ReplayHead(h Head, r io.Reader) io.Reader {
+	hr := &headReader{r: r}
+	hr.head = appendHead(hr.buff[:0], h)
+	return hr
+}
+
+// headReader reads a head's encoding before continuing with its Reader.
+type headReader struct {
+	buff [9]byte
+	head []byte
+	r    io.Reader
+}
+
+func (hr *headReader) Read(p []byte) (int, error) {
+	if len(hr.head) == 0 {
+		return hr.r.Read(p)
+	}
+
+	n := copy(p, hr.head)
+	hr.head = hr.head[n:]
+	return n, nil
+}
+
+// WriteHead writes a head into the Writer. In contrast to WriteMajors, the
+// head's additional information is respected. Thus, a non-minimal head is
+// written as it is.
+func WriteHead(h Head, w io.Writer) error {
+	var buff [9]byte
+	data := appendHead(buff[:0], h)
+
+	if wn, err := w.Write(data); err != nil {
+		return err
+	} else if wn != len(data) {
+		return fmt.Errorf("WriteHead: Wrote %d instead of %d bytes", wn, len(data))
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestReadHead(t *testing.T) {
+	tests := []struct {
+		data []byte
+		head Head
+	}{
+		{[]byte{0x00}, Head{Major: UInt, Info: 0, Argument: 0, Width: 1}},
+		{[]byte{0x18, 0x64}, Head{Major: UInt, Info: 24, Argument: 100, Width: 2}},
+		{[]byte{0x18, 0x01}, Head{Major: UInt, Info: 24, Argument: 1, Width: 2}},
+		{[]byte{0x39, 0x03, 0xE7}, Head{Major: NInt, Info: 25, Argument: 999, Width: 3}},
+		{[]byte{0x5F}, Head{Major: ByteString, Info: 31, Indefinite: true, Width: 1}},
+		{[]byte{0x7F}, Head{Major: TextString, Info: 31, Indefinite: true, Width: 1}},
+		{[]byte{0x9A, 0x00, 0x00, 0x00, 0x02}, Head{Major: Array, Info: 26, Argument: 2, Width: 5}},
+		{[]byte{0x9F}, Head{Major: Array, Info: 31, Indefinite: true, Width: 1}},
+		{[]byte{0xBF}, Head{Major: Map, Info: 31, Indefinite: true, Width: 1}},
+		{[]byte{0xD8, 0x18}, Head{Major: Tag, Info: 24, Argument: 24, Width: 2}},
+		{[]byte{0xF6}, Head{Major: SimpleData, Info: 22, Argument: 22, Width: 1}},
+		{[]byte{0xF7}, Head{Major: SimpleData, Info: 23, Argument: 23, Width: 1}},
+		{[]byte{0xF9, 0x3C, 0x00}, Head{Major: SimpleData, Info: 25, Argument: 0x3C00, Width: 3}},
+		{[]byte{0xFF}, Head{Major: SimpleData, Info: 31, Width: 1}},
+	}
+
+	for _, test := range tests {
+		// Read
+		h, err := ReadHead(bytes.NewBuffer(test.data))
+		if err != nil {
+			t.Fatal(err)
+		} else if h != test.head {
+			t.Fatalf("Head %#v mismatches %#v", h, test.head)
+		}
+
+		// Write
+		var buff bytes.Buffer
+		if err := WriteHead(h, &buff); err != nil {
+			t.Fatal(err)
+		} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.data)
+		}
+	}
+}
+
+func TestReadHeadError(t *testing.T) {
+	for _, b := range []byte{0x1C, 0x3D, 0x5E, 0xFC, 0xFD, 0xFE} {
+		var rie ReservedInfoError
+		if _, err := ReadHead(bytes.NewBuffer([]byte{b})); !errors.As(err, &rie) {
+			t.Fatalf("Initial byte 0x%x returned %v", b, err)
+		} else if rie.Initial != b {
+			t.Fatalf("ReservedInfoError has initial byte 0x%x instead of 0x%x", rie.Initial, b)
+		}
+
+		if _, _, err := ReadMajors(bytes.NewBuffer([]byte{b})); !errors.As(err, &rie) {
+			t.Fatalf("ReadMajors returned %v for initial byte 0x%x", err, b)
+		}
+	}
+
+	for _, b := range []byte{0x1F, 0x3F, 0xDF} {
+		if _, err := ReadHead(bytes.NewBuffer([]byte{b})); err == nil {
+			t.Fatalf("Indefinite length 0x%x did not error", b)
+		}
+	}
+
+	if _, err := ReadHead(bytes.NewBuffer(nil)); err != io.EOF {
+		t.Fatalf("Empty stream returned %v instead of io.EOF", err)
+	}
+	if _, err := ReadHead(bytes.NewBuffer([]byte{0x19, 0x01})); err != io.ErrUnexpectedEOF {
+		t.Fatalf("Truncated head returned %v instead of io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestReadMajorsFlags(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{IndefiniteArray}, FlagIndefiniteArray},
+		{[]byte{BreakCode}, FlagBreakCode},
+		{[]byte{Null}, FlagNull},
+	}
+
+	for _, test := range tests {
+		if _, _, err := ReadMajors(bytes.NewBuffer(test.data)); err != test.err {
+			t.Fatalf("ReadMajors returned %v instead of %v", err, test.err)
+		}
+	}
+
+	for _, b := range []byte{IndefiniteByteString, 0x7F, IndefiniteMap} {
+		if _, _, err := ReadMajors(bytes.NewBuffer([]byte{b})); err == nil {
+			t.Fatalf("ReadMajors did not error for 0x%x", b)
+		}
+	}
+}
+
+func TestHeadIsMinimal(t *testing.T) {
+	tests := []struct {
+		data    []byte
+		minimal bool
+	}{
+		{[]byte{0x17}, true},
+		{[]byte{0x18, 0x17}, false},
+		{[]byte{0x18, 0x18}, true},
+		{[]byte{0x99, 0x00, 0xFF}, false},
+		{[]byte{0xBF}, true},
+		{[]byte{0xF4}, true},
+		{[]byte{0xF8, 0x10}, false},
+		{[]byte{0xF8, 0x20}, true},
+		{[]byte{0xF8, 0xFF}, true},
+		{[]byte{0xFA, 0x00, 0x00, 0x00, 0x00}, true},
+	}
+
+	for _, test := range tests {
+		if h, err := ReadHead(bytes.NewBuffer(test.data)); err != nil {
+			t.Fatal(err)
+		} else if h.IsMinimal() != test.minimal {
+			t.Fatalf("Head %x is minimal: %t", test.data, h.IsMinimal())
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package cboring
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// IndexEntry locates an encoded data item within the indexed data.
+type IndexEntry struct {
+	// Offset of the data item's first byte.
+	Offset int64
+	// Length of the data item's encoding, including all nested data items.
+	Length int64
+	// Entries of the nested data items, as for an Item's Items: the elements
+	// of an array, the alternating keys and values of a map and the content of
+	// a tag. They are only recorded up to the depth requested for the Index.
+	Entries []IndexEntry
+}
+
+// Section returns a SectionReader for the data item's encoding.
+func (e IndexEntry) Section(r io.ReaderAt) *io.SectionReader {
+	return io.NewSectionReader(r, e.Offset, e.Length)
+}
+
+// Index locates the elements of a top-level array or the data items of a
+// CBOR Sequence stored in an io.ReaderAt, which can then be read lazily
+// without decoding their predecessors.
+//
+// An Index is a CborMarshaler itself, allowing it to be stored next to the
+// indexed data. Unlike other arrays, its entries are not limited by
+// MaxContainerLength, but by the indexed data's Size.
+type Index struct {
+	// Sequence is set for the data items of a CBOR Sequence, otherwise the
+	// Entries are the elements of a top-level array.
+	Sequence bool
+	// Size of the indexed data, to detect an outdated Index.
+	Size int64
+	// Entries of the elements or data items in their order.
+	Entries []IndexEntry
+}
+
+// NewArrayIndex scans the array of the ReaderAt's first size bytes once and
+// creates an Index of its elements. For a depth greater than zero, the nested
+// data items of the elements are indexed as well, up to this depth. An error
+// is returned if the data is not exactly one well-formed array.
+func NewArrayIndex(r io.ReaderAt, size int64, depth int) (*Index, error) {
+	s := newIndexScanner(r, size)
+
+	h, err := ReadHead(s)
+	if err != nil {
+		return nil, fmt.Errorf("NewArrayIndex: %w", err)
+	} else if h.Major != Array {
+		return nil, fmt.Errorf("NewArrayIndex: Wrong Major Type: 0x%x instead of 0x%x", h.Major, Array)
+	}
+
+	idx := &Index{Size: size, Entries: []IndexEntry{}}
+	for i := uint64(0); h.Indefinite || i < h.Argument; i++ {
+		start := s.offset
+		next, err := readNestedHead(s)
+		if err != nil {
+			return nil, fmt.Errorf("NewArrayIndex: Element %d: %w", i, err)
+		} else if next.IsBreak() && h.Indefinite {
+			break
+		}
+
+		entry, err := s.entry(next, start, depth, 1)
+		if err != nil {
+			return nil, fmt.Errorf("NewArrayIndex: Element %d: %w", i, err)
+		}
+		idx.Entries = append(idx.Entries, entry)
+	}
+
+	if s.offset != size {
+		return nil, fmt.Errorf("NewArrayIndex: Trailing data after offset %d", s.offset)
+	}
+	return idx, nil
+}
+
+// NewSequenceIndex scans the CBOR Sequence of the ReaderAt's first size bytes
+// once and creates an Index of its data items. For a depth greater than zero,
+// their nested data items are indexed as well, up to this depth. An error is
+// returned for a truncated or malformed data item.
+func NewSequenceIndex(r io.ReaderAt, size int64, depth int) (*Index, error) {
+	s := newIndexScanner(r, size)
+
+	idx := &Index{Sequence: true, Size: size, Entries: []IndexEntry{}}
+	for i := 0; ; i++ {
+		start := s.offset
+		h, err := ReadHead(s)
+		if err == io.EOF {
+			return idx, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("NewSequenceIndex: Data item %d: %w", i, err)
+		}
+
+		entry, err := s.entry(h, start, depth, 0)
+		if err != nil {
+			return nil, fmt.Errorf("NewSequenceIndex: Data item %d: %w", i, err)
+		}
+		idx.Entries = append(idx.Entries, entry)
+	}
+}
+
+// Len returns the number of indexed elements or data items.
+func (idx *Index) Len() int {
+	return len(idx.Entries)
+}
+
+// Entry returns the IndexEntry of an element or data item by its index. Further
+// indexes select nested data items, which must have been indexed up to this
+// depth.
+func (idx *Index) Entry(indexes ...int) (IndexEntry, error) {
+	if len(indexes) == 0 {
+		return IndexEntry{}, fmt.Errorf("Entry: No index")
+	}
+
+	entries := idx.Entries
+	var entry IndexEntry
+	for depth, i := range indexes {
+		if depth > 0 && entries == nil {
+			return IndexEntry{}, fmt.Errorf("Entry: Depth %d is not indexed", depth)
+		} else if i < 0 || i >= len(entries) {
+			return IndexEntry{}, fmt.Errorf("Entry: Index %d out of range of %d entries", i, len(entries))
+		}
+
+		entry = entries[i]
+		entries = entry.Entries
+	}
+	return entry, nil
+}
+
+// Section returns a SectionReader for the encoding of an element or data item,
+// selected as by Entry.
+func (idx *Index) Section(r io.ReaderAt, indexes ...int) (*io.SectionReader, error) {
+	entry, err := idx.Entry(indexes...)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Section(r), nil
+}
+
+func (idx *Index) MarshalCbor(w io.Writer) error {
+	if err := WriteArrayLength(3, w); err != nil {
+		return err
+	} else if err := WriteBoolean(idx.Sequence, w); err != nil {
+		return err
+	} else if err := WriteInt(idx.Size, w); err != nil {
+		return err
+	}
+	return writeIndexEntries(idx.Entries, w)
+}
+
+func (idx *Index) UnmarshalCbor(r io.Reader) (err error) {
+	if n, err := ReadArrayLength(r); err != nil {
+		return err
+	} else if n != 3 {
+		return fmt.Errorf("Index: Expected array of 3 elements, got %d", n)
+	}
+
+	if idx.Sequence, err = ReadBoolean(r); err != nil {
+		return fmt.Errorf("Index: Sequence: %w", err)
+	}
+	if idx.Size, err = ReadInt(r); err != nil {
+		return fmt.Errorf("Index: Size: %w", err)
+	} else if idx.Size < 0 {
+		return fmt.Errorf("Index: Illegal size %d", idx.Size)
+	}
+	if idx.Entries, err = readIndexEntries(r, idx.Size, 0); err != nil {
+		return fmt.Errorf("Index: %w", err)
+	}
+	return nil
+}
+
+// writeIndexEntries writes each IndexEntry as an array of its offset, its
+// length and, only if present, its nested entries.
+func writeIndexEntries(entries []IndexEntry, w io.Writer) error {
+	return WriteArrayOf(entries, func(e IndexEntry, w io.Writer) error {
+		n := uint64(2)
+		if e.Entries != nil {
+			n = 3
+		}
+
+		if err := WriteArrayLength(n, w); err != nil {
+			return err
+		} else if err := WriteInt(e.Offset, w); err != nil {
+			return err
+		} else if err := WriteInt(e.Length, w); err != nil {
+			return err
+		} else if e.Entries != nil {
+			return writeIndexEntries(e.Entries, w)
+		}
+		return nil
+	}, w)
+}
+
+// readIndexEntries reads the entries written by writeIndexEntries. Each entry
+// locates at least one byte, so their amount is limited by the length of the
+// data they are located in, instead of by MaxContainerLength.
+func readIndexEntries(r io.Reader, limit int64, depth int) ([]IndexEntry, error) {
+	if depth > MaxNestingDepth {
+		return nil, fmt.Errorf("Exceeded maximum nesting depth of %d", MaxNestingDepth)
+	}
+
+	n, err := ReadArrayLength(r)
+	if err != nil {
+		return nil, err
+	} else if n > uint64(limit) {
+		return nil, fmt.Errorf("Expected at most %d entries within %d bytes, got %d", limit, limit, n)
+	}
+
+	entries := []IndexEntry{}
+	for i := uint64(0); i < n; i++ {
+		e, err := readIndexEntry(r, depth)
+		if err != nil {
+			return nil, fmt.Errorf("Entry %d: %w", i, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func readIndexEntry(r io.Reader, depth int) (e IndexEntry, err error) {
+	n, err := ReadArrayLength(r)
+	if err != nil {
+		return
+	} else if n != 2 && n != 3 {
+		err = fmt.Errorf("Expected entry of 2 or 3 elements, got %d", n)
+		return
+	}
+
+	if e.Offset, err = ReadInt(r); err != nil {
+		return
+	} else if e.Length, err = ReadInt(r); err != nil {
+		return
+	} else if e.Offset < 0 || e.Length < 0 {
+		err = fmt.Errorf("Illegal entry of offset %d and length %d", e.Offset, e.Length)
+		return
+	}
+
+	if n == 3 {
+		e.Entries, err = readIndexEntries(r, e.Length, depth+1)
+	}
+	return
+}
+
+// indexScanner reads the indexed data sequentially and tracks its offset.
+type indexScanner struct {
+	r      *bufio.Reader
+	offset int64
+}
+
+func newIndexScanner(r io.ReaderAt, size int64) *indexScanner {
+	return &indexScanner{r: bufio.NewReader(io.NewSectionReader(r, 0, size))}
+}
+
+func (s *indexScanner) Read(p []byte) (n int, err error) {
+	n, err = s.r.Read(p)
+	s.offset += int64(n)
+	return
+}
+
+// entry scans the data item of the already read head, which started at the
+// offset start, and indexes its nested data items up to the depth.
+func (s *indexScanner) entry(h Head, start int64, depth, nesting int) (IndexEntry, error) {
+	if depth <= 0 || (h.Major != Array && h.Major != Map && h.Major != Tag) {
+		if err := copyItem(h, s, io.Discard, nesting); err != nil {
+			return IndexEntry{}, err
+		}
+		return IndexEntry{Offset: start, Length: s.offset - start}, nil
+	}
+
+	if nesting > MaxNestingDepth {
+		return IndexEntry{}, fmt.Errorf("Exceeded maximum nesting depth of %d", MaxNestingDepth)
+	}
+
+	count := h.Argument
+	switch h.Major {
+	case Map:
+		count *= 2
+	case Tag:
+		count = 1
+	}
+
+	entries := []IndexEntry{}
+	for i := uint64(0); h.Indefinite || i < count; i++ {
+		childStart := s.offset
+		next, err := readNestedHead(s)
+		if err != nil {
+			return IndexEntry{}, err
+		}
+
+		if next.IsBreak() && h.Indefinite {
+			if h.Major == Map && i%2 == 1 {
+				return IndexEntry{}, fmt.Errorf("Missing value of the last key")
+			}
+			break
+		}
+
+		entry, err := s.entry(next, childStart, depth-1, nesting+1)
+		if err != nil {
+			return IndexEntry{}, err
+		}
+		entries = append(entries, entry)
+	}
+	return IndexEntry{Offset: start, Length: s.offset - start, Entries: entries}, nil
+}
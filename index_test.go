@@ -0,0 +1,219 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestNewArrayIndex(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		depth    int
+		expected []IndexEntry
+	}{
+		{[]byte{0x80}, 0, []IndexEntry{}},
+		{[]byte{0x9F, 0xFF}, 0, []IndexEntry{}},
+		{
+			[]byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x62, 0x61, 0x62},
+			0,
+			[]IndexEntry{{Offset: 1, Length: 1}, {Offset: 2, Length: 3}, {Offset: 5, Length: 3}},
+		},
+		{
+			[]byte{0x9F, 0x19, 0x01, 0x00, 0xBF, 0x01, 0x02, 0xFF, 0xFF},
+			0,
+			[]IndexEntry{{Offset: 1, Length: 3}, {Offset: 4, Length: 4}},
+		},
+		// Nested data items
+		{
+			[]byte{0x83, 0x01, 0x82, 0x02, 0x9F, 0x03, 0xFF, 0xA1, 0x61, 0x61, 0xC1, 0x04},
+			1,
+			[]IndexEntry{
+				{Offset: 1, Length: 1},
+				{Offset: 2, Length: 5, Entries: []IndexEntry{{Offset: 3, Length: 1}, {Offset: 4, Length: 3}}},
+				{Offset: 7, Length: 5, Entries: []IndexEntry{{Offset: 8, Length: 2}, {Offset: 10, Length: 2}}},
+			},
+		},
+		{
+			[]byte{0x83, 0x01, 0x82, 0x02, 0x9F, 0x03, 0xFF, 0xA1, 0x61, 0x61, 0xC1, 0x04},
+			2,
+			[]IndexEntry{
+				{Offset: 1, Length: 1},
+				{Offset: 2, Length: 5, Entries: []IndexEntry{
+					{Offset: 3, Length: 1},
+					{Offset: 4, Length: 3, Entries: []IndexEntry{{Offset: 5, Length: 1}}},
+				}},
+				{Offset: 7, Length: 5, Entries: []IndexEntry{
+					{Offset: 8, Length: 2},
+					{Offset: 10, Length: 2, Entries: []IndexEntry{{Offset: 11, Length: 1}}},
+				}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		idx, err := NewArrayIndex(bytes.NewReader(test.data), int64(len(test.data)), test.depth)
+		if err != nil {
+			t.Fatalf("Indexing %x errored: %v", test.data, err)
+		} else if idx.Sequence || idx.Size != int64(len(test.data)) {
+			t.Fatalf("Indexing %x resulted in %v", test.data, idx)
+		} else if !reflect.DeepEqual(idx.Entries, test.expected) {
+			t.Fatalf("Indexing %x resulted in %v, expected %v", test.data, idx.Entries, test.expected)
+		}
+	}
+}
+
+func TestNewArrayIndexMalformed(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		depth int
+	}{
+		{[]byte{}, 0},
+		{[]byte{0x01}, 0},
+		{[]byte{0x82, 0x01}, 0},
+		{[]byte{0x9F, 0x01}, 0},
+		{[]byte{0x81, 0x01, 0x02}, 0},
+		{[]byte{0x81, 0xFF}, 0},
+		{[]byte{0x81, 0x82, 0x01}, 1},
+		{[]byte{0x81, 0xBF, 0x01, 0xFF}, 1},
+		{[]byte{0x81, 0x81, 0xFF}, 1},
+	}
+
+	for _, test := range tests {
+		if _, err := NewArrayIndex(bytes.NewReader(test.data), int64(len(test.data)), test.depth); err == nil {
+			t.Fatalf("Indexing %x did not error", test.data)
+		}
+	}
+}
+
+func TestNewSequenceIndex(t *testing.T) {
+	data := []byte{0x01, 0x82, 0x02, 0x03, 0x60}
+
+	idx, err := NewSequenceIndex(bytes.NewReader(data), int64(len(data)), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []IndexEntry{
+		{Offset: 0, Length: 1},
+		{Offset: 1, Length: 3, Entries: []IndexEntry{{Offset: 2, Length: 1}, {Offset: 3, Length: 1}}},
+		{Offset: 4, Length: 1},
+	}
+	if !idx.Sequence || idx.Len() != 3 || !reflect.DeepEqual(idx.Entries, expected) {
+		t.Fatalf("Unexpected index %v", idx)
+	}
+
+	// Only the first size bytes are indexed.
+	if _, err := NewSequenceIndex(bytes.NewReader(data), 3, 0); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF for a truncated data item, got %v", err)
+	}
+	if idx, err := NewSequenceIndex(bytes.NewReader(data), 0, 0); err != nil || idx.Len() != 0 {
+		t.Fatalf("Indexing an empty sequence resulted in %v, %v", idx, err)
+	}
+}
+
+func TestIndexSection(t *testing.T) {
+	data := []byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x62, 0x61, 0x62}
+	r := bytes.NewReader(data)
+
+	idx, err := NewArrayIndex(r, int64(len(data)), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		indexes  []int
+		expected []byte
+	}{
+		{[]int{0}, []byte{0x01}},
+		{[]int{1}, []byte{0x82, 0x02, 0x03}},
+		{[]int{1, 1}, []byte{0x03}},
+		{[]int{2}, []byte{0x62, 0x61, 0x62}},
+	}
+
+	for _, test := range tests {
+		section, err := idx.Section(r, test.indexes...)
+		if err != nil {
+			t.Fatalf("Section %v errored: %v", test.indexes, err)
+		}
+
+		if raw, err := io.ReadAll(section); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(raw, test.expected) {
+			t.Fatalf("Section %v resulted in %x, expected %x", test.indexes, raw, test.expected)
+		}
+	}
+
+	for _, indexes := range [][]int{{}, {3}, {-1}, {0, 0}, {1, 2}, {1, 0, 0}} {
+		if _, err := idx.Section(r, indexes...); err == nil {
+			t.Fatalf("Section %v did not error", indexes)
+		}
+	}
+
+	// A lazily read element can be unmarshaled.
+	section, _ := idx.Section(r, 1)
+	var item embeddedTestItem
+	if err := item.UnmarshalCbor(section); err != nil {
+		t.Fatal(err)
+	} else if item != (embeddedTestItem{2, 3}) {
+		t.Fatalf("Unexpected element %v", item)
+	}
+}
+
+func TestIndexMarshal(t *testing.T) {
+	data := []byte{0x9F, 0x01, 0x82, 0x02, 0x9F, 0x03, 0xFF, 0xFF}
+
+	idx, err := NewArrayIndex(bytes.NewReader(data), int64(len(data)), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buff bytes.Buffer
+	if err := idx.MarshalCbor(&buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var idx2 Index
+	if err := idx2.UnmarshalCbor(&buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(*idx, idx2) {
+		t.Fatalf("Index %v differs after unmarshaling: %v", idx, idx2)
+	}
+
+	for _, malformed := range [][]byte{
+		{0x82, 0xF4, 0x00},
+		{0x83, 0xF4, 0x00, 0x81, 0x81, 0x00},
+		{0x83, 0xF4, 0x00, 0x81, 0x82, 0x20, 0x00},
+		// Negative size
+		{0x83, 0xF4, 0x20, 0x80},
+		// More entries than bytes, also for nested entries
+		{0x83, 0xF4, 0x00, 0x81, 0x82, 0x00, 0x00},
+		{0x83, 0xF4, 0x01, 0x81, 0x83, 0x00, 0x01, 0x82, 0x82, 0x00, 0x00, 0x82, 0x00, 0x00},
+	} {
+		if err := new(Index).UnmarshalCbor(bytes.NewReader(malformed)); err == nil {
+			t.Fatalf("Unmarshaling %x did not error", malformed)
+		}
+	}
+}
+
+func TestIndexMarshalLarge(t *testing.T) {
+	// The amount of entries is not limited by MaxContainerLength.
+	idx := Index{Sequence: true, Size: int64(MaxContainerLength) + 1}
+	for i := int64(0); i < idx.Size; i++ {
+		idx.Entries = append(idx.Entries, IndexEntry{Offset: i, Length: 1})
+	}
+
+	var buff bytes.Buffer
+	if err := idx.MarshalCbor(&buff); err != nil {
+		t.Fatal(err)
+	}
+
+	var idx2 Index
+	if err := idx2.UnmarshalCbor(&buff); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(idx, idx2) {
+		t.Fatal("Large index differs after unmarshaling")
+	}
+}
@@ -0,0 +1,969 @@
+// Package cddlgen translates the rules of a CDDL specification into Go types,
+// to be generated together with their CborMarshaler methods by the codegen
+// package.
+package cddlgen
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dtn7/cboring/cddl"
+	"github.com/dtn7/cboring/internal/codegen"
+)
+
+// Result of a translation.
+type Result struct {
+	// Structs are the positional structs of arrays and the structs of maps.
+	Structs []codegen.Struct
+	// Unions are the sum types of type choices.
+	Unions []codegen.Union
+	// Reports describe each type which could not be translated. Those types
+	// are kept as raw CBOR data items.
+	Reports []string
+}
+
+// translator holds the state of a translation.
+type translator struct {
+	schema *cddl.Schema
+	result Result
+	// rules are the translated rules' types by their names.
+	rules map[string]*codegen.Type
+	// pending are the rules being translated, mapped to their Go names.
+	pending map[string]string
+	// names are the Go type names in use.
+	names map[string]bool
+}
+
+// Translate translates the named rules of the schema and all rules referenced
+// by them into Go types. If names is empty, all of the specification's rules
+// are translated.
+//
+// Arrays become positional structs, where integer literals are checked
+// constants, optional trailing members become pointers and a trailing member
+// of any occurrence becomes a slice of the remaining elements. An array of a
+// single repeated member becomes a slice. Maps with integer keys become
+// structs, with optional members becoming pointers and a member "* int =>
+// any" preserving unknown pairs. Type choices become unions, except for a
+// choice with null, which becomes a pointer.
+//
+// Literals, ranges, enumerations, minimal occurrences and the control
+// operators .size and .bits are checked by the generated code while reading
+// and writing. Everything else, e.g., tags, text keys, group choices or other
+// control operators, is kept as a raw data item and reported.
+func Translate(s *cddl.Schema, names []string) (*Result, error) {
+	t := &translator{
+		schema:  s,
+		rules:   make(map[string]*codegen.Type),
+		pending: make(map[string]string),
+		names:   make(map[string]bool),
+	}
+
+	if len(names) == 0 {
+		for _, name := range s.Names {
+			if s.Rules[name].Type != nil {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		rule, ok := s.Rules[name]
+		if !ok {
+			return nil, fmt.Errorf("Translate: Unknown rule %s", name)
+		} else if rule.Type == nil {
+			return nil, fmt.Errorf("Translate: Rule %s is a group, not a type", name)
+		}
+
+		if _, err := t.rule(rule); err != nil {
+			return nil, err
+		}
+	}
+	return &t.result, nil
+}
+
+// GoName converts a CDDL name into an exported Go name, e.g., "PrimaryBlock"
+// for "primary-block".
+func GoName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, c := range name {
+		switch {
+		case c == '-' || c == '_' || c == '.' || c == '$' || c == '@':
+			upper = true
+		case upper:
+			b.WriteRune(unicode.ToUpper(c))
+			upper = false
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	if b.Len() == 0 || !unicode.IsLetter([]rune(b.String())[0]) {
+		return "X" + b.String()
+	}
+	return b.String()
+}
+
+// typeName reserves a unique Go type name.
+func (t *translator) typeName(name string) string {
+	unique := name
+	for i := 2; t.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	t.names[unique] = true
+	return unique
+}
+
+// report records a problem for a type, identified by its path.
+func (t *translator) report(path, format string, args ...any) *codegen.Type {
+	t.result.Reports = append(t.result.Reports, path+": "+fmt.Sprintf(format, args...))
+	return &codegen.Type{Kind: codegen.Raw}
+}
+
+// lastReport returns the last report's message since a number of reports, to
+// be used as a field's comment.
+func (t *translator) lastReport(since int, path string) string {
+	if len(t.result.Reports) == since {
+		return ""
+	}
+	return strings.TrimPrefix(t.result.Reports[len(t.result.Reports)-1], path+": ")
+}
+
+// rule translates a rule once, named after the rule.
+func (t *translator) rule(rule *cddl.Rule) (*codegen.Type, error) {
+	if typ, ok := t.rules[rule.Name]; ok {
+		return typ, nil
+	}
+
+	if name, ok := t.pending[rule.Name]; ok {
+		// A recursive reference requires a named type.
+		return &codegen.Type{Kind: codegen.Marshaler, Name: name}, nil
+	}
+
+	name := t.typeName(GoName(rule.Name))
+	t.pending[rule.Name] = name
+	typ, err := t.typeOf(rule.Type, name, rule.Name,
+		fmt.Sprintf("%s is generated from the CDDL rule %s.", name, rule.Name))
+	delete(t.pending, rule.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	t.rules[rule.Name] = typ
+	if typ.Kind != codegen.Marshaler || typ.Name != name {
+		// The rule became an unnamed type, e.g., an uint64 or a slice.
+		delete(t.names, name)
+		if t.referenced(name) {
+			return nil, fmt.Errorf("Translate: Recursive rule %s must be an array, a map or a choice", rule.Name)
+		}
+	}
+	return typ, nil
+}
+
+// referenced checks if any translated type references the named type.
+func (t *translator) referenced(name string) bool {
+	var uses func(typ *codegen.Type) bool
+	uses = func(typ *codegen.Type) bool {
+		if typ == nil {
+			return false
+		}
+		return (typ.Kind == codegen.Marshaler && typ.Name == name) || uses(typ.Elem)
+	}
+
+	for _, s := range t.result.Structs {
+		for _, field := range s.Fields {
+			if uses(field.Type) {
+				return true
+			}
+		}
+	}
+	for _, u := range t.result.Unions {
+		for _, option := range u.Options {
+			if uses(option) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// prelude translates the standard prelude's types.
+func (t *translator) prelude(name, path string) *codegen.Type {
+	switch name {
+	case "uint":
+		return &codegen.Type{Kind: codegen.UInt, Name: "uint64"}
+	case "nint", "int":
+		return &codegen.Type{Kind: codegen.Int, Name: "int64"}
+	case "bool":
+		return &codegen.Type{Kind: codegen.Bool}
+	case "tstr", "text":
+		return &codegen.Type{Kind: codegen.String}
+	case "bstr", "bytes":
+		return &codegen.Type{Kind: codegen.Bytes}
+	case "float16", "float32", "float16-32":
+		return &codegen.Type{Kind: codegen.Float32}
+	case "float64", "float32-64", "float":
+		return &codegen.Type{Kind: codegen.Float64}
+	case "any":
+		return &codegen.Type{Kind: codegen.Raw}
+	default:
+		return t.report(path, "unsupported type %s", name)
+	}
+}
+
+// typeOf translates a type. Its name is used for a struct or union and the doc
+// for its declaration. The path is used for reports.
+func (t *translator) typeOf(typ cddl.Type, name, path, doc string) (*codegen.Type, error) {
+	switch typ := typ.(type) {
+	case *cddl.Ref:
+		rule := t.schema.Rules[typ.Name]
+		switch {
+		case rule.Line == 0:
+			return t.prelude(typ.Name, path), nil
+		case rule.Type == nil:
+			return t.report(path, "group %s used as a type", typ.Name), nil
+		default:
+			return t.rule(rule)
+		}
+
+	case *cddl.Literal:
+		value, ok := constant(typ.Value)
+		if !ok {
+			return t.report(path, "unsupported literal %v", typ), nil
+		}
+		return checked(literalType(typ.Value), codegen.Check{Values: []string{value}}), nil
+
+	case *cddl.Range:
+		return t.rangeType(typ, path), nil
+
+	case *cddl.Control:
+		reports := len(t.result.Reports)
+		target, err := t.typeOf(typ.Target, name, path, doc)
+		if err != nil {
+			return nil, err
+		} else if len(t.result.Reports) > reports {
+			return target, nil
+		}
+		return t.control(typ, target, path), nil
+
+	case *cddl.Array:
+		return t.arrayType(typ.Group, name, path, doc)
+
+	case *cddl.Map:
+		return t.mapType(typ.Group, name, path, doc)
+
+	case *cddl.Choice:
+		return t.choiceType(typ, name, path, doc)
+
+	case *cddl.MajorType:
+		return t.majorType(typ, path), nil
+
+	case *cddl.Enum:
+		var values []string
+		for _, value := range t.enumValues(typ.Group, 0) {
+			n, ok := t.literal(value).(uint64)
+			if !ok {
+				return t.report(path, "enumeration of non-integers %v", typ), nil
+			}
+			values = append(values, fmt.Sprint(n))
+		}
+		return checked(&codegen.Type{Kind: codegen.UInt, Name: "uint64"}, codegen.Check{Values: values}), nil
+
+	default:
+		return t.report(path, "unsupported type %v", typ), nil
+	}
+}
+
+// literalType returns the type of a literal value.
+func literalType(value any) *codegen.Type {
+	switch value.(type) {
+	case uint64:
+		return &codegen.Type{Kind: codegen.UInt, Name: "uint64"}
+	case int64:
+		return &codegen.Type{Kind: codegen.Int, Name: "int64"}
+	case float64:
+		return &codegen.Type{Kind: codegen.Float64}
+	case string:
+		return &codegen.Type{Kind: codegen.String}
+	default:
+		return &codegen.Type{Kind: codegen.Bytes}
+	}
+}
+
+// constant returns a literal's value as a Go expression. Byte strings cannot
+// be compared and are not supported.
+func constant(value any) (string, bool) {
+	switch value := value.(type) {
+	case uint64, int64:
+		return fmt.Sprint(value), true
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return "", false
+		}
+		return strconv.FormatFloat(value, 'g', -1, 64), true
+	case string:
+		return strconv.Quote(value), true
+	default:
+		return "", false
+	}
+}
+
+// checked returns a copy of a type with the checks, as translated types might
+// be shared by references. An empty check allows any value.
+func checked(typ *codegen.Type, checks ...codegen.Check) *codegen.Type {
+	c := *typ
+	c.Checks = checks
+	for _, check := range checks {
+		if len(check.Values) == 0 && check.Min == "" && check.Max == "" && check.Mask == "" {
+			c.Checks = nil
+		}
+	}
+	return &c
+}
+
+// resolve follows references to rules and returns the referenced type.
+func (t *translator) resolve(typ cddl.Type) cddl.Type {
+	for i := 0; i < len(t.schema.Rules); i++ {
+		ref, ok := typ.(*cddl.Ref)
+		if !ok || t.schema.Rules[ref.Name].Type == nil {
+			return typ
+		}
+		typ = t.schema.Rules[ref.Name].Type
+	}
+	return typ
+}
+
+// enumValues returns the values of an enumeration's group, splicing
+// referenced groups.
+func (t *translator) enumValues(g *cddl.Group, depth int) (values []cddl.Type) {
+	for _, e := range t.flatten(g) {
+		ref, ok := e.Value.(*cddl.Ref)
+		if ok && t.schema.Rules[ref.Name].Group != nil && depth < len(t.schema.Rules) {
+			values = append(values, t.enumValues(t.schema.Rules[ref.Name].Group, depth+1)...)
+		} else {
+			values = append(values, e.Value)
+		}
+	}
+	return
+}
+
+// literal follows references to a literal and returns its value, or nil.
+func (t *translator) literal(typ cddl.Type) any {
+	for i := 0; i < len(t.schema.Rules); i++ {
+		switch tt := typ.(type) {
+		case *cddl.Literal:
+			return tt.Value
+		case *cddl.Ref:
+			if typ = t.schema.Rules[tt.Name].Type; typ == nil {
+				return nil
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// uintName returns the smallest unsigned integer type for the maximum.
+func uintName(max uint64) string {
+	switch {
+	case max <= math.MaxUint8:
+		return "uint8"
+	case max <= math.MaxUint16:
+		return "uint16"
+	case max <= math.MaxUint32:
+		return "uint32"
+	default:
+		return "uint64"
+	}
+}
+
+// uintMax returns the maximum of an unsigned integer type.
+func uintMax(name string) uint64 {
+	switch name {
+	case "uint8":
+		return math.MaxUint8
+	case "uint16":
+		return math.MaxUint16
+	case "uint32":
+		return math.MaxUint32
+	default:
+		return math.MaxUint64
+	}
+}
+
+// intBounds returns the minimum and maximum of a signed integer type.
+func intBounds(name string) (int64, int64) {
+	switch name {
+	case "int8":
+		return math.MinInt8, math.MaxInt8
+	case "int16":
+		return math.MinInt16, math.MaxInt16
+	case "int32":
+		return math.MinInt32, math.MaxInt32
+	default:
+		return math.MinInt64, math.MaxInt64
+	}
+}
+
+// intName returns the smallest signed integer type for the range.
+func intName(min, max int64) string {
+	switch {
+	case min >= math.MinInt8 && max <= math.MaxInt8:
+		return "int8"
+	case min >= math.MinInt16 && max <= math.MaxInt16:
+		return "int16"
+	case min >= math.MinInt32 && max <= math.MaxInt32:
+		return "int32"
+	default:
+		return "int64"
+	}
+}
+
+// rangeType translates a range into the smallest fitting number type, which
+// checks the bounds not covered by the type itself.
+func (t *translator) rangeType(r *cddl.Range, path string) *codegen.Type {
+	minValue, maxValue := t.literal(r.Min), t.literal(r.Max)
+
+	if r.Exclusive {
+		switch m := maxValue.(type) {
+		case uint64:
+			if m == 0 {
+				return t.report(path, "empty range %v", r)
+			}
+			maxValue = m - 1
+		case int64:
+			maxValue = m - 1
+		default:
+			return t.report(path, "unsupported exclusive range %v", r)
+		}
+	}
+
+	switch maxValue := maxValue.(type) {
+	case uint64:
+		switch minValue := minValue.(type) {
+		case uint64:
+			typ := &codegen.Type{Kind: codegen.UInt, Name: uintName(maxValue)}
+			var check codegen.Check
+			if minValue > 0 {
+				check.Min = fmt.Sprint(minValue)
+			}
+			if maxValue < uintMax(typ.Name) {
+				check.Max = fmt.Sprint(maxValue)
+			}
+			return checked(typ, check)
+		case int64:
+			if maxValue <= math.MaxInt64 {
+				return intRange(minValue, int64(maxValue))
+			}
+		}
+	case int64:
+		if minValue, ok := minValue.(int64); ok {
+			return intRange(minValue, maxValue)
+		}
+	case float64:
+		switch minValue.(type) {
+		case uint64, int64, float64:
+			minConst, _ := constant(minValue)
+			maxConst, _ := constant(maxValue)
+			return checked(&codegen.Type{Kind: codegen.Float64}, codegen.Check{Min: minConst, Max: maxConst})
+		}
+	}
+	return t.report(path, "unsupported range %v", r)
+}
+
+// intRange returns the smallest signed integer type for the range, which
+// checks the bounds not covered by the type itself.
+func intRange(minValue, maxValue int64) *codegen.Type {
+	typ := &codegen.Type{Kind: codegen.Int, Name: intName(minValue, maxValue)}
+	typeMin, typeMax := intBounds(typ.Name)
+
+	var check codegen.Check
+	if minValue > typeMin {
+		check.Min = fmt.Sprint(minValue)
+	}
+	if maxValue < typeMax {
+		check.Max = fmt.Sprint(maxValue)
+	}
+	return checked(typ, check)
+}
+
+// control translates a control operator on the already translated target.
+// Only .size and .bits are supported, checking the value while reading and
+// writing.
+func (t *translator) control(c *cddl.Control, target *codegen.Type, path string) *codegen.Type {
+	if len(target.Checks) > 0 {
+		return t.report(path, "control operator on a constrained type %v", c)
+	}
+
+	switch {
+	case c.Op == "size" && target.Kind == codegen.UInt:
+		n, ok := t.literal(c.Controller).(uint64)
+		if !ok {
+			break
+		} else if n >= 8 {
+			return target
+		}
+
+		maxValue := uint64(1)<<(8*n) - 1
+		typ := &codegen.Type{Kind: codegen.UInt, Name: uintName(maxValue)}
+		if maxValue < uintMax(typ.Name) {
+			return checked(typ, codegen.Check{Max: fmt.Sprint(maxValue)})
+		}
+		return typ
+
+	case c.Op == "size" && (target.Kind == codegen.String || target.Kind == codegen.Bytes):
+		switch size := t.resolve(c.Controller).(type) {
+		case *cddl.Literal:
+			if n, ok := size.Value.(uint64); ok {
+				return checked(target, codegen.Check{Min: fmt.Sprint(n), Max: fmt.Sprint(n)})
+			}
+		case *cddl.Range:
+			minValue, minOK := t.literal(size.Min).(uint64)
+			maxValue, maxOK := t.literal(size.Max).(uint64)
+			if size.Exclusive && maxValue == 0 {
+				break
+			} else if size.Exclusive {
+				maxValue--
+			}
+
+			if minOK && maxOK {
+				check := codegen.Check{Max: fmt.Sprint(maxValue)}
+				if minValue > 0 {
+					check.Min = fmt.Sprint(minValue)
+				}
+				return checked(target, check)
+			}
+		}
+
+	case c.Op == "bits" && target.Kind == codegen.UInt:
+		var values []cddl.Type
+		switch bits := t.resolve(c.Controller).(type) {
+		case *cddl.Enum:
+			values = t.enumValues(bits.Group, 0)
+		case *cddl.Choice:
+			values = bits.Options
+		default:
+			values = []cddl.Type{bits}
+		}
+
+		var mask uint64
+		for _, value := range values {
+			n, ok := t.literal(value).(uint64)
+			if !ok || n >= 64 {
+				return t.report(path, "unsupported bits %v", c)
+			}
+			mask |= 1 << n
+		}
+		return checked(target, codegen.Check{Mask: fmt.Sprintf("%#x", mask)})
+	}
+	return t.report(path, "unsupported control operator %v", c)
+}
+
+func (t *translator) majorType(m *cddl.MajorType, path string) *codegen.Type {
+	switch {
+	case m.Major < 0:
+		return &codegen.Type{Kind: codegen.Raw}
+	case m.Major == 7 && m.Argument != nil:
+		switch *m.Argument {
+		case 20:
+			return &codegen.Type{Kind: codegen.Bool, Checks: []codegen.Check{{Values: []string{"false"}}}}
+		case 21:
+			return &codegen.Type{Kind: codegen.Bool, Checks: []codegen.Check{{Values: []string{"true"}}}}
+		case 25, 26:
+			return &codegen.Type{Kind: codegen.Float32}
+		case 27:
+			return &codegen.Type{Kind: codegen.Float64}
+		}
+	case m.Argument != nil:
+	case m.Major == 0:
+		return &codegen.Type{Kind: codegen.UInt, Name: "uint64"}
+	case m.Major == 1:
+		return &codegen.Type{Kind: codegen.Int, Name: "int64"}
+	case m.Major == 2:
+		return &codegen.Type{Kind: codegen.Bytes}
+	case m.Major == 3:
+		return &codegen.Type{Kind: codegen.String}
+	}
+	return t.report(path, "unsupported type %v", m)
+}
+
+// isNull checks if a type is the prelude's null.
+func (t *translator) isNull(typ cddl.Type) bool {
+	ref, ok := typ.(*cddl.Ref)
+	return ok && (ref.Name == "null" || ref.Name == "nil") && t.schema.Rules[ref.Name].Line == 0
+}
+
+func (t *translator) choiceType(c *cddl.Choice, name, path, doc string) (*codegen.Type, error) {
+	var options []cddl.Type
+	nullable := false
+	for _, option := range c.Options {
+		if t.isNull(option) {
+			nullable = true
+		} else {
+			options = append(options, option)
+		}
+	}
+
+	var typ *codegen.Type
+	var err error
+	if len(options) == 1 {
+		typ, err = t.typeOf(options[0], name, path, doc)
+	} else {
+		typ, err = t.unionType(options, name, path, doc)
+	}
+
+	if err != nil || !nullable || typ.Kind == codegen.Raw {
+		return typ, err
+	} else if typ.Kind == codegen.Pointer {
+		return t.report(path, "nested null in %v", c), nil
+	}
+	return &codegen.Type{Kind: codegen.Pointer, Elem: typ}, nil
+}
+
+func (t *translator) unionType(options []cddl.Type, name, path, doc string) (*codegen.Type, error) {
+	// Reserve the union's position and name before its options.
+	index := len(t.result.Unions)
+	t.result.Unions = append(t.result.Unions, codegen.Union{})
+	if !t.names[name] {
+		name = t.typeName(name)
+	}
+
+	u := codegen.Union{Name: name, Doc: doc}
+	goTypes := make(map[string]bool)
+	for i, option := range options {
+		optionName := t.typeName(fmt.Sprintf("%s%d", name, i))
+		typ, err := t.typeOf(option, optionName, fmt.Sprintf("%s/%d", path, i),
+			fmt.Sprintf("%s is option %d of %s.", optionName, i, name))
+		if err != nil {
+			return nil, err
+		}
+		if typ.Kind != codegen.Marshaler || typ.Name != optionName {
+			delete(t.names, optionName)
+		}
+
+		switch goType := typ.GoType(); {
+		case typ.Kind == codegen.Pointer:
+			return t.dropUnion(index, name, path, "nested null in option %d", i), nil
+		case goTypes[goType] && typ.Kind == codegen.Marshaler:
+			return t.dropUnion(index, name, path, "option %d repeats %s", i, goType), nil
+		case !goTypes[goType]:
+			goTypes[goType] = true
+			u.Options = append(u.Options, typ)
+		default:
+			// Options sharing a Go type, e.g., literals, allow the values of
+			// either one.
+			for j, other := range u.Options {
+				if other.GoType() != goType {
+					continue
+				} else if merged, ok := mergeChecks(other, typ); ok {
+					u.Options[j] = merged
+				} else {
+					return t.dropUnion(index, name, path, "option %d repeats %s with other constraints", i, goType), nil
+				}
+			}
+		}
+	}
+
+	if len(u.Options) == 1 {
+		// All options share a type, e.g., for a choice of literals.
+		t.result.Unions = append(t.result.Unions[:index], t.result.Unions[index+1:]...)
+		delete(t.names, name)
+		return u.Options[0], nil
+	}
+
+	t.result.Unions[index] = u
+	return &codegen.Type{Kind: codegen.Marshaler, Name: name}, nil
+}
+
+// mergeChecks returns a type of two options of the same Go type, which allows
+// the values of either one. Only the checks of the options themselves are
+// merged, not those of nested elements.
+func mergeChecks(a, b *codegen.Type) (*codegen.Type, bool) {
+	if a.Elem != nil && !reflect.DeepEqual(a.Elem, b.Elem) {
+		return nil, false
+	} else if len(a.Checks) == 0 || len(b.Checks) == 0 {
+		return checked(a), true
+	}
+	return checked(a, append(append([]codegen.Check(nil), a.Checks...), b.Checks...)...), true
+}
+
+// dropUnion removes a reserved union, which cannot be translated, and reports
+// the problem. Its nested types are kept, as they might already be referenced.
+func (t *translator) dropUnion(index int, name, path, format string, args ...any) *codegen.Type {
+	delete(t.names, name)
+	t.result.Unions = append(t.result.Unions[:index], t.result.Unions[index+1:]...)
+	return t.report(path, format, args...)
+}
+
+// flattenChecked returns a group's entries, splicing nested groups. It reports
+// false for group choices or occurrences of nested groups, which are not
+// supported.
+func (t *translator) flattenChecked(g *cddl.Group, depth int) ([]*cddl.Entry, bool) {
+	if len(g.Choices) != 1 || depth > len(t.schema.Rules) {
+		return nil, false
+	}
+
+	var entries []*cddl.Entry
+	for _, e := range g.Choices[0] {
+		var inner *cddl.Group
+		switch v := e.Value.(type) {
+		case *cddl.GroupType:
+			inner = v.Group
+		case *cddl.Ref:
+			inner = t.schema.Rules[v.Name].Group
+		}
+
+		if inner == nil {
+			entries = append(entries, e)
+			continue
+		} else if e.Min != 1 || e.Max != 1 || e.Key != nil {
+			return nil, false
+		}
+
+		nested, ok := t.flattenChecked(inner, depth+1)
+		if !ok {
+			return nil, false
+		}
+		entries = append(entries, nested...)
+	}
+	return entries, true
+}
+
+// flatten returns a group's entries of all choices, e.g., for enumerations.
+func (t *translator) flatten(g *cddl.Group) (entries []*cddl.Entry) {
+	for _, choice := range g.Choices {
+		entries = append(entries, choice...)
+	}
+	return
+}
+
+// fieldName returns the Go name of a member, either by its bareword key or
+// by the name of its referenced rule. The fallback is used otherwise.
+func (t *translator) fieldName(e *cddl.Entry, fallback string) string {
+	if l, ok := e.Key.(*cddl.Literal); ok {
+		if s, ok := l.Value.(string); ok {
+			return GoName(s)
+		}
+	}
+	if ref, ok := e.Value.(*cddl.Ref); ok && e.Key == nil && t.schema.Rules[ref.Name].Line > 0 {
+		return GoName(ref.Name)
+	}
+	return fallback
+}
+
+// uniqueFields renames fields sharing a name.
+func uniqueFields(fields []codegen.Field) {
+	seen := make(map[string]bool)
+	for i := range fields {
+		if fields[i].Name == "_" {
+			continue
+		}
+
+		name := fields[i].Name
+		for j := 2; seen[name]; j++ {
+			name = fmt.Sprintf("%s%d", fields[i].Name, j)
+		}
+		fields[i].Name = name
+		seen[name] = true
+	}
+}
+
+// field translates a member's value for a field of a struct.
+func (t *translator) field(e *cddl.Entry, structName, name, structPath string) (codegen.Field, error) {
+	path := structPath + "." + name
+	reports := len(t.result.Reports)
+
+	typ, err := t.typeOf(e.Value, structName+name, path,
+		fmt.Sprintf("%s is the type of %s.%s.", structName+name, structName, name))
+	if err != nil {
+		return codegen.Field{}, err
+	}
+
+	f := codegen.Field{Name: name, Type: typ}
+	if typ.Kind == codegen.Raw {
+		f.Comment = t.lastReport(reports, path)
+	}
+	return f, nil
+}
+
+// arrayType translates an array into a positional struct or a slice.
+func (t *translator) arrayType(g *cddl.Group, name, path, doc string) (*codegen.Type, error) {
+	entries, ok := t.flattenChecked(g, 0)
+	if !ok {
+		return t.report(path, "unsupported group choice or nested group occurrence"), nil
+	}
+
+	if len(entries) == 1 && entries[0].Max == cddl.Unbounded {
+		elem, err := t.typeOf(entries[0].Value, name+"Elem", path+"[]",
+			fmt.Sprintf("%s is an element of %s.", name+"Elem", name))
+		if err != nil {
+			return nil, err
+		} else if elem.Kind == codegen.Raw {
+			return t.report(path, "slice of raw data items"), nil
+		}
+		return checked(&codegen.Type{Kind: codegen.Slice, Elem: elem}, minLength(entries[0].Min)), nil
+	}
+
+	// Reserve the struct's position and name before its nested types.
+	index := len(t.result.Structs)
+	t.result.Structs = append(t.result.Structs, codegen.Struct{})
+	if !t.names[name] {
+		name = t.typeName(name)
+	}
+
+	s := codegen.Struct{Name: name, Doc: doc, AsArray: true}
+	optional := false
+	for i, e := range entries {
+		last := i == len(entries)-1
+		switch {
+		case e.Min == 1 && e.Max == 1 && !optional:
+		case e.Min == 0 && e.Max == 1:
+			optional = true
+		case e.Max == cddl.Unbounded && last && !optional:
+		default:
+			return t.dropStruct(index, name, path, "unsupported occurrence of member %d in %s", i, e), nil
+		}
+
+		f, err := t.field(e, name, t.fieldName(e, fmt.Sprintf("Field%d", i)), path)
+		if err != nil {
+			return nil, err
+		}
+		f.Key = int64(i)
+
+		switch value := t.literal(e.Value).(type) {
+		case uint64:
+			f.Const, f.Name = fmt.Sprint(value), "_"
+		case int64:
+			f.Const, f.Name = fmt.Sprint(value), "_"
+		}
+
+		switch {
+		case e.Min == 0 && e.Max == 1:
+			if f.Type.Kind == codegen.Pointer {
+				f.Type = t.report(path+"."+f.Name, "optional member of nullable type")
+				f.Comment = t.lastReport(len(t.result.Reports)-1, path+"."+f.Name)
+			}
+			f.Type = &codegen.Type{Kind: codegen.Pointer, Elem: f.Type}
+			f.Optional, f.Const = true, ""
+
+		case e.Max == cddl.Unbounded:
+			if f.Type.Kind == codegen.Raw {
+				return t.dropStruct(index, name, path, "slice of raw data items"), nil
+			}
+			f.Type = checked(&codegen.Type{Kind: codegen.Slice, Elem: f.Type}, minLength(e.Min))
+			f.Rest, f.Const = true, ""
+		}
+
+		if f.Const != "" {
+			// The constant is checked by itself.
+			f.Type = literalType(t.literal(e.Value))
+			f.Comment = ""
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	uniqueFields(s.Fields)
+
+	t.result.Structs[index] = s
+	return &codegen.Type{Kind: codegen.Marshaler, Name: name}, nil
+}
+
+// minLength returns the check of a slice's minimum length.
+func minLength(n uint64) codegen.Check {
+	if n == 0 {
+		return codegen.Check{}
+	}
+	return codegen.Check{Min: fmt.Sprint(n)}
+}
+
+// dropStruct removes a reserved struct, which cannot be translated, and
+// reports the problem. Its nested types are kept, as they might already be
+// referenced.
+func (t *translator) dropStruct(index int, name, path, format string, args ...any) *codegen.Type {
+	delete(t.names, name)
+	t.result.Structs = append(t.result.Structs[:index], t.result.Structs[index+1:]...)
+	return t.report(path, format, args...)
+}
+
+// mapType translates a map with integer keys into a struct.
+func (t *translator) mapType(g *cddl.Group, name, path, doc string) (*codegen.Type, error) {
+	entries, ok := t.flattenChecked(g, 0)
+	if !ok {
+		return t.report(path, "unsupported group choice or nested group occurrence"), nil
+	}
+
+	index := len(t.result.Structs)
+	t.result.Structs = append(t.result.Structs, codegen.Struct{})
+	if !t.names[name] {
+		name = t.typeName(name)
+	}
+
+	s := codegen.Struct{Name: name, Doc: doc}
+	for _, e := range entries {
+		var key int64
+		switch k := t.literal(e.Key).(type) {
+		case uint64:
+			if k > math.MaxInt64 {
+				return t.dropStruct(index, name, path, "map key %d overflows int64", k), nil
+			}
+			key = int64(k)
+		case int64:
+			key = k
+		default:
+			if e.Max == cddl.Unbounded && e.Min == 0 && s.Unknown == "" && t.isIntegerKey(e.Key) {
+				s.Unknown = "Unknown"
+				continue
+			}
+			return t.dropStruct(index, name, path, "unsupported map member %v", e), nil
+		}
+
+		fieldName := fmt.Sprintf("Key%d", key)
+		if key < 0 {
+			fieldName = fmt.Sprintf("KeyNeg%d", -key)
+		}
+
+		f, err := t.field(e, name, fieldName, path)
+		if err != nil {
+			return nil, err
+		}
+		f.Key = key
+
+		switch {
+		case e.Min == 1 && e.Max == 1:
+		case e.Min == 0 && e.Max == 1:
+			// An omitted member is a nil pointer, which cannot be null as well.
+			f.OmitEmpty = true
+			if f.Type.Kind == codegen.Pointer {
+				f.Type = t.report(path+"."+f.Name, "optional member of nullable type")
+				f.Comment = t.lastReport(len(t.result.Reports)-1, path+"."+f.Name)
+			} else if f.Type.Kind != codegen.Raw {
+				f.Type = &codegen.Type{Kind: codegen.Pointer, Elem: f.Type}
+			}
+		default:
+			return t.dropStruct(index, name, path, "unsupported occurrence of map member %v", e), nil
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	uniqueFields(s.Fields)
+
+	t.result.Structs[index] = s
+	return &codegen.Type{Kind: codegen.Marshaler, Name: name}, nil
+}
+
+// isIntegerKey checks if a member key's type might be any integer.
+func (t *translator) isIntegerKey(key cddl.Type) bool {
+	ref, ok := key.(*cddl.Ref)
+	if !ok || t.schema.Rules[ref.Name].Line > 0 {
+		return false
+	}
+	switch ref.Name {
+	case "int", "uint", "nint", "any":
+		return true
+	default:
+		return false
+	}
+}
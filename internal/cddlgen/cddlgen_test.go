@@ -0,0 +1,180 @@
+package cddlgen
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dtn7/cboring/cddl"
+	"github.com/dtn7/cboring/internal/codegen"
+)
+
+// TestTranslateGolden ensures the generated code of the bundle example is up
+// to date. Run go generate within the example's directory after changes.
+func TestTranslateGolden(t *testing.T) {
+	const dir = "../../examples/bundle/"
+
+	spec, err := os.ReadFile(dir + "bundle.cddl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema, err := cddl.Parse(string(spec) + "\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Translate(schema, nil)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(result.Reports) != 0 {
+		t.Fatalf("Translation reported problems: %v", result.Reports)
+	}
+
+	src, err := codegen.Generate(&codegen.File{
+		Package: "bundle",
+		Structs: result.Structs,
+		Unions:  result.Unions,
+		Declare: true,
+	}, "cboring-cddl")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile(dir + "bundle_cbor.go")
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(src, golden) {
+		t.Fatalf("Generated code differs from %sbundle_cbor.go:\n%s", dir, src)
+	}
+}
+
+// translate translates the named rule of the specification and generates its
+// code, which must not fail.
+func translate(t *testing.T, spec, name string) (*Result, string) {
+	t.Helper()
+
+	result, err := Translate(cddl.MustParse(spec), []string{name})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := codegen.Generate(&codegen.File{
+		Package: "p",
+		Structs: result.Structs,
+		Unions:  result.Unions,
+		Declare: true,
+	}, "test")
+	if err != nil {
+		t.Fatalf("Generating code errored: %v", err)
+	}
+	return result, string(src)
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		spec     string
+		name     string
+		expected []string
+	}{
+		{"a = [* tstr]", "a", nil},
+		{"a = [x: uint, ? y: bstr]", "a", []string{"type A struct", "X uint64", "Y *[]byte `cbor:\"1,optional\"`"}},
+		{"a = [3, * b]\nb = -10..10", "a", []string{"_ uint64 `cbor:\"0,const=3\"`", "B []int8 `cbor:\"1,rest\"`"}},
+		{"a = {1 => tstr, ? -2 => float64, * int => any}", "a", []string{"Key1 string `cbor:\"1\"`", "KeyNeg2 *float64 `cbor:\"-2,omitempty\"`", "Unknown []cboring.RawPair `cbor:\",unknown\"`"}},
+		{"a = [b: tstr / null]", "a", []string{"B *string `cbor:\"0\"`"}},
+		{"a = [v: tstr / uint / [uint]]", "a", []string{"type AV struct", "// Value is one of string, uint64 or *AV2.", "type AV2 struct"}},
+		{"a = uint .size 2", "a", nil},
+		{"a = [1, tstr] / [2, uint]", "a", []string{"type A struct", "// Value is one of *A0 or *A1."}},
+		{"a = [flag]\nflag = 1 / 2 / 4", "a", []string{"Flag uint64 `cbor:\"0\"`", "if !(a.Flag == 1 || a.Flag == 2 || a.Flag == 4)"}},
+		// Constraints are checked
+		{"a = [x: 1..10, y: -3...3]", "a", []string{"X uint8", "if !(a.X >= 1 && a.X <= 10)", "Y int8", "if !(a.Y >= -3 && a.Y <= 2)"}},
+		{"a = [x: bstr .size 2 / bstr .size 4]", "a", []string{"X []byte", "if !(len(a.X) == 2 || len(a.X) == 4)"}},
+		{"a = [x: tstr .size (1..8), + tstr]", "a", []string{"if !(len(a.X) >= 1 && len(a.X) <= 8)", "if !(len(a.Field1) >= 1)"}},
+		{"a = [x: uint .size 3, y: uint .bits f]\nf = &(b0: 0, b4: 4)", "a", []string{"X uint32", "if !(a.X <= 16777215)", "if !(a.Y&^0x11 == 0)"}},
+		{"a = {1 => 7, ? 2 => &(b)}\nb = (c: 1, d: 2)", "a", []string{"if !(a.Key1 == 7)", "if !(*a.Key2 == 1 || *a.Key2 == 2)"}},
+		{"a = [v: 0 / tstr]", "a", []string{"// Value is one of uint64 or string.", "if !(v == 0)"}},
+		{"a = [\"x\", 0.5..1.5]", "a", []string{"if !(a.Field0 == \"x\")", "if !(a.Field1 >= 0.5 && a.Field1 <= 1.5)"}},
+		{"a = [a-b: uint, a-b: uint]", "a", []string{"AB uint64", "AB2 uint64"}},
+	}
+
+	for _, test := range tests {
+		result, src := translate(t, test.spec, test.name)
+		if len(result.Reports) != 0 {
+			t.Fatalf("Translating %q reported problems: %v", test.spec, result.Reports)
+		}
+
+		// Ignore gofmt's alignment of struct fields.
+		compact := strings.Join(strings.Fields(src), " ")
+		for _, expected := range test.expected {
+			if !strings.Contains(compact, expected) {
+				t.Fatalf("Generated code of %q misses %q:\n%s", test.spec, expected, src)
+			}
+		}
+	}
+}
+
+func TestTranslateReports(t *testing.T) {
+	tests := []struct {
+		spec   string
+		report string
+	}{
+		{"a = [t: #6.24(bstr)]", "a.T: "},
+		{"a = [t: {* tstr => uint}]", "a.T: "},
+		{"a = [t: [(x: uint // y: tstr)]]", "a.T: "},
+		{"a = [t: b / null]\nb = uint / null", "a.T: "},
+		{"a = [t: undefined]", "a.T: "},
+		// Constraints which cannot be checked
+		{"a = [t: tstr .regexp \"x\"]", "a.T: "},
+		{"a = [t: bstr .size uint]", "a.T: "},
+		{"a = [t: (0..3) .size 1]", "a.T: "},
+		{"a = [t: 0.5...1.5]", "a.T: "},
+		{"a = {? 1 => uint / null}", "a.Key1: "},
+	}
+
+	for _, test := range tests {
+		result, src := translate(t, test.spec, "a")
+		if len(result.Reports) == 0 || !strings.HasPrefix(result.Reports[0], test.report) {
+			t.Fatalf("Translating %q reported %v, expected %q", test.spec, result.Reports, test.report)
+		} else if !strings.Contains(src, "cboring.ReadRawItem") {
+			t.Fatalf("Generated code of %q keeps no raw data item:\n%s", test.spec, src)
+		}
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	tests := []struct {
+		spec string
+		name string
+	}{
+		{"a = uint", "b"},
+		{"a = (x: uint)", "a"},
+		{"a = b\nb = a / uint", "a"},
+	}
+
+	for _, test := range tests {
+		if _, err := Translate(cddl.MustParse(test.spec), []string{test.name}); err == nil {
+			t.Fatalf("Translating %s of %q did not error", test.name, test.spec)
+		}
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"bundle", "Bundle"},
+		{"primary-block", "PrimaryBlock"},
+		{"crc_type", "CrcType"},
+		{"dtn.eid", "DtnEid"},
+		{"$socket", "Socket"},
+		{"7up", "X7up"},
+	}
+
+	for _, test := range tests {
+		if name := GoName(test.name); name != test.expected {
+			t.Fatalf("GoName(%q) = %q, expected %q", test.name, name, test.expected)
+		}
+	}
+}
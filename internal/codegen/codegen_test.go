@@ -0,0 +1,247 @@
+package codegen
+
+import (
+	"bytes"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestGenerateGolden ensures the generated code of the primaryblock example is
+// up to date. Run go generate within the example's directory after changes.
+func TestGenerateGolden(t *testing.T) {
+	const dir = "../../examples/primaryblock/"
+
+	f, err := ParseFile(dir+"primaryblock.go", nil, []string{"primaryBlock", "creationTimestamp", "extension"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := Generate(f, "cboring-gen")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile(dir + "primaryblock_cbor.go")
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(src, golden) {
+		t.Fatalf("Generated code differs from %sprimaryblock_cbor.go:\n%s", dir, src)
+	}
+}
+
+func TestParseFileSelection(t *testing.T) {
+	src := `package p
+
+type tagged struct {
+	A uint64 ` + "`cbor:\"0\"`" + `
+}
+
+type untagged struct {
+	A uint64
+}
+`
+
+	if f, err := ParseFile("p.go", src, nil); err != nil {
+		t.Fatal(err)
+	} else if len(f.Structs) != 1 || f.Structs[0].Name != "tagged" {
+		t.Fatalf("Selected structs mismatch: %v", f.Structs)
+	}
+
+	if _, err := ParseFile("p.go", src, []string{"missing"}); err == nil {
+		t.Fatal("Missing struct did not error")
+	}
+}
+
+func TestParseFileError(t *testing.T) {
+	tests := []string{
+		// Unknown option
+		"A uint64 `cbor:\"0,foo\"`",
+		// Invalid key
+		"A uint64 `cbor:\"a\"`",
+		// Shared key
+		"A uint64 `cbor:\"0\"`\nB uint64 `cbor:\"0\"`",
+		// Unsupported type
+		"A map[string]int `cbor:\"0\"`",
+		"A [4]byte `cbor:\"0\"`",
+		"A **uint64 `cbor:\"0\"`",
+		// Raw must be a []byte
+		"A string `cbor:\"0,raw\"`",
+		// Array positions must be contiguous
+		"_ struct{} `cbor:\",asarray\"`\nA uint64 `cbor:\"1\"`",
+		// No omitempty within arrays
+		"_ struct{} `cbor:\",asarray\"`\nA uint64 `cbor:\"0,omitempty\"`",
+		// No omitempty for marshalers
+		"A other `cbor:\"0,omitempty\"`",
+		// Unknown field with wrong type
+		"U []byte `cbor:\",unknown\"`",
+		// Unknown pairs within an array
+		"_ struct{} `cbor:\",asarray\"`\nU []cboring.RawPair `cbor:\",unknown\"`",
+		// Optional, rest and const only within arrays
+		"A *uint64 `cbor:\"0,optional\"`",
+		"A []uint64 `cbor:\"0,rest\"`",
+		"_ uint64 `cbor:\"0,const=1\"`",
+		// Optional fields must be pointers at the end
+		"_ struct{} `cbor:\",asarray\"`\nA uint64 `cbor:\"0,optional\"`",
+		"_ struct{} `cbor:\",asarray\"`\nA *uint64 `cbor:\"0,optional\"`\nB uint64 `cbor:\"1\"`",
+		// Rest must be the last slice without optional fields
+		"_ struct{} `cbor:\",asarray\"`\nA []uint64 `cbor:\"0,rest\"`\nB uint64 `cbor:\"1\"`",
+		"_ struct{} `cbor:\",asarray\"`\nA uint64 `cbor:\"0,rest\"`",
+		"_ struct{} `cbor:\",asarray\"`\nA *uint64 `cbor:\"0,optional\"`\nB []uint64 `cbor:\"1,rest\"`",
+		// Constants must be valid integers of blank fields
+		"_ struct{} `cbor:\",asarray\"`\n_ uint8 `cbor:\"0,const=256\"`",
+		"_ struct{} `cbor:\",asarray\"`\n_ int8 `cbor:\"0,const=128\"`",
+		"_ struct{} `cbor:\",asarray\"`\n_ uint64 `cbor:\"0,const=-1\"`",
+		"_ struct{} `cbor:\",asarray\"`\n_ string `cbor:\"0,const=1\"`",
+		"_ struct{} `cbor:\",asarray\"`\nA uint64 `cbor:\"0,const=1\"`",
+	}
+
+	for _, test := range tests {
+		src := "package p\n\ntype s struct {\n" + test + "\n}\n"
+		if _, err := ParseFile("p.go", src, nil); err == nil {
+			t.Fatalf("Illegal struct did not error:\n%s", src)
+		}
+	}
+}
+
+// TestGenerateDeclare ensures declared structs are parsed into the same
+// descriptions again.
+func TestGenerateDeclare(t *testing.T) {
+	structs := []Struct{
+		{Name: "tuple", AsArray: true, Fields: []Field{
+			{Name: "A", Key: 0, Type: &Type{Kind: UInt, Name: "uint64"}},
+			{Name: "B", Key: 1, Type: &Type{Kind: Raw}, Comment: "unknown"},
+		}},
+		{Name: "versioned", AsArray: true, Fields: []Field{
+			{Name: "_", Key: 0, Const: "-7", Type: &Type{Kind: Int, Name: "int8"}},
+			{Name: "E", Key: 1, Optional: true, Type: &Type{Kind: Pointer, Elem: &Type{Kind: Marshaler, Name: "tuple"}}},
+			{Name: "F", Key: 2, Optional: true, Type: &Type{Kind: Pointer, Elem: &Type{Kind: Bytes}}},
+		}},
+		{Name: "list", AsArray: true, Fields: []Field{
+			{Name: "G", Key: 0, Type: &Type{Kind: Bool}},
+			{Name: "H", Key: 1, Rest: true, Type: &Type{Kind: Slice, Elem: &Type{Kind: Float64}}},
+		}},
+		{Name: "record", Unknown: "Unknown", Fields: []Field{
+			{Name: "C", Key: -1, Type: &Type{Kind: Slice, Elem: &Type{Kind: Marshaler, Name: "tuple"}}},
+			{Name: "D", Key: 2, OmitEmpty: true, Type: &Type{Kind: Pointer, Elem: &Type{Kind: String}}},
+		}},
+	}
+
+	src, err := Generate(&File{Package: "p", Structs: structs, Declare: true}, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := ParseFile("p.go", src, nil)
+	if err != nil {
+		t.Fatalf("Parsing generated code errored: %v\n%s", err, src)
+	}
+
+	for i := range f.Structs {
+		for j := range f.Structs[i].Fields {
+			f.Structs[i].Fields[j].Comment = structs[i].Fields[j].Comment
+		}
+	}
+	if !reflect.DeepEqual(f.Structs, structs) {
+		t.Fatalf("Parsed structs mismatch: %v != %v", f.Structs, structs)
+	}
+}
+
+func TestGenerateUnion(t *testing.T) {
+	f := &File{
+		Package: "p",
+		Structs: []Struct{
+			{Name: "tuple", AsArray: true, Fields: []Field{{Name: "A", Key: 0, Type: &Type{Kind: UInt, Name: "uint64"}}}},
+			{Name: "other", AsArray: true, Fields: []Field{{Name: "B", Key: 0, Type: &Type{Kind: String}}}},
+		},
+		Unions: []Union{
+			{Name: "value", Options: []*Type{
+				{Kind: Int, Name: "int32"},
+				{Kind: String},
+				{Kind: Marshaler, Name: "tuple"},
+				{Kind: Marshaler, Name: "other"},
+				{Kind: Marshaler, Name: "nested"},
+			}},
+			{Name: "nested", Options: []*Type{{Kind: Bool}, {Kind: Raw}}},
+		},
+		Declare: true,
+	}
+
+	src, err := Generate(f, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"// Value is one of int32, string, *tuple, *other or *nested.",
+		"case *tuple:",
+		// Both arrays and nested's raw data item share the Array Major Type.
+		// The Choice is only created once.
+		"return valueChoice.Decode(x, r)",
+		"var valueChoice *cboring.Choice[*value]",
+		"Major(cboring.Array, func(x *value, h cboring.Head, r io.Reader) error {\n\t\t\tr = cboring.ReplayHead(h, r)\n\t\t\traw, err := cboring.ReadRawItem(r)",
+		"Major(cboring.TextString, func(x *value, h cboring.Head, r io.Reader) error {\n\t\t\tr = cboring.ReplayHead(h, r)\n\t\t\traw, err := cboring.ReadRawItem(r)",
+		"Major(cboring.NInt, func(x *value, h cboring.Head, r io.Reader) error {\n\t\t\tr = cboring.ReplayHead(h, r)\n\t\t\traw, err",
+		"Major(cboring.SimpleData, func(x *value, h cboring.Head, r io.Reader) error {\n\t\t\tr = cboring.ReplayHead(h, r)\n\t\t\tvar v nested",
+		"Major(cboring.Map, func(x *nested, h cboring.Head, r io.Reader) error {\n\t\t\tr = cboring.ReplayHead(h, r)\n\t\t\tvar v []byte",
+	} {
+		if !bytes.Contains(src, []byte(expected)) {
+			t.Fatalf("Generated code misses %q:\n%s", expected, src)
+		}
+	}
+}
+
+func TestGenerateUnionError(t *testing.T) {
+	tests := [][]*Type{
+		{},
+		{{Kind: String}, {Kind: String}},
+		{{Kind: Pointer, Elem: &Type{Kind: String}}},
+		{{Kind: Marshaler, Name: "missing"}},
+		{{Kind: Marshaler, Name: "u"}},
+	}
+
+	for _, test := range tests {
+		f := &File{Package: "p", Unions: []Union{{Name: "u", Options: test}}}
+		if _, err := Generate(f, "test"); err == nil {
+			t.Fatalf("Illegal union %v did not error", test)
+		}
+	}
+}
+
+// TestGenerateReceiver ensures receivers neither shadow generated variables,
+// e.g., the rest loop's i, nor imported packages or predeclared identifiers.
+func TestGenerateReceiver(t *testing.T) {
+	fields := []Field{
+		{Name: "A", Key: 0, Type: &Type{Kind: UInt, Name: "uint8"}},
+		{Name: "B", Key: 1, Type: &Type{Kind: Bytes}},
+		{Name: "Rest", Key: 2, Rest: true, Type: &Type{Kind: Slice, Elem: &Type{Kind: String}}},
+	}
+
+	f := &File{Package: "p", Declare: true}
+	for _, name := range []string{"Index", "Item", "IO", "FMT", "Math", "LEN", "IF"} {
+		f.Structs = append(f.Structs, Struct{Name: name, AsArray: true, Fields: fields})
+	}
+	f.Structs = append(f.Structs, Struct{Name: "Bytes", Fields: fields[:2]})
+	f.Unions = []Union{{Name: "RAW", Options: []*Type{{Kind: Bool}, {Kind: Raw}}}}
+
+	src, err := Generate(f, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check("p", fset, []*ast.File{file}, nil); err != nil {
+		t.Fatalf("Generated code does not type-check: %v\n%s", err, src)
+	}
+}
@@ -0,0 +1,610 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// generator accumulates the generated code of a file.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]bool
+	// depth counts nested closures to create unique variable names.
+	depth int
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// Generate returns the formatted Go source code of a file with the
+// MarshalCbor and UnmarshalCbor methods of all structs. The command is named
+// in the generated code's header.
+func Generate(f *File, command string) ([]byte, error) {
+	g := &generator{imports: map[string]bool{
+		"io":                      true,
+		"github.com/dtn7/cboring": true,
+	}}
+
+	for i := range f.Structs {
+		s := &f.Structs[i]
+		if err := s.validate(); err != nil {
+			return nil, err
+		}
+
+		if f.Declare {
+			g.declare(s)
+		}
+		g.marshal(s)
+		g.unmarshal(s)
+	}
+
+	for i := range f.Unions {
+		u := &f.Unions[i]
+		dispatch, err := f.dispatch(u)
+		if err != nil {
+			return nil, err
+		}
+
+		g.declareUnion(u)
+		g.marshalUnion(u)
+		g.unmarshalUnion(u, dispatch)
+	}
+
+	var head bytes.Buffer
+	fmt.Fprintf(&head, "// Code generated by %s. DO NOT EDIT.\n\n", command)
+	fmt.Fprintf(&head, "package %s\n\n", f.Package)
+
+	var imports []string
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	head.WriteString("import (\n")
+	for _, imp := range imports {
+		if strings.Contains(imp, ".") {
+			continue
+		}
+		fmt.Fprintf(&head, "%q\n", imp)
+	}
+	head.WriteString("\n")
+	for _, imp := range imports {
+		if strings.Contains(imp, ".") {
+			fmt.Fprintf(&head, "%q\n", imp)
+		}
+	}
+	head.WriteString(")\n")
+
+	head.Write(g.buf.Bytes())
+
+	src, err := format.Source(head.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w\n%s", err, head.Bytes())
+	}
+	return src, nil
+}
+
+// declare generates the struct's declaration with its cbor struct tags.
+func (g *generator) declare(s *Struct) {
+	g.printf("\n")
+	for _, line := range strings.Split(s.Doc, "\n") {
+		if line != "" {
+			g.printf("// %s\n", line)
+		}
+	}
+	g.printf("type %s struct {\n", s.Name)
+
+	if s.AsArray {
+		g.printf("_ struct{} `cbor:\",asarray\"`\n\n")
+	}
+
+	for _, field := range s.Fields {
+		tag := fmt.Sprintf("%d", field.Key)
+		if field.OmitEmpty {
+			tag += ",omitempty"
+		}
+		if field.Type.Kind == Raw {
+			tag += ",raw"
+		}
+		if field.Optional {
+			tag += ",optional"
+		}
+		if field.Rest {
+			tag += ",rest"
+		}
+		if field.Const != "" {
+			tag += ",const=" + field.Const
+		}
+
+		g.printf("%s %s `cbor:\"%s\"`", field.Name, field.Type.GoType(), tag)
+		if field.Comment != "" {
+			g.printf(" // %s", field.Comment)
+		}
+		g.printf("\n")
+	}
+
+	if s.Unknown != "" {
+		g.printf("\n%s []cboring.RawPair `cbor:\",unknown\"`\n", s.Unknown)
+	}
+	g.printf("}\n")
+}
+
+// reserved names are used by the generated code, either as local variables or
+// as imported packages, and must not be shadowed by a receiver.
+var reserved = map[string]bool{
+	"r": true, "w": true, "e": true, "h": true, "i": true, "n": true, "l": true, "b": true, "f": true,
+	"s": true, "v": true, "err": true, "val": true, "raw": true, "fields": true, "seen": true,
+	"bytes": true, "cboring": true, "fmt": true, "io": true, "math": true,
+}
+
+// receiver returns the receiver name for a struct, the lower case initials of
+// its name, e.g., "pb" for "PrimaryBlock". A receiver colliding with a reserved
+// name, a keyword or a predeclared identifier, e.g., "len", becomes "x".
+func receiver(name string) string {
+	var recv []rune
+	for i, c := range name {
+		if i == 0 || unicode.IsUpper(c) {
+			recv = append(recv, unicode.ToLower(c))
+		}
+	}
+
+	r := string(recv)
+	if r == "" || reserved[r] || token.IsKeyword(r) || types.Universe.Lookup(r) != nil {
+		return "x"
+	}
+	return r
+}
+
+func (g *generator) marshal(s *Struct) {
+	recv := receiver(s.Name)
+
+	g.printf("\n// MarshalCbor writes %s's CBOR representation.\n", s.Name)
+	g.printf("func (%s *%s) MarshalCbor(w io.Writer) error {\n", recv, s.Name)
+
+	if s.AsArray {
+		g.marshalArray(s, recv)
+		return
+	}
+
+	g.printf("fields := make([]cboring.IntMapField, 0, %d)\n", len(s.Fields))
+	for _, field := range s.Fields {
+		v := recv + "." + field.Name
+		if field.OmitEmpty {
+			g.printf("if %s {\n", nonEmpty(field.Type, v))
+		}
+		g.printf("fields = append(fields, cboring.IntMapField{Key: %d, Write: func(w io.Writer) error {\n", field.Key)
+		g.write(field.Type, v, s.Name+"."+field.Name)
+		g.printf("return nil\n}})\n")
+		if field.OmitEmpty {
+			g.printf("}\n")
+		}
+	}
+
+	unknown := "nil"
+	if s.Unknown != "" {
+		unknown = recv + "." + s.Unknown
+	}
+	g.printf("\nreturn cboring.WriteIntMap(fields, %s, w)\n}\n", unknown)
+}
+
+func (g *generator) unmarshal(s *Struct) {
+	recv := receiver(s.Name)
+
+	g.printf("\n// UnmarshalCbor reads %s's CBOR representation.\n", s.Name)
+
+	if s.AsArray {
+		g.unmarshalArray(s, recv)
+		return
+	}
+
+	// Keys are tracked to detect missing required fields and to reset omitted
+	// fields, which might hold a value from a previous call.
+	g.printf("func (%s *%s) UnmarshalCbor(r io.Reader) (err error) {\n", recv, s.Name)
+	if len(s.Fields) > 0 {
+		g.printf("var seen [%d]bool\n", len(s.Fields))
+	}
+	if s.Unknown != "" {
+		g.printf("%s.%s, err = cboring.NewIntMap().\n", recv, s.Unknown)
+	} else {
+		g.printf("_, err = cboring.NewIntMap().\n")
+	}
+	for i, field := range s.Fields {
+		v, name := recv+"."+field.Name, s.Name+"."+field.Name
+		g.printf("Field(%d, func(r io.Reader) error {\nseen[%d] = true\n", field.Key, i)
+		if field.OmitEmpty && field.Type.Kind == Pointer {
+			// An omitted pointer is nil, as null is never written.
+			g.printf("%s = new(%s)\n", v, field.Type.Elem.GoType())
+			g.read(field.Type.Elem, deref(field.Type.Elem, v), name, "return ")
+		} else {
+			g.read(field.Type, v, name, "return ")
+		}
+		g.printf("return nil\n}).\n")
+	}
+	g.printf("Decode(r)\nif err != nil {\nreturn\n}\n\n")
+
+	for i, field := range s.Fields {
+		g.printf("if !seen[%d] {\n", i)
+		if field.OmitEmpty {
+			g.printf("%s.%s = %s\n}\n", recv, field.Name, zero(field.Type))
+		} else {
+			g.imports["fmt"] = true
+			g.printf("return fmt.Errorf(\"%s: Missing required key %d\")\n}\n", s.Name, field.Key)
+		}
+	}
+	g.printf("return nil\n}\n")
+}
+
+// arrayLength returns the amount of an array's required elements and if it has
+// a variable length due to optional or rest fields.
+func arrayLength(s *Struct) (required int, variable bool) {
+	for _, field := range s.Fields {
+		if field.Optional || field.Rest {
+			variable = true
+		} else {
+			required++
+		}
+	}
+	return
+}
+
+func (g *generator) marshalArray(s *Struct, recv string) {
+	required, variable := arrayLength(s)
+
+	switch {
+	case !variable:
+		g.printf("if err := cboring.WriteArrayLength(%d, w); err != nil {\nreturn err\n}\n\n", required)
+
+	case s.Fields[len(s.Fields)-1].Rest:
+		rest := recv + "." + s.Fields[len(s.Fields)-1].Name
+		g.printf("if err := cboring.WriteArrayLength(uint64(%d+len(%s)), w); err != nil {\nreturn err\n}\n\n",
+			required, rest)
+
+	default:
+		// The length covers the last present optional element.
+		g.printf("l := %d\n", required)
+		for i := len(s.Fields) - 1; i >= required; i-- {
+			if i < len(s.Fields)-1 {
+				g.printf(" else ")
+			}
+			g.printf("if %s.%s != nil {\nl = %d\n}", recv, s.Fields[i].Name, i+1)
+		}
+		g.printf("\nif err := cboring.WriteArrayLength(uint64(l), w); err != nil {\nreturn err\n}\n\n")
+	}
+
+	for i, field := range s.Fields {
+		v := recv + "." + field.Name
+		switch {
+		case field.Const != "":
+			fn := "WriteUInt"
+			if field.Type.Kind == Int {
+				fn = "WriteInt"
+			}
+			g.printf("if err := cboring.%s(%s, w); err != nil {\nreturn err\n}\n", fn, field.Const)
+
+		case field.Optional:
+			g.imports["fmt"] = true
+			g.printf("if l > %d {\n", i)
+			g.printf("if %s == nil {\nreturn fmt.Errorf(\"%s.%s: Missing value before a later optional element\")\n}\n",
+				v, s.Name, field.Name)
+			g.write(field.Type.Elem, deref(field.Type.Elem, v), s.Name+"."+field.Name)
+			g.printf("}\n")
+
+		case field.Rest:
+			g.check(field.Type, v, s.Name+"."+field.Name, "return ")
+			e := fmt.Sprintf("e%d", g.depth)
+			g.printf("for _, %s := range %s {\n", e, v)
+			g.depth++
+			g.write(field.Type.Elem, e, s.Name+"."+field.Name)
+			g.depth--
+			g.printf("}\n")
+
+		default:
+			g.write(field.Type, v, s.Name+"."+field.Name)
+		}
+	}
+	g.printf("return nil\n}\n")
+}
+
+func (g *generator) unmarshalArray(s *Struct, recv string) {
+	g.imports["fmt"] = true
+	required, variable := arrayLength(s)
+
+	g.printf("func (%s *%s) UnmarshalCbor(r io.Reader) error {\n", recv, s.Name)
+
+	switch {
+	case !variable:
+		g.printf("if l, err := cboring.ReadArrayLength(r); err != nil {\nreturn err\n}")
+		g.printf(" else if l != %d {\n", required)
+		g.printf("return fmt.Errorf(\"%s: Expected array of length %d, got %%d\", l)\n}\n\n",
+			s.Name, required)
+
+	case s.Fields[len(s.Fields)-1].Rest:
+		g.printf("l, err := cboring.ReadArrayLength(r)\nif err != nil {\nreturn err\n}")
+		g.printf(" else if l < %d || l > cboring.MaxContainerLength {\n", required)
+		g.printf("return fmt.Errorf(\"%s: Expected array of at least length %d, got %%d\", l)\n}\n\n",
+			s.Name, required)
+
+	default:
+		g.printf("l, err := cboring.ReadArrayLength(r)\nif err != nil {\nreturn err\n}")
+		g.printf(" else if l < %d || l > %d {\n", required, len(s.Fields))
+		g.printf("return fmt.Errorf(\"%s: Expected array of length %d to %d, got %%d\", l)\n}\n\n",
+			s.Name, required, len(s.Fields))
+	}
+
+	for i, field := range s.Fields {
+		v := recv + "." + field.Name
+		name := s.Name + "." + field.Name
+		switch {
+		case field.Const != "":
+			fn := "ReadUInt"
+			if field.Type.Kind == Int {
+				fn = "ReadInt"
+			}
+			g.printf("if val, err := cboring.%s(r); err != nil {\nreturn err\n}", fn)
+			g.printf(" else if val != %s {\n", field.Const)
+			g.printf("return fmt.Errorf(\"%s: Expected %s at position %d, got %%d\", val)\n}\n",
+				s.Name, field.Const, i)
+
+		case field.Optional:
+			g.printf("if l > %d {\n%s = new(%s)\n", i, v, field.Type.Elem.GoType())
+			g.read(field.Type.Elem, deref(field.Type.Elem, v), name, "return ")
+			g.printf("} else {\n%s = nil\n}\n", v)
+
+		case field.Rest:
+			e := fmt.Sprintf("e%d", g.depth)
+			g.printf("%s = nil\nfor i := uint64(%d); i < l; i++ {\nvar %s %s\n", v, i, e, field.Type.Elem.GoType())
+			g.depth++
+			g.read(field.Type.Elem, e, name, "return ")
+			g.depth--
+			g.printf("%s = append(%s, %s)\n}\n", v, v, e)
+			g.check(field.Type, v, name, "return ")
+
+		default:
+			g.read(field.Type, v, name, "return ")
+		}
+	}
+	g.printf("return nil\n}\n")
+}
+
+// deref returns the expression to access the value of the pointer v to a value
+// of type t. Methods of a Marshaler are called on the pointer itself.
+func deref(t *Type, v string) string {
+	if t.Kind == Marshaler {
+		return v
+	}
+	return "*" + v
+}
+
+// nonEmpty returns a boolean expression, true if v is not empty.
+func nonEmpty(t *Type, v string) string {
+	switch t.Kind {
+	case Bool:
+		return v
+	case String:
+		return v + ` != ""`
+	case Bytes, Raw, Slice:
+		return "len(" + v + ") > 0"
+	case Pointer:
+		return v + " != nil"
+	default:
+		return v + " != 0"
+	}
+}
+
+// zero returns the zero value of type t.
+func zero(t *Type) string {
+	switch t.Kind {
+	case Bool:
+		return "false"
+	case String:
+		return `""`
+	case Bytes, Raw, Slice, Pointer:
+		return "nil"
+	case Marshaler:
+		return "*new(" + t.Name + ")"
+	default:
+		return "0"
+	}
+}
+
+// write generates the statements writing the value v of type t into w. The
+// name is used within error messages.
+func (g *generator) write(t *Type, v, name string) {
+	g.check(t, v, name, "return ")
+
+	check := func(call string) {
+		g.printf("if err := %s; err != nil {\nreturn err\n}\n", call)
+	}
+
+	switch t.Kind {
+	case UInt:
+		if t.Name != "uint64" {
+			v = "uint64(" + v + ")"
+		}
+		check("cboring.WriteUInt(" + v + ", w)")
+	case Int:
+		if t.Name != "int64" {
+			v = "int64(" + v + ")"
+		}
+		check("cboring.WriteInt(" + v + ", w)")
+	case Bool:
+		check("cboring.WriteBoolean(" + v + ", w)")
+	case String:
+		check("cboring.WriteTextString(" + v + ", w)")
+	case Bytes:
+		check("cboring.WriteByteString(" + v + ", w)")
+	case Float32:
+		check("cboring.WriteFloat32(" + v + ", w)")
+	case Float64:
+		check("cboring.WriteFloat64(" + v + ", w)")
+	case Raw:
+		g.printf("if len(%s) == 0 {\n", v)
+		check("cboring.WriteNull(w)")
+		g.printf("} else if _, err := w.Write(%s); err != nil {\nreturn err\n}\n", v)
+	case Marshaler:
+		check(v + ".MarshalCbor(w)")
+	case Slice, Pointer:
+		e := fmt.Sprintf("e%d", g.depth)
+		fn := "WriteArrayOf"
+		if t.Kind == Pointer {
+			fn = "WriteOptional"
+		}
+
+		g.printf("if err := cboring.%s(%s, func(%s %s, w io.Writer) error {\n", fn, v, e, t.Elem.GoType())
+		g.depth++
+		g.write(t.Elem, e, name)
+		g.depth--
+		g.printf("return nil\n}, w); err != nil {\nreturn err\n}\n")
+	}
+}
+
+// read generates the statements reading a value of type t from r into the
+// addressable v. The name is used within error messages. Each error is
+// returned by prefixing it with ret, e.g., "return ".
+func (g *generator) read(t *Type, v, name, ret string) {
+	assign := func(call string) {
+		g.printf("if val, err := %s; err != nil {\n%serr\n} else {\n%s = val\n}\n", call, ret, v)
+	}
+
+	switch t.Kind {
+	case UInt:
+		g.readInteger(t, v, name, ret, "cboring.ReadUInt(r)", "", "math.Max"+capitalize(t.Name))
+	case Int:
+		bound := capitalize(t.Name)
+		g.readInteger(t, v, name, ret, "cboring.ReadInt(r)", "math.Min"+bound, "math.Max"+bound)
+	case Bool:
+		assign("cboring.ReadBoolean(r)")
+	case String:
+		assign("cboring.ReadTextString(r)")
+	case Bytes:
+		assign("cboring.ReadByteString(r)")
+	case Float32:
+		assign("cboring.ReadFloat32(r)")
+	case Float64:
+		assign("cboring.ReadFloat64(r)")
+	case Raw:
+		assign("cboring.ReadRawItem(r)")
+	case Marshaler:
+		g.printf("if err := %s.UnmarshalCbor(r); err != nil {\n%serr\n}\n", v, ret)
+	case Slice, Pointer:
+		e := fmt.Sprintf("e%d", g.depth)
+		elemType := t.Elem.GoType()
+
+		closure := func() {
+			g.printf("func(r io.Reader) (%s %s, err error) {\n", e, elemType)
+			g.depth++
+			g.read(t.Elem, e, name, "return "+e+", ")
+			g.depth--
+			g.printf("return %s, nil\n}, r)", e)
+		}
+
+		if t.Kind == Slice {
+			g.printf("if val, err := cboring.ReadArrayOf(")
+			closure()
+			g.printf("; err != nil {\n%serr\n} else {\n%s = val\n}\n", ret, v)
+		} else {
+			g.printf("if val, present, err := cboring.ReadOptional(")
+			closure()
+			g.printf("; err != nil {\n%serr\n} else if present {\n%s = &val\n} else {\n%s = nil\n}\n", ret, v, v)
+		}
+	}
+
+	g.check(t, v, name, ret)
+}
+
+// check generates the statement checking the value v of type t against its
+// Checks, if there are any.
+func (g *generator) check(t *Type, v, name, ret string) {
+	if len(t.Checks) == 0 {
+		return
+	}
+
+	n := v
+	if t.Kind == String || t.Kind == Bytes || t.Kind == Slice {
+		n = "len(" + v + ")"
+	}
+
+	var alternatives []string
+	for _, c := range t.Checks {
+		var conds []string
+		if len(c.Values) > 0 {
+			var values []string
+			for _, value := range c.Values {
+				values = append(values, v+" == "+value)
+			}
+			conds = append(conds, strings.Join(values, " || "))
+		}
+		if c.Min != "" && c.Min == c.Max {
+			conds = append(conds, n+" == "+c.Min)
+		} else {
+			if c.Min != "" {
+				conds = append(conds, n+" >= "+c.Min)
+			}
+			if c.Max != "" {
+				conds = append(conds, n+" <= "+c.Max)
+			}
+		}
+		if c.Mask != "" {
+			conds = append(conds, v+"&^"+c.Mask+" == 0")
+		}
+
+		if len(conds) == 0 {
+			// An empty Check allows any value.
+			return
+		} else if len(conds) == 1 {
+			alternatives = append(alternatives, conds[0])
+			continue
+		}
+		for i := range conds {
+			if strings.Contains(conds[i], "||") {
+				conds[i] = "(" + conds[i] + ")"
+			}
+		}
+		if len(t.Checks) > 1 {
+			alternatives = append(alternatives, "("+strings.Join(conds, " && ")+")")
+		} else {
+			alternatives = append(alternatives, strings.Join(conds, " && "))
+		}
+	}
+
+	g.imports["fmt"] = true
+	g.printf("if !(%s) {\n%sfmt.Errorf(\"%s: Value %%v is not allowed\", %s)\n}\n",
+		strings.Join(alternatives, " || "), ret, name, v)
+}
+
+// readInteger generates the statements reading an integer of type t and
+// checking its bounds, unless it is a 64-bit integer.
+func (g *generator) readInteger(t *Type, v, name, ret, call, min, max string) {
+	g.printf("if val, err := %s; err != nil {\n%serr\n}", call, ret)
+
+	if t.Name != "uint64" && t.Name != "int64" {
+		g.imports["fmt"] = true
+		g.imports["math"] = true
+
+		cond := "val > " + max
+		if min != "" {
+			cond = "val < " + min + " || " + cond
+		}
+		g.printf(" else if %s {\n%sfmt.Errorf(\"%s: Value %%d overflows %s\", val)\n}",
+			cond, ret, name, t.Name)
+		g.printf(" else {\n%s = %s(val)\n}\n", v, t.Name)
+	} else {
+		g.printf(" else {\n%s = val\n}\n", v)
+	}
+}
+
+// capitalize returns an integer type's name as used for the math package's
+// bounds, e.g., "Uint16" for "uint16".
+func capitalize(name string) string {
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,141 @@
+// Package codegen generates reflection-free MarshalCbor and UnmarshalCbor
+// methods based on cboring's primitives for a description of Go structs.
+//
+// The descriptions are either parsed from annotated Go source code, as done by
+// the cboring-gen command, or created by other tools, e.g., for type inference.
+package codegen
+
+import "fmt"
+
+// Kind of a field's type.
+type Kind int
+
+const (
+	// UInt is an unsigned integer type, e.g., uint8 or uint64.
+	UInt Kind = iota
+	// Int is a signed integer type, e.g., int or int32.
+	Int
+	// Bool is a bool.
+	Bool
+	// String is a string, encoded as a text string.
+	String
+	// Bytes is a []byte, encoded as a byte string.
+	Bytes
+	// Float32 is a float32, always encoded as a single-precision float.
+	Float32
+	// Float64 is a float64, always encoded as a double-precision float.
+	Float64
+	// Raw is a []byte holding an encoded CBOR data item, which is written and
+	// read as it is.
+	Raw
+	// Marshaler is a named type implementing cboring.CborMarshaler by pointer.
+	Marshaler
+	// Slice is a slice of Elem, encoded as an array.
+	Slice
+	// Pointer is a pointer to Elem for an optional value, encoded as null if
+	// nil.
+	Pointer
+)
+
+// Type of a field.
+type Type struct {
+	Kind Kind
+	// Name is the Go type name, e.g., "uint16" or "EndpointID". It is only
+	// used for UInt, Int and Marshaler.
+	Name string
+	// Elem is the element type for Slice and Pointer.
+	Elem *Type
+	// Checks constrain the values of a UInt, Int, Bool, String, Bytes, Float32,
+	// Float64 or Slice. A value must pass at least one of them, if there are
+	// any, both while writing and reading.
+	Checks []Check
+}
+
+// Check constrains a value, e.g., as specified by a schema. Its fields are Go
+// expressions and all of the set ones must hold.
+type Check struct {
+	// Values are the allowed values, e.g., of an enumeration. They cannot be
+	// used for Bytes.
+	Values []string
+	// Min and Max are the inclusive bounds of a number, or of the length of a
+	// String, Bytes or Slice.
+	Min, Max string
+	// Mask are the bits an unsigned integer might have set.
+	Mask string
+}
+
+// GoType returns the type's Go representation.
+func (t *Type) GoType() string {
+	switch t.Kind {
+	case UInt, Int, Marshaler:
+		return t.Name
+	case Bool:
+		return "bool"
+	case String:
+		return "string"
+	case Bytes, Raw:
+		return "[]byte"
+	case Float32:
+		return "float32"
+	case Float64:
+		return "float64"
+	case Slice:
+		return "[]" + t.Elem.GoType()
+	case Pointer:
+		return "*" + t.Elem.GoType()
+	default:
+		return fmt.Sprintf("invalid kind %d", t.Kind)
+	}
+}
+
+// Field of a struct.
+type Field struct {
+	// Name is the Go field name.
+	Name string
+	// Key is the position within an array or the integer key within a map.
+	Key int64
+	// OmitEmpty omits a field with an empty value within a map. While reading,
+	// a missing field is reset, and a Pointer must not be null. Other fields
+	// of a map are required.
+	OmitEmpty bool
+	// Optional marks a trailing array element, which might be missing. Its
+	// type must be a Pointer, being nil for a missing element.
+	Optional bool
+	// Rest marks the last field of an array as a Slice of all remaining
+	// elements.
+	Rest bool
+	// Const is the decimal value of an integer array element without a Go
+	// field, e.g., a version number. It is written as it is and checked while
+	// reading. Such a field's Name is "_".
+	Const string
+	Type  *Type
+	// Comment is written next to the field's declaration, if declared.
+	Comment string
+}
+
+// Struct describes a Go struct to generate methods for.
+type Struct struct {
+	Name string
+	// Doc is the doc comment of the struct's declaration, if declared.
+	Doc string
+	// AsArray encodes the struct as an array of its fields, ordered by their
+	// keys, instead of as a map with integer keys.
+	AsArray bool
+	Fields  []Field
+	// Unknown is the name of a []cboring.RawPair field for a map, which
+	// preserves pairs of unknown keys. It might be empty.
+	Unknown string
+}
+
+// Union describes a sum type, encoded as one of its alternatives. It is
+// declared as a struct with a single field Value, holding a value of one of
+// the Options' Go types. Values of a Marshaler type are held by pointer.
+//
+// While reading, the alternative is chosen by the data item's Major Type. If
+// multiple alternatives share a Major Type, they are tried in order.
+type Union struct {
+	Name string
+	// Doc is the doc comment of the union's declaration.
+	Doc     string
+	Options []*Type
+}
@@ -0,0 +1,328 @@
+package codegen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// File is the result of parsing a Go source file or, for declared structs, a
+// description of a Go source file to generate.
+type File struct {
+	Package string
+	Structs []Struct
+	// Unions are always declared, as they cannot be parsed.
+	Unions []Union
+	// Declare also generates the declarations of the structs, e.g., for
+	// inferred types. Parsed structs are already declared.
+	Declare bool
+}
+
+// ParseFile parses the Go source file, read from src if not nil, and returns
+// the description of the structs to generate methods for. If names is empty,
+// each struct with at least one cbor struct tag is selected. Otherwise, only
+// the named structs are selected, and each of them must exist.
+func ParseFile(filename string, src any, names []string) (*File, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	result := &File{Package: f.Name.Name}
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || gd.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gd.Specs {
+			ts := spec.(*ast.TypeSpec)
+			st, ok := ts.Type.(*ast.StructType)
+			if !ok || (len(names) > 0 && !wanted[ts.Name.Name]) {
+				continue
+			} else if len(names) == 0 && !hasCborTags(st) {
+				continue
+			}
+
+			s, err := parseStruct(ts.Name.Name, st)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", fset.Position(ts.Pos()), err)
+			}
+
+			result.Structs = append(result.Structs, *s)
+			delete(wanted, ts.Name.Name)
+		}
+	}
+
+	if len(wanted) > 0 {
+		var missing []string
+		for name := range wanted {
+			missing = append(missing, name)
+		}
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%s: structs not found: %s", filename, strings.Join(missing, ", "))
+	}
+
+	return result, nil
+}
+
+// cborTag returns the cbor struct tag of a field.
+func cborTag(field *ast.Field) (tag string, ok bool) {
+	if field.Tag == nil {
+		return
+	}
+
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return
+	}
+	return reflect.StructTag(raw).Lookup("cbor")
+}
+
+func hasCborTags(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if _, ok := cborTag(field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStruct creates the description of a struct based on its fields' tags.
+//
+// A field's tag is `cbor:"KEY[,OPTION...]"`, with KEY being the array position
+// or integer map key and the options omitempty, raw, optional, rest or
+// const=N. The tag `cbor:"-"` ignores a field. A blank field of type struct{}
+// tagged with `cbor:",asarray"` encodes the struct as an array. A field of type
+// []cboring.RawPair tagged with `cbor:",unknown"` preserves unknown map pairs.
+func parseStruct(name string, st *ast.StructType) (*Struct, error) {
+	s := &Struct{Name: name}
+	keys := make(map[int64]string)
+
+	for _, field := range st.Fields.List {
+		tag, ok := cborTag(field)
+		if !ok || tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		options := make(map[string]bool)
+		var constant string
+		for _, option := range parts[1:] {
+			switch {
+			case option == "asarray", option == "unknown", option == "omitempty", option == "raw",
+				option == "optional", option == "rest":
+				options[option] = true
+			case strings.HasPrefix(option, "const="):
+				constant = strings.TrimPrefix(option, "const=")
+			default:
+				return nil, fmt.Errorf("struct %s: unknown option %q", name, option)
+			}
+		}
+
+		if options["asarray"] {
+			s.AsArray = true
+			continue
+		}
+
+		if len(field.Names) != 1 {
+			return nil, fmt.Errorf("struct %s: tagged fields must be named individually", name)
+		}
+		fieldName := field.Names[0].Name
+
+		if options["unknown"] {
+			if typ := exprString(field.Type); typ != "[]cboring.RawPair" {
+				return nil, fmt.Errorf("struct %s: unknown field %s has type %s instead of []cboring.RawPair",
+					name, fieldName, typ)
+			}
+			s.Unknown = fieldName
+			continue
+		}
+
+		key, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("struct %s: field %s has an invalid key %q", name, fieldName, parts[0])
+		} else if other, exists := keys[key]; exists {
+			return nil, fmt.Errorf("struct %s: fields %s and %s share key %d", name, other, fieldName, key)
+		}
+		keys[key] = fieldName
+
+		typ, err := parseType(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("struct %s: field %s: %w", name, fieldName, err)
+		}
+
+		if options["raw"] {
+			if typ.Kind != Bytes {
+				return nil, fmt.Errorf("struct %s: raw field %s must be a []byte", name, fieldName)
+			}
+			typ.Kind = Raw
+		}
+
+		s.Fields = append(s.Fields, Field{
+			Name:      fieldName,
+			Key:       key,
+			OmitEmpty: options["omitempty"],
+			Optional:  options["optional"],
+			Rest:      options["rest"],
+			Const:     constant,
+			Type:      typ,
+		})
+	}
+
+	return s, s.validate()
+}
+
+// validate checks the struct's description for consistency.
+func (s *Struct) validate() error {
+	sort.SliceStable(s.Fields, func(i, j int) bool { return s.Fields[i].Key < s.Fields[j].Key })
+
+	optional := false
+	for i, field := range s.Fields {
+		if !s.AsArray && (field.Optional || field.Rest || field.Const != "") {
+			return fmt.Errorf("struct %s: optional, rest and const are only supported for arrays, field %s",
+				s.Name, field.Name)
+		}
+
+		if s.AsArray {
+			if field.Key != int64(i) {
+				return fmt.Errorf("struct %s: array positions must be 0 to %d, field %s has %d",
+					s.Name, len(s.Fields)-1, field.Name, field.Key)
+			} else if field.OmitEmpty {
+				return fmt.Errorf("struct %s: omitempty is not supported for arrays, field %s",
+					s.Name, field.Name)
+			}
+		} else if field.OmitEmpty && field.Type.Kind == Marshaler {
+			return fmt.Errorf("struct %s: omitempty is not supported for %s, use a pointer for field %s",
+				s.Name, field.Type.Name, field.Name)
+		}
+
+		switch {
+		case field.Optional && field.Type.Kind != Pointer:
+			return fmt.Errorf("struct %s: optional field %s must be a pointer", s.Name, field.Name)
+		case optional && !field.Optional:
+			return fmt.Errorf("struct %s: field %s must be optional, as it follows an optional field",
+				s.Name, field.Name)
+		case field.Rest && (field.Type.Kind != Slice || i != len(s.Fields)-1 || optional):
+			return fmt.Errorf("struct %s: rest field %s must be a slice following all other fields",
+				s.Name, field.Name)
+		case field.Const != "" && !validConst(field):
+			return fmt.Errorf("struct %s: constant %q of field %s is not a valid %s",
+				s.Name, field.Const, field.Name, field.Type.GoType())
+		}
+		optional = optional || field.Optional
+	}
+
+	if s.AsArray && s.Unknown != "" {
+		return fmt.Errorf("struct %s: unknown pairs are only supported for maps", s.Name)
+	}
+	return nil
+}
+
+// validConst checks if a constant field's value fits its integer type.
+func validConst(field Field) bool {
+	bits := 64
+	if n := strings.TrimPrefix(strings.TrimPrefix(field.Type.Name, "u"), "int"); n != "" {
+		bits, _ = strconv.Atoi(n)
+	}
+
+	var err error
+	switch field.Type.Kind {
+	case UInt:
+		_, err = strconv.ParseUint(field.Const, 10, bits)
+	case Int:
+		_, err = strconv.ParseInt(field.Const, 10, bits)
+	default:
+		return false
+	}
+	return err == nil && field.Name == "_"
+}
+
+// parseType creates the description of a field's type.
+func parseType(expr ast.Expr) (*Type, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "uint", "uint8", "uint16", "uint32", "uint64":
+			return &Type{Kind: UInt, Name: e.Name}, nil
+		case "int", "int8", "int16", "int32", "int64":
+			return &Type{Kind: Int, Name: e.Name}, nil
+		case "bool":
+			return &Type{Kind: Bool}, nil
+		case "string":
+			return &Type{Kind: String}, nil
+		case "float32":
+			return &Type{Kind: Float32}, nil
+		case "float64":
+			return &Type{Kind: Float64}, nil
+		case "byte", "rune", "uintptr", "complex64", "complex128", "any", "error":
+			return nil, fmt.Errorf("unsupported type %s", e.Name)
+		default:
+			return &Type{Kind: Marshaler, Name: e.Name}, nil
+		}
+
+	case *ast.SelectorExpr:
+		return &Type{Kind: Marshaler, Name: exprString(e)}, nil
+
+	case *ast.ArrayType:
+		if e.Len != nil {
+			return nil, fmt.Errorf("unsupported array type %s, use a slice", exprString(e))
+		}
+
+		if ident, ok := e.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return &Type{Kind: Bytes}, nil
+		}
+
+		elem, err := parseType(e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return &Type{Kind: Slice, Elem: elem}, nil
+
+	case *ast.StarExpr:
+		elem, err := parseType(e.X)
+		if err != nil {
+			return nil, err
+		} else if elem.Kind == Pointer {
+			return nil, fmt.Errorf("unsupported pointer to pointer %s", exprString(e))
+		}
+		return &Type{Kind: Pointer, Elem: elem}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported type %s", exprString(expr))
+	}
+}
+
+// exprString returns a type expression's source representation.
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	case *ast.ArrayType:
+		if e.Len != nil {
+			return "[" + exprString(e.Len) + "]" + exprString(e.Elt)
+		}
+		return "[]" + exprString(e.Elt)
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.BasicLit:
+		return e.Value
+	case *ast.MapType:
+		return "map[" + exprString(e.Key) + "]" + exprString(e.Value)
+	default:
+		return fmt.Sprintf("%T", expr)
+	}
+}
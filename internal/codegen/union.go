@@ -0,0 +1,203 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// majorTypes of cboring, ordered by their value, as named in generated code.
+var majorTypes = []string{"UInt", "NInt", "ByteString", "TextString", "Array", "Map", "Tag", "SimpleData"}
+
+// alternatives are a union's options sharing a Major Type.
+type alternatives struct {
+	major   string
+	options []*Type
+}
+
+// valueType returns the Go type of an option's value within a union.
+func valueType(t *Type) string {
+	if t.Kind == Marshaler {
+		return "*" + t.Name
+	}
+	return t.GoType()
+}
+
+// dispatch checks the union's description and groups its options by the
+// Major Types they might be encoded as.
+func (f *File) dispatch(u *Union) ([]alternatives, error) {
+	if len(u.Options) == 0 {
+		return nil, fmt.Errorf("union %s: no options", u.Name)
+	}
+
+	byMajor := make(map[string][]*Type)
+	goTypes := make(map[string]bool)
+	for _, option := range u.Options {
+		typ := valueType(option)
+		if goTypes[typ] {
+			return nil, fmt.Errorf("union %s: multiple options of type %s", u.Name, typ)
+		}
+		goTypes[typ] = true
+
+		majors, err := f.majors(option, map[string]bool{u.Name: true})
+		if err != nil {
+			return nil, fmt.Errorf("union %s: %w", u.Name, err)
+		}
+		seen := make(map[string]bool)
+		for _, major := range majors {
+			if !seen[major] {
+				seen[major] = true
+				byMajor[major] = append(byMajor[major], option)
+			}
+		}
+	}
+
+	var result []alternatives
+	for _, major := range majorTypes {
+		if options, ok := byMajor[major]; ok {
+			result = append(result, alternatives{major: major, options: options})
+		}
+	}
+	return result, nil
+}
+
+// majors returns the Major Types a value of type t might be encoded as. The
+// visited unions are tracked to detect cycles.
+func (f *File) majors(t *Type, visited map[string]bool) ([]string, error) {
+	switch t.Kind {
+	case UInt:
+		return []string{"UInt"}, nil
+	case Int:
+		return []string{"UInt", "NInt"}, nil
+	case Bool, Float32, Float64:
+		return []string{"SimpleData"}, nil
+	case String:
+		return []string{"TextString"}, nil
+	case Bytes:
+		return []string{"ByteString"}, nil
+	case Slice:
+		return []string{"Array"}, nil
+	case Raw:
+		return majorTypes, nil
+	case Pointer:
+		return nil, fmt.Errorf("unsupported pointer option %s", t.GoType())
+	}
+
+	for _, s := range f.Structs {
+		if s.Name != t.Name {
+			continue
+		} else if s.AsArray {
+			return []string{"Array"}, nil
+		}
+		return []string{"Map"}, nil
+	}
+
+	for _, u := range f.Unions {
+		if u.Name != t.Name {
+			continue
+		} else if visited[u.Name] {
+			return nil, fmt.Errorf("union %s contains itself", u.Name)
+		}
+		visited[u.Name] = true
+		defer delete(visited, u.Name)
+
+		var result []string
+		for _, option := range u.Options {
+			majors, err := f.majors(option, visited)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, majors...)
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("cannot determine the Major Type of %s", t.Name)
+}
+
+// declareUnion generates the union's declaration.
+func (g *generator) declareUnion(u *Union) {
+	var types []string
+	for _, option := range u.Options {
+		types = append(types, valueType(option))
+	}
+
+	g.printf("\n")
+	for _, line := range strings.Split(u.Doc, "\n") {
+		if line != "" {
+			g.printf("// %s\n", line)
+		}
+	}
+	g.printf("type %s struct {\n", u.Name)
+	if len(types) == 1 {
+		g.printf("// Value is a %s.\n", types[0])
+	} else {
+		g.printf("// Value is one of %s or %s.\n", strings.Join(types[:len(types)-1], ", "), types[len(types)-1])
+	}
+	g.printf("Value any\n}\n")
+}
+
+func (g *generator) marshalUnion(u *Union) {
+	g.imports["fmt"] = true
+	recv := receiver(u.Name)
+
+	g.printf("\n// MarshalCbor writes %s's CBOR representation.\n", u.Name)
+	g.printf("func (%s *%s) MarshalCbor(w io.Writer) error {\n", recv, u.Name)
+	g.printf("switch v := %s.Value.(type) {\n", recv)
+	for _, option := range u.Options {
+		g.printf("case %s:\n", valueType(option))
+		g.write(option, "v", u.Name)
+		g.printf("return nil\n")
+	}
+	g.printf("default:\nreturn fmt.Errorf(\"%s: Invalid value of type %%T\", v)\n}\n}\n", u.Name)
+}
+
+func (g *generator) unmarshalUnion(u *Union, dispatch []alternatives) {
+	recv := receiver(u.Name)
+	choice := strings.ToLower(u.Name[:1]) + u.Name[1:] + "Choice"
+
+	// readOption generates the statements reading an option's value into v.
+	readOption := func(option *Type, ret string) string {
+		g.printf("var v %s\n", option.GoType())
+		g.read(option, "v", u.Name, ret)
+		if option.Kind == Marshaler {
+			return "&v"
+		}
+		return "v"
+	}
+
+	g.printf("\n// UnmarshalCbor reads %s's CBOR representation.\n", u.Name)
+	g.printf("func (%s *%s) UnmarshalCbor(r io.Reader) error {\nreturn %s.Decode(%s, r)\n}\n", recv, u.Name, choice, recv)
+
+	// The Choice is only created once. An init function allows the options to
+	// refer to the union again, without an initialization cycle.
+	g.printf("\n// %s decodes %s's options.\nvar %s *cboring.Choice[*%s]\n", choice, u.Name, choice, u.Name)
+	g.printf("\nfunc init() {\n%s = cboring.NewChoice[*%s]().\n", choice, u.Name)
+
+	for i, alt := range dispatch {
+		if i > 0 {
+			g.printf(".\n")
+		}
+		g.printf("Major(cboring.%s, func(%s *%s, h cboring.Head, r io.Reader) error {\n", alt.major, recv, u.Name)
+		g.printf("r = cboring.ReplayHead(h, r)\n")
+
+		if len(alt.options) == 1 {
+			v := readOption(alt.options[0], "return ")
+			g.printf("%s.Value = %s\nreturn nil\n})", recv, v)
+			continue
+		}
+
+		g.imports["bytes"] = true
+		g.imports["fmt"] = true
+		g.printf("raw, err := cboring.ReadRawItem(r)\nif err != nil {\nreturn err\n}\n\n")
+		g.printf("for _, f := range []func(r io.Reader) (any, error){\n")
+		for _, option := range alt.options {
+			g.printf("func(r io.Reader) (any, error) {\n")
+			v := readOption(option, "return nil, ")
+			g.printf("return %s, nil\n},\n", v)
+		}
+		g.printf("} {\n")
+		g.printf("if v, err := f(bytes.NewReader(raw)); err == nil {\n%s.Value = v\nreturn nil\n}\n}\n", recv)
+		g.printf("return fmt.Errorf(\"%s: No option matches the data item %%x\", raw)\n})", u.Name)
+	}
+	g.printf("\n}\n")
+}
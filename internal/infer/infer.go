@@ -0,0 +1,271 @@
+// Package infer derives Go struct definitions from sample CBOR data items, to
+// be generated together with their CborMarshaler methods by the codegen
+// package.
+package infer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dtn7/cboring"
+	"github.com/dtn7/cboring/internal/codegen"
+)
+
+// MaxTupleLength is the maximum length of an array to be inferred as a
+// positional struct. Longer arrays are inferred as slices.
+const MaxTupleLength = 16
+
+// Result of an inference.
+type Result struct {
+	// Structs are the inferred structs, starting with the root struct.
+	Structs []codegen.Struct
+	// Reports describe each field whose type could not be inferred. Those
+	// fields are kept as raw CBOR data items.
+	Reports []string
+}
+
+// inferrer holds the state of an inference.
+type inferrer struct {
+	result Result
+}
+
+// Infer derives a struct named name from the samples, which must be either
+// arrays of a fixed shape or maps with integer keys.
+//
+// Arrays of the same length within all samples are inferred as positional
+// structs, unless being longer than MaxTupleLength, and as slices otherwise.
+// Maps with integer keys are inferred as structs, with keys missing in some
+// samples being omitempty and unknown keys being preserved. Integers, strings,
+// booleans and floating-point values are inferred as such, and null or
+// undefined results in a pointer. Everything else, e.g., maps with text keys,
+// tags, conflicting samples or keys being both missing and null, is kept as a
+// raw data item and reported.
+func Infer(name string, samples []*cboring.Item) (*Result, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("Infer: No samples")
+	}
+
+	in := &inferrer{}
+	if t := in.typeOf(name, name, samples); t.Kind != codegen.Marshaler {
+		return nil, fmt.Errorf("Infer: The samples are neither arrays of a fixed shape nor maps with integer keys")
+	}
+	return &in.result, nil
+}
+
+// report records a problem for a field, identified by its path.
+func (in *inferrer) report(path, format string, args ...any) *codegen.Type {
+	in.result.Reports = append(in.result.Reports, path+": "+fmt.Sprintf(format, args...))
+	return &codegen.Type{Kind: codegen.Raw}
+}
+
+// class names the kind of a sample as used within reports.
+func class(item *cboring.Item) string {
+	switch v := item.Value.(type) {
+	case bool:
+		return "boolean"
+	case float32:
+		return "float32"
+	case float64:
+		return "float64"
+	case nil:
+		if item.Major() == cboring.SimpleData {
+			return "null"
+		}
+	case cboring.UndefinedValue:
+		return "null"
+	case cboring.SimpleValue:
+		return fmt.Sprintf("simple(%d)", v)
+	}
+
+	switch item.Major() {
+	case cboring.UInt:
+		return "unsigned integer"
+	case cboring.NInt:
+		return "negative integer"
+	case cboring.ByteString:
+		return "byte string"
+	case cboring.TextString:
+		return "text string"
+	case cboring.Array:
+		return "array"
+	case cboring.Map:
+		return "map"
+	case cboring.Tag:
+		return fmt.Sprintf("tag %d", item.Value)
+	default:
+		return "unknown"
+	}
+}
+
+// typeOf infers the type of all samples at the same position. The name is
+// used for nested structs and the path for reports.
+func (in *inferrer) typeOf(name, path string, samples []*cboring.Item) *codegen.Type {
+	var nonNull []*cboring.Item
+	classes := make(map[string]bool)
+	for _, sample := range samples {
+		if c := class(sample); c != "null" {
+			nonNull = append(nonNull, sample)
+			classes[c] = true
+		}
+	}
+	nullable := len(nonNull) < len(samples)
+
+	if len(nonNull) == 0 {
+		return in.report(path, "only null or no samples")
+	}
+
+	if classes["negative integer"] {
+		delete(classes, "unsigned integer")
+	}
+	if len(classes) > 1 {
+		var names []string
+		for c := range classes {
+			names = append(names, c)
+		}
+		sort.Strings(names)
+		return in.report(path, "conflicting samples: %s", strings.Join(names, ", "))
+	}
+
+	var t *codegen.Type
+	switch c := class(nonNull[0]); {
+	case classes["negative integer"]:
+		t = &codegen.Type{Kind: codegen.Int, Name: "int64"}
+	case c == "unsigned integer":
+		t = &codegen.Type{Kind: codegen.UInt, Name: "uint64"}
+	case c == "byte string":
+		t = &codegen.Type{Kind: codegen.Bytes}
+	case c == "text string":
+		t = &codegen.Type{Kind: codegen.String}
+	case c == "boolean":
+		t = &codegen.Type{Kind: codegen.Bool}
+	case c == "float32":
+		t = &codegen.Type{Kind: codegen.Float32}
+	case c == "float64":
+		t = &codegen.Type{Kind: codegen.Float64}
+	case c == "array":
+		t = in.arrayType(name, path, nonNull)
+	case c == "map":
+		t = in.mapType(name, path, nonNull)
+	default:
+		return in.report(path, "unsupported %s", c)
+	}
+
+	if nullable && t.Kind != codegen.Raw {
+		t = &codegen.Type{Kind: codegen.Pointer, Elem: t}
+	}
+	return t
+}
+
+// arrayType infers either a positional struct or a slice.
+func (in *inferrer) arrayType(name, path string, samples []*cboring.Item) *codegen.Type {
+	length := len(samples[0].Items)
+	fixed := length > 0 && length <= MaxTupleLength
+	for _, sample := range samples {
+		fixed = fixed && len(sample.Items) == length
+	}
+
+	if !fixed {
+		var elems []*cboring.Item
+		for _, sample := range samples {
+			elems = append(elems, sample.Items...)
+		}
+
+		elem := in.typeOf(name+"Elem", path+"[]", elems)
+		if elem.Kind == codegen.Raw {
+			return in.report(path, "slice of raw data items")
+		}
+		return &codegen.Type{Kind: codegen.Slice, Elem: elem}
+	}
+
+	// Reserve the struct's position before its nested structs.
+	index := len(in.result.Structs)
+	in.result.Structs = append(in.result.Structs, codegen.Struct{})
+
+	s := codegen.Struct{
+		Name:    name,
+		Doc:     fmt.Sprintf("%s is inferred from arrays of length %d.", name, length),
+		AsArray: true,
+	}
+	for i := 0; i < length; i++ {
+		var column []*cboring.Item
+		for _, sample := range samples {
+			column = append(column, sample.Items[i])
+		}
+
+		s.Fields = append(s.Fields, in.field(name, fmt.Sprintf("Field%d", i), path, int64(i), column, false))
+	}
+
+	in.result.Structs[index] = s
+	return &codegen.Type{Kind: codegen.Marshaler, Name: name}
+}
+
+// mapType infers a struct for maps with integer keys.
+func (in *inferrer) mapType(name, path string, samples []*cboring.Item) *codegen.Type {
+	values := make(map[int64][]*cboring.Item)
+	for _, sample := range samples {
+		for i := 0; i+1 < len(sample.Items); i += 2 {
+			var key int64
+			switch k := sample.Items[i].Value.(type) {
+			case uint64:
+				if int64(k) < 0 {
+					return in.report(path, "map key %d overflows int64", k)
+				}
+				key = int64(k)
+			case int64:
+				key = k
+			default:
+				return in.report(path, "map with %s keys", class(sample.Items[i]))
+			}
+			values[key] = append(values[key], sample.Items[i+1])
+		}
+	}
+
+	var keys []int64
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	index := len(in.result.Structs)
+	in.result.Structs = append(in.result.Structs, codegen.Struct{})
+
+	s := codegen.Struct{
+		Name:    name,
+		Doc:     fmt.Sprintf("%s is inferred from maps with integer keys.", name),
+		Unknown: "Unknown",
+	}
+	for _, key := range keys {
+		fieldName := fmt.Sprintf("Key%d", key)
+		if key < 0 {
+			fieldName = fmt.Sprintf("KeyNeg%d", -key)
+		}
+
+		optional := len(values[key]) < len(samples)
+		s.Fields = append(s.Fields, in.field(name, fieldName, path, key, values[key], optional))
+	}
+
+	in.result.Structs[index] = s
+	return &codegen.Type{Kind: codegen.Marshaler, Name: name}
+}
+
+// field infers a struct's field. An optional field is omitted if empty.
+func (in *inferrer) field(structName, name, structPath string, key int64, samples []*cboring.Item, optional bool) codegen.Field {
+	path := structPath + "." + name
+	reports := len(in.result.Reports)
+	t := in.typeOf(structName+name, path, samples)
+
+	if optional && t.Kind == codegen.Pointer {
+		// An omitempty Pointer must not be null, as it is nil if missing.
+		t = in.report(path, "missing in some samples and null in others")
+	}
+
+	f := codegen.Field{Name: name, Key: key, OmitEmpty: optional, Type: t}
+	if optional && t.Kind == codegen.Marshaler {
+		f.Type = &codegen.Type{Kind: codegen.Pointer, Elem: t}
+	}
+	if t.Kind == codegen.Raw && len(in.result.Reports) > reports {
+		f.Comment = strings.TrimPrefix(in.result.Reports[len(in.result.Reports)-1], path+": ")
+	}
+	return f
+}
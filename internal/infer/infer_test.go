@@ -0,0 +1,211 @@
+package infer
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dtn7/cboring"
+	"github.com/dtn7/cboring/internal/codegen"
+)
+
+func readSamples(t *testing.T, data []byte) (samples []*cboring.Item) {
+	r := bytes.NewReader(data)
+	for {
+		item, err := cboring.ReadItem(r)
+		if err == io.EOF {
+			return
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		samples = append(samples, item)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	samples := readSamples(t, []byte{
+		// [7, "a", [1, 2], {1: h'00', -2: [3]}, null, 1(0)]
+		0x86, 0x07, 0x61, 0x61, 0x82, 0x01, 0x02, 0xA2, 0x01, 0x41, 0x00, 0x21, 0x81, 0x03, 0xF6, 0xC1, 0x00,
+		// [-1, "b", [3, 4], {1: h'01', 5: true}, 2, 1(1)]
+		0x86, 0x20, 0x61, 0x62, 0x82, 0x03, 0x04, 0xA2, 0x01, 0x41, 0x01, 0x05, 0xF5, 0x02, 0xC1, 0x01,
+	})
+
+	result, err := Infer("Sample", samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, s := range result.Structs {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "Sample,SampleField2,SampleField3,SampleField3KeyNeg2" {
+		t.Fatalf("Structs mismatch: %s", got)
+	}
+
+	root := result.Structs[0]
+	expected := []string{"int64", "string", "SampleField2", "SampleField3", "*uint64", "[]byte"}
+	if !root.AsArray || len(root.Fields) != len(expected) {
+		t.Fatalf("Root struct mismatches: %v", root)
+	}
+	for i, f := range root.Fields {
+		if f.Type.GoType() != expected[i] {
+			t.Fatalf("Field %d has type %s instead of %s", i, f.Type.GoType(), expected[i])
+		}
+	}
+	if root.Fields[5].Type.Kind != codegen.Raw || len(result.Reports) != 1 {
+		t.Fatalf("Tag was not reported: %v", result.Reports)
+	}
+
+	m := result.Structs[2]
+	if m.AsArray || m.Unknown == "" || len(m.Fields) != 3 {
+		t.Fatalf("Map struct mismatches: %v", m)
+	}
+	for _, f := range m.Fields {
+		if optional := f.Key != 1; f.OmitEmpty != optional {
+			t.Fatalf("Field %s has omitempty %t", f.Name, f.OmitEmpty)
+		}
+	}
+	// [3] is of a fixed shape, but optional.
+	if m.Fields[0].Name != "KeyNeg2" || m.Fields[0].Type.GoType() != "*SampleField3KeyNeg2" {
+		t.Fatalf("Field KeyNeg2 mismatches: %v", m.Fields[0])
+	}
+
+	// The inferred structs must result in valid code.
+	if _, err := codegen.Generate(&codegen.File{Package: "p", Structs: result.Structs, Declare: true}, "test"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// roundTripMain decodes each sample from the hex encoded argument by the
+// generated Sample type, encoding and decoding it again.
+const roundTripMain = `package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+func main() {
+	data, _ := hex.DecodeString(os.Args[1])
+	for r := bytes.NewReader(data); r.Len() > 0; {
+		var v, again Sample
+		if err := v.UnmarshalCbor(r); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		var buff bytes.Buffer
+		if err := v.MarshalCbor(&buff); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		} else if err := again.UnmarshalCbor(&buff); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+}
+`
+
+func TestInferRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Building the generated code is skipped in short mode")
+	}
+
+	tests := [][]byte{
+		// {1: 5}, {1: null}, {}
+		{0xA1, 0x01, 0x05, 0xA1, 0x01, 0xF6, 0xA0},
+		// {1: [1, "a"]}, {2: null}, {1: null, 2: -1}
+		{0xA1, 0x01, 0x82, 0x01, 0x61, 0x61, 0xA1, 0x02, 0xF6, 0xA2, 0x01, 0xF6, 0x02, 0x20},
+		// [1, null], [2, 3]
+		{0x82, 0x01, 0xF6, 0x82, 0x02, 0x03},
+	}
+
+	for _, test := range tests {
+		result, err := Infer("Sample", readSamples(t, test))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		src, err := codegen.Generate(&codegen.File{Package: "main", Structs: result.Structs, Declare: true}, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The package must be within this module to import cboring.
+		dir, err := os.MkdirTemp(".", "_roundtrip")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		if err := os.WriteFile(filepath.Join(dir, "sample.go"), src, 0o644); err != nil {
+			t.Fatal(err)
+		} else if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte(roundTripMain), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := exec.Command("go", "run", "./"+dir, hex.EncodeToString(test)).CombinedOutput()
+		if err != nil {
+			t.Fatalf("Samples %x do not round trip: %v\n%s\n%s", test, err, out, src)
+		}
+	}
+}
+
+func TestInferSlice(t *testing.T) {
+	// [[1], [2, 3]], inferred as a struct with a slice, as the lengths differ
+	samples := readSamples(t, []byte{0x81, 0x81, 0x01, 0x81, 0x82, 0x02, 0x03})
+
+	result, err := Infer("Sample", samples)
+	if err != nil {
+		t.Fatal(err)
+	} else if typ := result.Structs[0].Fields[0].Type.GoType(); typ != "[]uint64" {
+		t.Fatalf("Slice was inferred as %s", typ)
+	}
+}
+
+func TestInferReports(t *testing.T) {
+	tests := [][]byte{
+		// Conflicting samples
+		{0x81, 0x01, 0x81, 0x61, 0x61},
+		// Only null
+		{0x81, 0xF6},
+		// Text keys
+		{0x81, 0xA1, 0x61, 0x61, 0x01},
+		// Slice of conflicting elements
+		{0x81, 0x98, 0x11, 0x01, 0x61, 0x61, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+			0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01},
+	}
+
+	for _, test := range tests {
+		if result, err := Infer("Sample", readSamples(t, test)); err != nil {
+			t.Fatal(err)
+		} else if len(result.Reports) == 0 {
+			t.Fatalf("Samples %x were not reported", test)
+		} else if f := result.Structs[0].Fields[0]; f.Type.Kind != codegen.Raw || f.Comment == "" {
+			t.Fatalf("Reported field mismatches: %v", f)
+		}
+	}
+}
+
+func TestInferError(t *testing.T) {
+	tests := [][]byte{
+		{},
+		{0x01},
+		{0x80},
+		{0xF6},
+	}
+
+	for _, test := range tests {
+		if _, err := Infer("Sample", readSamples(t, test)); err == nil {
+			t.Fatalf("Samples %x did not error", test)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/dtn7/cboring"
+)
+
+// Problem is a finding of Check at an offset of the checked data.
+type Problem struct {
+	Offset  int64
+	Message string
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("offset %d: %s", p.Offset, p.Message)
+}
+
+// Check returns the problems of a well-formed data item which prevent it from
+// being valid or deterministically encoded, following RFC 8949, sections 5.3
+// and 4.2.1. The data must be the item's encoding, as its Offsets refer to it.
+//
+// Text strings must be valid UTF-8 and map keys must be encoded uniquely. For a
+// deterministic encoding, heads must be minimal, lengths must be definite,
+// floating-point values must be in their shortest form and map keys must be
+// sorted by their encodings' bytewise lexicographic order.
+func Check(item *cboring.Item, data []byte) (problems []Problem) {
+	c := checker{data: data}
+	c.check(item)
+
+	sort.SliceStable(c.problems, func(i, j int) bool {
+		return c.problems[i].Offset < c.problems[j].Offset
+	})
+	return c.problems
+}
+
+type checker struct {
+	data     []byte
+	problems []Problem
+}
+
+func (c *checker) report(offset int64, format string, args ...any) {
+	c.problems = append(c.problems, Problem{Offset: offset, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *checker) check(item *cboring.Item) {
+	h := item.Head
+
+	switch {
+	case h.Indefinite:
+		c.report(item.Offset, "Indefinite-length %v", h)
+	case h.Major == cboring.SimpleData && h.Info == 24 && h.Argument < 32:
+		c.report(item.Offset, "Simple value %d in two bytes is not well-formed", h.Argument)
+	case !h.IsMinimal():
+		c.report(item.Offset, "Head %v is not minimal, %d instead of %d bytes", h, h.Width, cboring.HeadSize(h.Argument))
+	case h.Major == cboring.SimpleData && !isShortestFloat(h):
+		c.report(item.Offset, "Floating-point value %v is not in its shortest form", item.Value)
+	}
+
+	if s, ok := item.Value.(string); ok && !utf8.ValidString(s) {
+		c.report(item.Offset, "Text string is not valid UTF-8")
+	}
+
+	for _, child := range item.Items {
+		c.check(child)
+	}
+
+	if h.Major == cboring.Map {
+		c.checkKeys(item)
+	}
+}
+
+// checkKeys checks the order and uniqueness of a map's keys.
+func (c *checker) checkKeys(item *cboring.Item) {
+	var prev []byte
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(item.Items); i += 2 {
+		// A key's encoding ends where its value starts.
+		key := c.data[item.Items[i].Offset:item.Items[i+1].Offset]
+
+		if seen[string(key)] {
+			c.report(item.Items[i].Offset, "Duplicate map key %x", key)
+		} else if prev != nil && bytes.Compare(prev, key) > 0 {
+			c.report(item.Items[i].Offset, "Map key %x is not sorted after %x", key, prev)
+		}
+
+		seen[string(key)] = true
+		prev = key
+	}
+}
+
+// isShortestFloat checks if a floating-point value's head could not be encoded
+// shorter without losing its value. Other heads are always reported as
+// shortest. NaN's shortest form is half-precision.
+func isShortestFloat(h cboring.Head) bool {
+	var f float32
+	switch h.Info {
+	case 26:
+		f = math.Float32frombits(uint32(h.Argument))
+	case 27:
+		f64 := math.Float64frombits(h.Argument)
+		if math.IsNaN(f64) {
+			return false
+		} else if float64(float32(f64)) != f64 {
+			return true
+		}
+		f = float32(f64)
+	default:
+		return true
+	}
+
+	if f != f {
+		return false
+	}
+	_, fits := cboring.Float32ToHalf(f)
+	return !fits
+}
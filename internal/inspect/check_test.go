@@ -0,0 +1,64 @@
+package inspect
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dtn7/cboring"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected []int64
+	}{
+		// Deterministically encoded data items
+		{[]byte{0x01}, nil},
+		{[]byte{0x83, 0x01, 0x61, 0x61, 0xF9, 0x3E, 0x00}, nil},
+		{[]byte{0xA3, 0x01, 0x00, 0x20, 0x00, 0x61, 0x61, 0x00}, nil},
+		{[]byte{0xA2, 0x18, 0x18, 0x00, 0x20, 0x00}, nil},
+		{[]byte{0xFA, 0x47, 0xC3, 0x50, 0x00}, nil},
+		{[]byte{0xFB, 0x3F, 0xF1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9A}, nil},
+		// Non-minimal heads
+		{[]byte{0x82, 0x18, 0x01, 0xD8, 0x01, 0x00}, []int64{1, 3}},
+		// Indefinite lengths
+		{[]byte{0x9F, 0x5F, 0x41, 0x00, 0xFF, 0xFF}, []int64{0, 1}},
+		// Floating-point values not in their shortest form
+		{[]byte{0x82, 0xFA, 0x3F, 0xC0, 0x00, 0x00, 0xFB, 0x7F, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, []int64{1, 6}},
+		{[]byte{0x82, 0xFA, 0x00, 0x00, 0x00, 0x00, 0xFA, 0x00, 0x00, 0x00, 0x01}, []int64{1}},
+		// Unsorted and duplicate keys
+		{[]byte{0xA3, 0x02, 0x00, 0x01, 0x00, 0x01, 0x00}, []int64{3, 5}},
+		{[]byte{0xA2, 0x61, 0x61, 0x00, 0x18, 0x18, 0x00}, []int64{4}},
+		// Invalid UTF-8
+		{[]byte{0x81, 0x61, 0xFF}, []int64{1}},
+	}
+
+	for _, test := range tests {
+		var offsets []int64
+		for _, p := range Check(readItem(t, test.data), test.data) {
+			offsets = append(offsets, p.Offset)
+		}
+		if !reflect.DeepEqual(offsets, test.expected) {
+			t.Fatalf("Problems of %x at offsets %v, expected %v", test.data, offsets, test.expected)
+		}
+	}
+}
+
+func TestCheckTwoByteSimpleValue(t *testing.T) {
+	// ReadItem rejects this data item, but it might be built otherwise.
+	item := &cboring.Item{
+		Head:  cboring.Head{Major: cboring.SimpleData, Info: 24, Argument: 16, Width: 2},
+		Value: cboring.SimpleValue(16),
+	}
+
+	if problems := Check(item, []byte{0xF8, 0x10}); len(problems) != 1 {
+		t.Fatalf("Problems of f810 are %v, expected one", problems)
+	} else if !strings.Contains(problems[0].Message, "not well-formed") {
+		t.Fatalf("Problem of f810 is %v", problems[0])
+	}
+
+	if diag := Diagnostic(item); diag != "simple(16)_0" {
+		t.Fatalf("Diagnostic of f810 is %s", diag)
+	}
+}
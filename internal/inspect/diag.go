@@ -0,0 +1,167 @@
+// Package inspect implements the human-readable representations and checks of
+// the cboring command on top of the library's decoder.
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dtn7/cboring"
+)
+
+// Diagnostic returns the data item in the diagnostic notation of RFC 8949,
+// section 8, e.g., `[1, "foo", {_ h'00': 2(3)}]`.
+//
+// Heads not encoded in their shortest form are marked by an encoding
+// indicator, e.g., "1_0" for the unsigned integer one within two bytes, as are
+// floating-point values which could be encoded shorter. The chunks of an
+// indefinite-length string are shown as one, e.g., `(_ "foobar")`.
+func Diagnostic(item *cboring.Item) string {
+	var b strings.Builder
+	writeDiagnostic(&b, item)
+	return b.String()
+}
+
+// indicator returns the encoding indicator of a non-minimal head, or an empty
+// string.
+func indicator(h cboring.Head) string {
+	if h.IsMinimal() {
+		return ""
+	}
+	return indicatorOf(h.Width)
+}
+
+// indicatorOf returns the encoding indicator of a head's width.
+func indicatorOf(width int) string {
+	switch width {
+	case 2:
+		return "_0"
+	case 3:
+		return "_1"
+	case 5:
+		return "_2"
+	default:
+		return "_3"
+	}
+}
+
+func writeDiagnostic(b *strings.Builder, item *cboring.Item) {
+	h := item.Head
+
+	switch h.Major {
+	case cboring.UInt:
+		fmt.Fprintf(b, "%d%s", h.Argument, indicator(h))
+
+	case cboring.NInt:
+		n := new(big.Int).SetUint64(h.Argument)
+		fmt.Fprintf(b, "%v%s", n.Neg(n.Add(n, big.NewInt(1))), indicator(h))
+
+	case cboring.ByteString:
+		if h.Indefinite {
+			fmt.Fprintf(b, "(_ h'%x')", item.Value)
+		} else {
+			fmt.Fprintf(b, "h'%x'%s", item.Value, indicator(h))
+		}
+
+	case cboring.TextString:
+		if h.Indefinite {
+			fmt.Fprintf(b, "(_ %s)", quote(item.Value.(string)))
+		} else {
+			fmt.Fprintf(b, "%s%s", quote(item.Value.(string)), indicator(h))
+		}
+
+	case cboring.Array, cboring.Map:
+		open, close := "[", "]"
+		if h.Major == cboring.Map {
+			open, close = "{", "}"
+		}
+
+		b.WriteString(open)
+		if h.Indefinite {
+			b.WriteString("_ ")
+		} else if ind := indicator(h); ind != "" {
+			b.WriteString(ind + " ")
+		}
+		for i, child := range item.Items {
+			switch {
+			case i == 0:
+			case h.Major == cboring.Map && i%2 == 1:
+				b.WriteString(": ")
+			default:
+				b.WriteString(", ")
+			}
+			writeDiagnostic(b, child)
+		}
+		b.WriteString(close)
+
+	case cboring.Tag:
+		fmt.Fprintf(b, "%d%s(", h.Argument, indicator(h))
+		writeDiagnostic(b, item.Items[0])
+		b.WriteString(")")
+
+	default:
+		b.WriteString(simpleDiagnostic(item))
+	}
+}
+
+// simpleDiagnostic returns the diagnostic notation of a simple value or a
+// floating-point value.
+func simpleDiagnostic(item *cboring.Item) string {
+	switch v := item.Value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return "null"
+	case cboring.UndefinedValue:
+		return "undefined"
+	case cboring.SimpleValue:
+		return fmt.Sprintf("simple(%d)%s", v, indicator(item.Head))
+	case float32:
+		s := formatFloat(float64(v), 32)
+		if !isShortestFloat(item.Head) {
+			s += indicatorOf(item.Head.Width)
+		}
+		return s
+	case float64:
+		s := formatFloat(v, 64)
+		if !isShortestFloat(item.Head) {
+			s += indicatorOf(item.Head.Width)
+		}
+		return s
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// formatFloat formats a floating-point value, which always contains a decimal
+// point or an exponent to be distinguishable from an integer.
+func formatFloat(f float64, bits int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+
+	s := strconv.FormatFloat(f, 'g', -1, bits)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// quote returns a text string as a JSON string.
+func quote(s string) string {
+	var buff bytes.Buffer
+	enc := json.NewEncoder(&buff)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buff.String(), "\n")
+}
@@ -0,0 +1,65 @@
+package inspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dtn7/cboring"
+)
+
+// readItem reads a single data item, which must be well-formed.
+func readItem(t *testing.T, data []byte) *cboring.Item {
+	t.Helper()
+
+	item, err := cboring.ReadItem(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Reading %x errored: %v", data, err)
+	}
+	return item
+}
+
+func TestDiagnostic(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		expected string
+	}{
+		{[]byte{0x00}, "0"},
+		{[]byte{0x18, 0x18}, "24"},
+		{[]byte{0x18, 0x01}, "1_0"},
+		{[]byte{0x1B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "18446744073709551615"},
+		{[]byte{0x20}, "-1"},
+		{[]byte{0x3B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, "-18446744073709551616"},
+		{[]byte{0x42, 0x01, 0x02}, "h'0102'"},
+		{[]byte{0x63, 0x66, 0x6F, 0x6F}, `"foo"`},
+		{[]byte{0x62, 0x22, 0x3C}, `"\"<"`},
+		{[]byte{0x5F, 0x41, 0x01, 0x41, 0x02, 0xFF}, "(_ h'0102')"},
+		{[]byte{0x7F, 0x61, 0x61, 0xFF}, `(_ "a")`},
+		{[]byte{0x80}, "[]"},
+		{[]byte{0x83, 0x01, 0x82, 0x02, 0x03, 0x04}, "[1, [2, 3], 4]"},
+		{[]byte{0x98, 0x01, 0x01}, "[_0 1]"},
+		{[]byte{0x9F, 0x01, 0x02, 0xFF}, "[_ 1, 2]"},
+		{[]byte{0xA2, 0x01, 0x61, 0x61, 0x20, 0xF6}, `{1: "a", -1: null}`},
+		{[]byte{0xBF, 0x01, 0x02, 0xFF}, "{_ 1: 2}"},
+		{[]byte{0xC1, 0x1A, 0x5E, 0x0B, 0xE1, 0x00}, "1(1577836800)"},
+		{[]byte{0xF4}, "false"},
+		{[]byte{0xF5}, "true"},
+		{[]byte{0xF7}, "undefined"},
+		{[]byte{0xF0}, "simple(16)"},
+		{[]byte{0xF8, 0xFF}, "simple(255)"},
+		{[]byte{0xF9, 0x3C, 0x00}, "1.0"},
+		{[]byte{0xF9, 0x7C, 0x00}, "Infinity"},
+		{[]byte{0xF9, 0xFC, 0x00}, "-Infinity"},
+		{[]byte{0xF9, 0x7E, 0x00}, "NaN"},
+		{[]byte{0xFA, 0x47, 0xC3, 0x50, 0x00}, "100000.0"},
+		{[]byte{0xFA, 0x3F, 0xC0, 0x00, 0x00}, "1.5_2"},
+		{[]byte{0xFB, 0x3F, 0xF1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9A}, "1.1"},
+		{[]byte{0xFB, 0x7F, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, "NaN_3"},
+		{[]byte{0xFB, 0x7E, 0x37, 0xE4, 0x3C, 0x88, 0x00, 0x75, 0x9C}, "1e+300"},
+	}
+
+	for _, test := range tests {
+		if diag := Diagnostic(readItem(t, test.data)); diag != test.expected {
+			t.Fatalf("Diagnostic of %x is %s, expected %s", test.data, diag, test.expected)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"unicode"
+)
+
+// Formats of the input and output data.
+const (
+	Binary = "binary"
+	Hex    = "hex"
+	Base64 = "base64"
+)
+
+// Decode converts data of a format into binary.
+//
+// Hexadecimal data might contain whitespace and comments from a "#" to the end
+// of a line, as written by cboring.Dump. Base64 data might use the standard or
+// the URL alphabet, with or without padding.
+func Decode(data []byte, format string) ([]byte, error) {
+	switch format {
+	case Binary:
+		return data, nil
+
+	case Hex:
+		var digits []byte
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			if i := bytes.IndexByte(line, '#'); i >= 0 {
+				line = line[:i]
+			}
+			digits = append(digits, bytes.Join(bytes.Fields(line), nil)...)
+		}
+
+		out := make([]byte, hex.DecodedLen(len(digits)))
+		if _, err := hex.Decode(out, digits); err != nil {
+			return nil, fmt.Errorf("Decode: %w", err)
+		}
+		return out, nil
+
+	case Base64:
+		text := string(bytes.Map(func(r rune) rune {
+			if unicode.IsSpace(r) {
+				return -1
+			}
+			return r
+		}, data))
+
+		for _, enc := range []*base64.Encoding{
+			base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding,
+		} {
+			if out, err := enc.DecodeString(text); err == nil {
+				return out, nil
+			}
+		}
+		return nil, fmt.Errorf("Decode: Illegal base64 data")
+
+	default:
+		return nil, fmt.Errorf("Decode: Unknown format %s", format)
+	}
+}
+
+// Encode converts binary data into a format. Textual formats are terminated by
+// a newline.
+func Encode(data []byte, format string) ([]byte, error) {
+	switch format {
+	case Binary:
+		return data, nil
+	case Hex:
+		return []byte(hex.EncodeToString(data) + "\n"), nil
+	case Base64:
+		return []byte(base64.StdEncoding.EncodeToString(data) + "\n"), nil
+	default:
+		return nil, fmt.Errorf("Encode: Unknown format %s", format)
+	}
+}
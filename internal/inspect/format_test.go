@@ -0,0 +1,71 @@
+package inspect
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	expected := []byte{0x82, 0x01, 0xFB, 0xFF}
+
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{"\x82\x01\xfb\xff", Binary},
+		{"8201fbff", Hex},
+		{"82 01\n FB FF\n", Hex},
+		{"82  # array(2)\n  01  # unsigned(1)\nfbff # comment\n", Hex},
+		{"ggH7/w==", Base64},
+		{"ggH7/w", Base64},
+		{"ggH7_w", Base64},
+		{"ggH7\n/w==\n", Base64},
+	}
+
+	for _, test := range tests {
+		if data, err := Decode([]byte(test.data), test.format); err != nil {
+			t.Fatalf("Decoding %q errored: %v", test.data, err)
+		} else if !bytes.Equal(data, expected) {
+			t.Fatalf("Decoding %q resulted in %x, expected %x", test.data, data, expected)
+		}
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		data   string
+		format string
+	}{
+		{"820", Hex},
+		{"8x", Hex},
+		{"gg*7", Base64},
+		{"8201", "octal"},
+	}
+
+	for _, test := range tests {
+		if _, err := Decode([]byte(test.data), test.format); err == nil {
+			t.Fatalf("Decoding %q as %s did not error", test.data, test.format)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	data := []byte{0x82, 0x01, 0xFB, 0xFF}
+
+	for _, format := range []string{Binary, Hex, Base64} {
+		encoded, err := Encode(data, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded, err := Decode(encoded, format); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(decoded, data) {
+			t.Fatalf("Round trip as %s resulted in %x", format, decoded)
+		}
+	}
+
+	if _, err := Encode(data, "octal"); err == nil {
+		t.Fatal("Encoding as octal did not error")
+	}
+}
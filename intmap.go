@@ -0,0 +1,155 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// RawPair is an encoded map pair, e.g., for an unknown key which should be
+// preserved until the map is written again.
+type RawPair struct {
+	Key   []byte
+	Value []byte
+}
+
+// IntMap decodes a map with integer keys, as used to encode structs in, e.g.,
+// COSE or CWT. For each known key, a handler must be registered by Field.
+//
+// Duplicate keys result in an error. Pairs with unknown keys, including
+// non-integer keys, are returned as RawPairs. These can be passed to
+// WriteIntMap, which preserves them.
+type IntMap struct {
+	fields map[int64]func(r io.Reader) error
+
+	// Canonical requires the keys to be sorted by the bytewise lexicographic
+	// order of their encoding, as for the deterministic encoding in RFC 8949,
+	// section 4.2.1.
+	Canonical bool
+}
+
+// NewIntMap creates an IntMap without any known keys.
+func NewIntMap() *IntMap {
+	return &IntMap{fields: make(map[int64]func(r io.Reader) error)}
+}
+
+// Field registers a handler for the key, which reads the value.
+func (im *IntMap) Field(key int64, read func(r io.Reader) error) *IntMap {
+	im.fields[key] = read
+	return im
+}
+
+// Decode expects a map of definite or indefinite length at the Reader's
+// position and calls the registered handler for each known key. The pairs of
+// unknown keys are returned in their order of appearance.
+func (im *IntMap) Decode(r io.Reader) (unknown []RawPair, err error) {
+	seen := make(map[string]bool)
+	var lastKey []byte
+
+	err = readContainer(Map, r, func(i uint64, r io.Reader) error {
+		rawKey, keyErr := ReadRawItem(r)
+		if keyErr == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if keyErr != nil {
+			return fmt.Errorf("Key of pair %d: %w", i, keyErr)
+		}
+
+		// An integer key is identified by its value, independent of its encoding.
+		key, intErr := ReadInt(bytes.NewReader(rawKey))
+		isInt := intErr == nil
+		id := string(rawKey)
+		if isInt {
+			id = fmt.Sprintf("%d", key)
+		}
+
+		if seen[id] {
+			return fmt.Errorf("Duplicate key %x of pair %d", rawKey, i)
+		}
+		seen[id] = true
+
+		if im.Canonical && lastKey != nil && bytes.Compare(lastKey, rawKey) >= 0 {
+			return fmt.Errorf("Key %x of pair %d is not in canonical order", rawKey, i)
+		}
+		lastKey = rawKey
+
+		if read, ok := im.fields[key]; isInt && ok {
+			if err := read(r); err != nil {
+				return fmt.Errorf("Value of key %d: %w", key, err)
+			}
+			return nil
+		}
+
+		rawValue, valueErr := ReadRawItem(r)
+		if valueErr == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if valueErr != nil {
+			return fmt.Errorf("Value of pair %d: %w", i, valueErr)
+		}
+
+		unknown = append(unknown, RawPair{Key: rawKey, Value: rawValue})
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("IntMap: %w", err)
+	}
+	return
+}
+
+// IntMapField is a known pair of an integer key and a function writing its
+// value, to be written by WriteIntMap.
+type IntMapField struct {
+	Key   int64
+	Write func(w io.Writer) error
+}
+
+// WriteIntMap writes a map of definite length of both the fields and the
+// preserved RawPairs into the Writer. All pairs are sorted by the bytewise
+// lexicographic order of their encoded keys, as required for the
+// deterministic encoding in RFC 8949, section 4.2.1. A field must be omitted
+// from the fields slice if it should not be written.
+func WriteIntMap(fields []IntMapField, unknown []RawPair, w io.Writer) error {
+	type pair struct {
+		key   []byte
+		write func(w io.Writer) error
+	}
+
+	pairs := make([]pair, 0, len(fields)+len(unknown))
+	for _, field := range fields {
+		var buff bytes.Buffer
+		if err := WriteInt(field.Key, &buff); err != nil {
+			return err
+		}
+		pairs = append(pairs, pair{buff.Bytes(), field.Write})
+	}
+	for _, rp := range unknown {
+		value := rp.Value
+		pairs = append(pairs, pair{rp.Key, func(w io.Writer) error {
+			_, err := w.Write(value)
+			return err
+		}})
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].key, pairs[j].key) < 0
+	})
+	for i := 1; i < len(pairs); i++ {
+		if bytes.Equal(pairs[i-1].key, pairs[i].key) {
+			return fmt.Errorf("WriteIntMap: Duplicate key %x", pairs[i].key)
+		}
+	}
+
+	if err := WriteMapPairLength(uint64(len(pairs)), w); err != nil {
+		return err
+	}
+
+	for _, p := range pairs {
+		if _, err := w.Write(p.key); err != nil {
+			return err
+		} else if err := p.write(w); err != nil {
+			return fmt.Errorf("WriteIntMap: Value of key %x: %w", p.key, err)
+		}
+	}
+	return nil
+}
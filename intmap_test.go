@@ -0,0 +1,123 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// intMapTestStruct is encoded as {1: A, -1: B, ...unknown}.
+type intMapTestStruct struct {
+	A       uint64
+	B       string
+	unknown []RawPair
+}
+
+func (imts *intMapTestStruct) MarshalCbor(w io.Writer) error {
+	return WriteIntMap([]IntMapField{
+		{1, func(w io.Writer) error { return WriteUInt(imts.A, w) }},
+		{-1, func(w io.Writer) error { return WriteTextString(imts.B, w) }},
+	}, imts.unknown, w)
+}
+
+func (imts *intMapTestStruct) UnmarshalCbor(r io.Reader) (err error) {
+	imts.unknown, err = NewIntMap().
+		Field(1, func(r io.Reader) (err error) {
+			imts.A, err = ReadUInt(r)
+			return
+		}).
+		Field(-1, func(r io.Reader) (err error) {
+			imts.B, err = ReadTextString(r)
+			return
+		}).
+		Decode(r)
+	return
+}
+
+func TestIntMap(t *testing.T) {
+	tests := []struct {
+		data []byte
+		imts intMapTestStruct
+	}{
+		// {1: 23, -1: "a"}
+		{[]byte{0xA2, 0x01, 0x17, 0x20, 0x61, 0x61},
+			intMapTestStruct{A: 23, B: "a"}},
+		// {1: 23, 5: [1], -1: "a", "x": null}
+		{[]byte{0xA4, 0x01, 0x17, 0x05, 0x81, 0x01, 0x20, 0x61, 0x61, 0x61, 0x78, 0xF6},
+			intMapTestStruct{A: 23, B: "a", unknown: []RawPair{
+				{[]byte{0x05}, []byte{0x81, 0x01}},
+				{[]byte{0x61, 0x78}, []byte{0xF6}}}}},
+	}
+
+	for _, test := range tests {
+		var imts intMapTestStruct
+		if err := imts.UnmarshalCbor(bytes.NewBuffer(test.data)); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(imts, test.imts) {
+			t.Fatalf("Decoded struct mismatches: %v != %v", imts, test.imts)
+		}
+
+		// Unknown pairs must be re-emitted unchanged.
+		var buff bytes.Buffer
+		if err := imts.MarshalCbor(&buff); err != nil {
+			t.Fatal(err)
+		} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.data)
+		}
+	}
+}
+
+func TestIntMapIndefinite(t *testing.T) {
+	// {_ -1: "a", 1: 23}
+	data := []byte{0xBF, 0x20, 0x61, 0x61, 0x01, 0x17, 0xFF}
+
+	var imts intMapTestStruct
+	if err := imts.UnmarshalCbor(bytes.NewBuffer(data)); err != nil {
+		t.Fatal(err)
+	} else if imts.A != 23 || imts.B != "a" || imts.unknown != nil {
+		t.Fatalf("Decoded struct mismatches: %v", imts)
+	}
+}
+
+func TestIntMapError(t *testing.T) {
+	tests := []struct {
+		data      []byte
+		canonical bool
+	}{
+		// Duplicate known key
+		{[]byte{0xA2, 0x01, 0x17, 0x01, 0x17}, false},
+		// Duplicate known key, non-minimal encoding
+		{[]byte{0xA2, 0x01, 0x17, 0x18, 0x01, 0x17}, false},
+		// Duplicate unknown key
+		{[]byte{0xA2, 0x05, 0x17, 0x05, 0x17}, false},
+		// Wrong value type
+		{[]byte{0xA1, 0x01, 0x61, 0x61}, false},
+		// Not canonical
+		{[]byte{0xA2, 0x20, 0x61, 0x61, 0x01, 0x17}, true},
+		// Truncated
+		{[]byte{0xA2, 0x01, 0x17, 0x05}, false},
+	}
+
+	for _, test := range tests {
+		var imts intMapTestStruct
+		im := NewIntMap().Field(1, func(r io.Reader) (err error) {
+			imts.A, err = ReadUInt(r)
+			return
+		})
+		im.Canonical = test.canonical
+
+		if _, err := im.Decode(bytes.NewBuffer(test.data)); err == nil {
+			t.Fatalf("Illegal input %x did not error", test.data)
+		}
+	}
+}
+
+func TestWriteIntMapDuplicate(t *testing.T) {
+	fields := []IntMapField{{5, func(w io.Writer) error { return WriteNull(w) }}}
+	unknown := []RawPair{{[]byte{0x05}, []byte{0xF6}}}
+
+	if err := WriteIntMap(fields, unknown, io.Discard); err == nil {
+		t.Fatal("Duplicate key did not error")
+	}
+}
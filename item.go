@@ -0,0 +1,139 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+)
+
+// MaxNestingDepth limits the nesting of arrays, maps and tags for functions
+// processing whole data items, e.g., CopyItem. This is a mitigation against
+// stack exhaustion by crafted input.
+var MaxNestingDepth = 1024
+
+// CopyItem copies the next data item from the Reader into the Writer without
+// changing its encoding. Nested data items are copied as well. If the Reader is
+// at its end, io.EOF is returned. If the data item is truncated,
+// io.ErrUnexpectedEOF is returned.
+func CopyItem(r io.Reader, w io.Writer) error {
+	h, err := ReadHead(r)
+	if err != nil {
+		return err
+	}
+
+	return copyItem(h, r, w, 0)
+}
+
+// ReadRawItem reads the next data item from the Reader and returns its encoded
+// bytes, including all nested data items.
+func ReadRawItem(r io.Reader) ([]byte, error) {
+	var buff bytes.Buffer
+	if err := CopyItem(r, &buff); err != nil {
+		return nil, err
+	}
+	return buff.Bytes(), nil
+}
+
+// SkipItem reads and discards the next data item from the Reader, including
+// all nested data items.
+func SkipItem(r io.Reader) error {
+	return CopyItem(r, io.Discard)
+}
+
+// readNestedHead reads a head within a data item, where io.EOF is unexpected.
+func readNestedHead(r io.Reader) (Head, error) {
+	h, err := ReadHead(r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return h, err
+}
+
+// copyString copies n bytes of a string's content.
+func copyString(n uint64, r io.Reader, w io.Writer) error {
+	if n > math.MaxInt64 {
+		return fmt.Errorf("CopyItem: String of length %d exceeds max int64", n)
+	}
+
+	if _, err := io.CopyN(w, r, int64(n)); err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else {
+		return err
+	}
+}
+
+// copyItem copies the data item of the already read head.
+func copyItem(h Head, r io.Reader, w io.Writer, depth int) error {
+	if depth > MaxNestingDepth {
+		return fmt.Errorf("CopyItem: Exceeded maximum nesting depth of %d", MaxNestingDepth)
+	}
+
+	if err := WriteHead(h, w); err != nil {
+		return err
+	}
+
+	switch h.Major {
+	case ByteString, TextString:
+		if !h.Indefinite {
+			return copyString(h.Argument, r, w)
+		}
+
+		for {
+			chunk, err := readNestedHead(r)
+			if err != nil {
+				return err
+			} else if err := WriteHead(chunk, w); err != nil {
+				return err
+			}
+
+			if chunk.IsBreak() {
+				return nil
+			} else if chunk.Major != h.Major || chunk.Indefinite {
+				return fmt.Errorf("CopyItem: Illegal chunk %v in indefinite-length string", chunk)
+			} else if err := copyString(chunk.Argument, r, w); err != nil {
+				return err
+			}
+		}
+
+	case Array, Map:
+		itemsPerElement := 1
+		if h.Major == Map {
+			itemsPerElement = 2
+		}
+
+		for i := uint64(0); h.Indefinite || i < h.Argument; i++ {
+			for j := 0; j < itemsPerElement; j++ {
+				next, err := readNestedHead(r)
+				if err != nil {
+					return err
+				}
+
+				if next.IsBreak() && h.Indefinite && j == 0 {
+					return WriteHead(next, w)
+				} else if err := copyItem(next, r, w, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	case Tag:
+		next, err := readNestedHead(r)
+		if err != nil {
+			return err
+		}
+		return copyItem(next, r, w, depth+1)
+
+	case SimpleData:
+		if h.IsBreak() {
+			return fmt.Errorf("CopyItem: Unexpected break stop code")
+		} else if h.Info == 24 && h.Argument < 32 {
+			return fmt.Errorf("CopyItem: Simple value %d in two bytes is not well-formed", h.Argument)
+		}
+		return nil
+
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestReadRawItem(t *testing.T) {
+	tests := [][]byte{
+		{0x00},
+		{0x18, 0x01},
+		{0x43, 0x01, 0x02, 0x03},
+		{0x5F, 0x41, 0x01, 0x40, 0xFF},
+		{0x83, 0x01, 0x82, 0x02, 0x03, 0x9F, 0x04, 0xFF},
+		{0xBF, 0x61, 0x61, 0xA1, 0x01, 0x02, 0xFF},
+		{0xD8, 0x18, 0x41, 0x00},
+		{0xF9, 0x3C, 0x00},
+		{0x9F, 0x9F, 0xFF, 0xFF},
+	}
+
+	for _, test := range tests {
+		// Append another item to ensure exactly one item is read.
+		buff := bytes.NewBuffer(append(append([]byte{}, test...), 0x07))
+
+		if raw, err := ReadRawItem(buff); err != nil {
+			t.Fatalf("Reading %x errored: %v", test, err)
+		} else if !reflect.DeepEqual(raw, test) {
+			t.Fatalf("Raw item mismatches: %x != %x", raw, test)
+		}
+
+		if n, err := ReadUInt(buff); err != nil {
+			t.Fatal(err)
+		} else if n != 7 {
+			t.Fatalf("Following item is %d instead of 7", n)
+		}
+	}
+}
+
+func TestSkipItemError(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{}, io.EOF},
+		{[]byte{0x82, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x9F, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x42, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x19, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0xC1}, io.ErrUnexpectedEOF},
+		{[]byte{0xFF}, nil},
+		{[]byte{0xBF, 0x01, 0xFF}, nil},
+		{[]byte{0x5F, 0x61, 0x61, 0xFF}, nil},
+		{[]byte{0xF8, 0x00}, nil},
+	}
+
+	for _, test := range tests {
+		err := SkipItem(bytes.NewBuffer(test.data))
+		if err == nil {
+			t.Fatalf("Illegal input %x did not error", test.data)
+		} else if test.err != nil && err != test.err {
+			t.Fatalf("Input %x errored with %v instead of %v", test.data, err, test.err)
+		}
+	}
+}
+
+func TestSkipItemNestingDepth(t *testing.T) {
+	data := bytes.Repeat([]byte{0x81}, MaxNestingDepth+2)
+	data = append(data, 0x00)
+
+	if err := SkipItem(bytes.NewBuffer(data)); err == nil {
+		t.Fatal("Exceeding the maximum nesting depth did not error")
+	}
+}
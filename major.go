@@ -15,25 +15,47 @@ const (
 	TextString MajorType = 0x60
 	Array      MajorType = 0x80
 	Map        MajorType = 0xA0
+	Tag        MajorType = 0xC0
 	SimpleData MajorType = 0xE0
 )
 
 const (
-	IndefiniteArray byte = 0x9F
-	Null            byte = SimpleData | simpleNull
-	BreakCode       byte = 0xFF
+	IndefiniteByteString byte = 0x5F
+	IndefiniteArray      byte = 0x9F
+	IndefiniteMap        byte = 0xBF
+	Null                 byte = SimpleData | simpleNull
+	Undefined            byte = SimpleData | simpleUndefined
+	BreakCode            byte = 0xFF
 )
 
+// Flag is returned as an error by ReadMajors for heads without a length or a
+// value, e.g., null.
 type Flag byte
 
+// flagNames are the Flags' human readable names.
+var flagNames = map[Flag]string{
+	FlagIndefiniteArray: "indefinite-length array",
+	FlagBreakCode:       "break stop code",
+	FlagNull:            "null",
+	FlagUndefined:       "undefined",
+}
+
+func (f Flag) String() string {
+	if name, ok := flagNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("Flag(%d)", byte(f))
+}
+
 func (f Flag) Error() string {
-	return string(f)
+	return "Flag: " + f.String()
 }
 
 const (
 	FlagIndefiniteArray = Flag(iota)
 	FlagBreakCode       = Flag(iota)
 	FlagNull            = Flag(iota)
+	FlagUndefined       = Flag(iota)
 )
 
 func readMajorType(b byte) (major MajorType, adds byte) {
@@ -43,51 +65,68 @@ func readMajorType(b byte) (major MajorType, adds byte) {
 }
 
 // ReadMajors parses a (major) type definition from the Reader.
+//
+// The special values for an indefinite-length array, the break stop code, null
+// and undefined are reported as FlagIndefiniteArray, FlagBreakCode, FlagNull
+// and FlagUndefined. Other indefinite-length heads result in an error. This
+// function wraps ReadHead, which reports all heads without using errors.
 func ReadMajors(r io.Reader) (m MajorType, n uint64, err error) {
-	var buff [8]byte
-	tmpBuff := buff[:1]
-
-	if _, rerr := io.ReadFull(r, tmpBuff); rerr != nil {
-		err = rerr
+	h, err := ReadHead(r)
+	if err != nil {
 		return
 	}
 
-	switch b := tmpBuff[0]; b {
-	case IndefiniteArray:
+	switch b := h.Initial(); {
+	case b == IndefiniteArray:
 		err = FlagIndefiniteArray
 
-	case BreakCode:
+	case b == BreakCode:
 		err = FlagBreakCode
 
-	case Null:
-		err = FlagNull
+	case b == Null || b == Undefined:
+		err = nullFlag(h)
+
+	case h.Indefinite:
+		err = fmt.Errorf("ReadMajors: Other additional information 0x%x", h.Info)
 
 	default:
-		var adds byte
-		m, adds = readMajorType(b)
-
-		if adds <= 23 {
-			n = uint64(adds)
-		} else if 24 <= adds && adds <= 27 {
-			l := 1 << (adds - 24)
-			tmpBuff = buff[:l]
-
-			if rn, rerr := io.ReadFull(r, tmpBuff); rerr != nil {
-				err = rerr
-				return
-			} else if rn != l {
-				err = fmt.Errorf("ReadMajors: Read %d bytes instead of %d", rn, l)
-				return
-			}
-
-			for i := 0; i < l; i++ {
-				n = n<<8 | uint64(tmpBuff[i])
-			}
-		} else {
-			err = fmt.Errorf("ReadMajors: Other additional information 0x%x", adds)
-		}
+		m, n = h.Major, h.Argument
+	}
+
+	return
+}
+
+// nullFlag returns FlagNull or FlagUndefined for a null or undefined head and
+// nil otherwise.
+func nullFlag(h Head) error {
+	switch h.Initial() {
+	case Null:
+		return FlagNull
+	case Undefined:
+		return FlagUndefined
+	default:
+		return nil
 	}
+}
+
+// readArgument reads the argument following an initial byte from the Reader.
+// The additional information must be 24 to 27, as checked by headFromInitial.
+func readArgument(adds byte, r io.Reader) (n uint64, err error) {
+	var buff [8]byte
+	l := 1 << (adds - 24)
+	tmpBuff := buff[:l]
 
+	if rn, rerr := io.ReadFull(r, tmpBuff); rerr != nil {
+		err = rerr
+		return
+	} else if rn != l {
+		err = fmt.Errorf("ReadMajors: Read %d bytes instead of %d", rn, l)
+		return
+	}
+
+	for i := 0; i < l; i++ {
+		n = n<<8 | uint64(tmpBuff[i])
+	}
 	return
 }
 
@@ -122,6 +161,12 @@ func writeMajorType(major MajorType, adds byte) byte {
 	return major | adds
 }
 
+// writeByte writes a single byte, e.g., a break stop code, into the Writer.
+func writeByte(b byte, w io.Writer) error {
+	_, err := w.Write([]byte{b})
+	return err
+}
+
 // WriteMajors composes a (major) type definition into the Writer.
 func WriteMajors(m MajorType, n uint64, w io.Writer) (err error) {
 	var buff [9]byte
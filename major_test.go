@@ -5,7 +5,9 @@ import (
 	"bytes"
 	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"unicode"
 
 	"pgregory.net/rapid"
 )
@@ -248,3 +250,16 @@ func TestNull(t *testing.T) {
 		t.Fatal("written value not null")
 	}
 }
+
+func TestFlagError(t *testing.T) {
+	messages := make(map[string]bool)
+	for _, f := range []Flag{FlagIndefiniteArray, FlagBreakCode, FlagNull, FlagUndefined, Flag(42)} {
+		msg := f.Error()
+		if strings.IndexFunc(msg, func(r rune) bool { return !unicode.IsPrint(r) }) >= 0 {
+			t.Fatalf("Error of flag %d is not printable: %q", byte(f), msg)
+		} else if messages[msg] {
+			t.Fatalf("Error of flag %d is not unique: %q", byte(f), msg)
+		}
+		messages[msg] = true
+	}
+}
@@ -0,0 +1,52 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+)
+
+// ReadNull expects a null at the Reader's position.
+func ReadNull(r io.Reader) error {
+	return ReadExpect(Null, r)
+}
+
+// ReadUndefined expects an undefined at the Reader's position.
+func ReadUndefined(r io.Reader) error {
+	return ReadExpect(Undefined, r)
+}
+
+// WriteUndefined writes an undefined into the Writer.
+func WriteUndefined(w io.Writer) error {
+	return WriteMajors(SimpleData, uint64(simpleUndefined), w)
+}
+
+// ReadOptional reads an optional value by the readT function, e.g., ReadUInt.
+// If a null or undefined is at the Reader's position, present is false and no
+// error is returned.
+func ReadOptional[T any](readT func(r io.Reader) (T, error), r io.Reader) (value T, present bool, err error) {
+	h, err := ReadHead(r)
+	if err != nil {
+		return
+	}
+
+	if initial := h.Initial(); initial == Null || initial == Undefined {
+		return
+	}
+
+	if value, err = readT(ReplayHead(h, r)); err != nil {
+		err = fmt.Errorf("ReadOptional: %w", err)
+		return
+	}
+
+	present = true
+	return
+}
+
+// WriteOptional writes an optional value by the writeT function, e.g.,
+// WriteUInt. A nil value is written as null.
+func WriteOptional[T any](value *T, writeT func(value T, w io.Writer) error, w io.Writer) error {
+	if value == nil {
+		return WriteNull(w)
+	}
+	return writeT(*value, w)
+}
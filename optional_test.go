@@ -0,0 +1,119 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestOptional(t *testing.T) {
+	n := uint64(1000)
+
+	tests := []struct {
+		data    []byte
+		value   *uint64
+		present bool
+	}{
+		{[]byte{0x19, 0x03, 0xE8}, &n, true},
+		{[]byte{Null}, nil, false},
+		{[]byte{Undefined}, nil, false},
+	}
+
+	for _, test := range tests {
+		// Read
+		buff := bytes.NewBuffer(append(test.data, 0x07))
+		if value, present, err := ReadOptional(ReadUInt, buff); err != nil {
+			t.Fatal(err)
+		} else if present != test.present {
+			t.Fatalf("Present is %t for %x", present, test.data)
+		} else if present && value != *test.value {
+			t.Fatalf("Value %d is not %d", value, *test.value)
+		}
+
+		if n, err := ReadUInt(buff); err != nil {
+			t.Fatal(err)
+		} else if n != 7 {
+			t.Fatalf("Following item is %d instead of 7", n)
+		}
+
+		// Write
+		if test.data[0] == Undefined {
+			continue
+		}
+
+		buff.Reset()
+		if err := WriteOptional(test.value, WriteUInt, buff); err != nil {
+			t.Fatal(err)
+		} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.data)
+		}
+	}
+
+	if _, _, err := ReadOptional(ReadUInt, bytes.NewBuffer([]byte{0x61, 0x61})); err == nil {
+		t.Fatal("Wrong Major Type did not error")
+	}
+
+	// The head is replayed as it was read, e.g., a non-minimal one.
+	if value, present, err := ReadOptional(ReadRawItem, bytes.NewBuffer([]byte{0x18, 0x05})); err != nil {
+		t.Fatal(err)
+	} else if !present || !reflect.DeepEqual(value, []byte{0x18, 0x05}) {
+		t.Fatalf("Replayed data item is %x", value)
+	}
+}
+
+func TestNullUndefined(t *testing.T) {
+	var buff bytes.Buffer
+	if err := WriteNull(&buff); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteUndefined(&buff); err != nil {
+		t.Fatal(err)
+	}
+
+	if bb := buff.Bytes(); !reflect.DeepEqual(bb, []byte{Null, Undefined}) {
+		t.Fatalf("Serialized data mismatches: %x", bb)
+	}
+
+	if err := ReadUndefined(bytes.NewBuffer([]byte{Null})); err == nil {
+		t.Fatal("ReadUndefined accepted a null")
+	}
+	if err := ReadNull(&buff); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadNull(&buff); err == nil {
+		t.Fatal("ReadNull accepted an undefined")
+	}
+}
+
+func TestPrimitivesNullUndefined(t *testing.T) {
+	readers := map[string]func(r io.Reader) (any, error){
+		"ReadUInt":             asUntyped(ReadUInt),
+		"ReadNInt":             asUntyped(ReadNInt),
+		"ReadInt":              asUntyped(ReadInt),
+		"ReadByteStringLen":    asUntyped(ReadByteStringLen),
+		"ReadByteString":       asUntyped(ReadByteString),
+		"ReadByteStringReader": asUntyped(ReadByteStringReader),
+		"ReadTextStringLen":    asUntyped(ReadTextStringLen),
+		"ReadTextString":       asUntyped(ReadTextString),
+		"ReadArrayLength":      asUntyped(ReadArrayLength),
+		"ReadMapPairLength":    asUntyped(ReadMapPairLength),
+		"ReadTag":              asUntyped(ReadTag),
+		"ReadBoolean":          asUntyped(ReadBoolean),
+		"ReadFloat32":          asUntyped(ReadFloat32),
+		"ReadFloat64":          asUntyped(ReadFloat64),
+		"ReadArrayOf": asUntyped(func(r io.Reader) ([]uint64, error) {
+			return ReadArrayOf(ReadUInt, r)
+		}),
+	}
+
+	for name, read := range readers {
+		if _, err := read(bytes.NewBuffer([]byte{Null})); !errors.Is(err, FlagNull) {
+			t.Fatalf("%s returned %v instead of FlagNull", name, err)
+		}
+		if _, err := read(bytes.NewBuffer([]byte{Undefined})); !errors.Is(err, FlagUndefined) {
+			t.Fatalf("%s returned %v instead of FlagUndefined", name, err)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// ParseHandler receives the events of a Parser.
+type ParseHandler interface {
+	// Head is called for each parsed head, including break stop codes and the
+	// chunks of indefinite-length strings.
+	Head(h Head) error
+
+	// Data is called with the following content of a string. A string's
+	// content might be split into multiple calls. The byte slice must not be
+	// retained after the call.
+	Data(p []byte) error
+
+	// Item is called after a top-level data item was completed. The offset is
+	// the position directly after this data item in the input stream, which
+	// is the boundary to the next data item within a CBOR sequence.
+	Item(offset int64) error
+}
+
+// parserFrame is an open container, indefinite-length string or tag on the
+// Parser's stack.
+type parserFrame struct {
+	major      MajorType
+	indefinite bool
+	// remaining data items for a definite-length container or a tag
+	remaining uint64
+	// items parsed so far, to check the parity of an indefinite-length map
+	items uint64
+}
+
+// Parser is an incremental, resumable CBOR parser. In contrast to the other
+// functions of this package, it does not read from an io.Reader, but is fed
+// with arbitrary fragments of the input stream. The parsed data is reported as
+// events to a ParseHandler.
+//
+// A partially received head or string is kept between calls of Feed. Thus, the
+// Parser can be used in an event loop without blocking.
+type Parser struct {
+	handler ParseHandler
+
+	head    [9]byte
+	headLen int
+	payload uint64
+
+	stack  []parserFrame
+	offset int64
+	err    error
+}
+
+// NewParser creates a new Parser, which reports to the ParseHandler.
+func NewParser(handler ParseHandler) *Parser {
+	return &Parser{handler: handler}
+}
+
+// Offset returns the amount of bytes parsed so far.
+func (p *Parser) Offset() int64 {
+	return p.offset
+}
+
+// Depth returns the amount of open containers, indefinite-length strings and
+// tags.
+func (p *Parser) Depth() int {
+	return len(p.stack)
+}
+
+// Pending checks if the Parser is within a data item, i.e., not at a boundary
+// between two top-level data items.
+func (p *Parser) Pending() bool {
+	return p.headLen > 0 || p.payload > 0 || len(p.stack) > 0
+}
+
+// Close checks that the input stream did not end within a data item. Then,
+// io.ErrUnexpectedEOF is returned.
+func (p *Parser) Close() error {
+	if p.err != nil {
+		return p.err
+	} else if p.Pending() {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+
+// Feed parses the next fragment of the input stream. After an error, the Parser
+// cannot be used anymore and each further call returns this error.
+func (p *Parser) Feed(data []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	for len(data) > 0 {
+		var n int
+		if p.payload > 0 {
+			n, p.err = p.feedPayload(data)
+		} else {
+			n, p.err = p.feedHead(data)
+		}
+
+		data = data[n:]
+
+		if p.err != nil {
+			return p.err
+		}
+	}
+	return nil
+}
+
+// feedPayload passes the next part of a string's content to the handler.
+func (p *Parser) feedPayload(data []byte) (int, error) {
+	n := len(data)
+	if uint64(n) > p.payload {
+		n = int(p.payload)
+	}
+
+	p.offset += int64(n)
+	if err := p.handler.Data(data[:n]); err != nil {
+		return n, err
+	}
+
+	p.payload -= uint64(n)
+	if p.payload == 0 {
+		return n, p.stringDone()
+	}
+	return n, nil
+}
+
+// feedHead collects the bytes of the next head and processes it, if complete.
+func (p *Parser) feedHead(data []byte) (n int, err error) {
+	if p.headLen == 0 {
+		p.head[0] = data[0]
+		p.headLen = 1
+		n = 1
+	}
+
+	h, err := headFromInitial(p.head[0])
+	if err != nil {
+		p.offset += int64(n)
+		return
+	}
+
+	copied := copy(p.head[p.headLen:h.Width], data[n:])
+	p.headLen += copied
+	n += copied
+	p.offset += int64(n)
+
+	if p.headLen < h.Width {
+		return
+	}
+
+	for i := 1; i < h.Width; i++ {
+		h.Argument = h.Argument<<8 | uint64(p.head[i])
+	}
+	p.headLen = 0
+
+	err = p.processHead(h)
+	return
+}
+
+// processHead reports a completed head and updates the Parser's state.
+func (p *Parser) processHead(h Head) error {
+	if err := p.handler.Head(h); err != nil {
+		return err
+	}
+
+	var top *parserFrame
+	if len(p.stack) > 0 {
+		top = &p.stack[len(p.stack)-1]
+	}
+
+	// Within an indefinite-length string, only definite-length chunks of the
+	// same Major Type and the break stop code are allowed.
+	if top != nil && top.indefinite && (top.major == ByteString || top.major == TextString) {
+		if h.IsBreak() {
+			p.stack = p.stack[:len(p.stack)-1]
+			return p.itemDone()
+		} else if h.Major != top.major || h.Indefinite {
+			return fmt.Errorf("Parser: Illegal chunk %v in indefinite-length string", h)
+		}
+
+		p.payload = h.Argument
+		return nil
+	}
+
+	switch h.Major {
+	case ByteString, TextString:
+		if h.Indefinite {
+			p.stack = append(p.stack, parserFrame{major: h.Major, indefinite: true})
+			return nil
+		}
+
+		p.payload = h.Argument
+		if p.payload == 0 {
+			return p.itemDone()
+		}
+		return nil
+
+	case Array, Map:
+		remaining := h.Argument
+		if h.Major == Map {
+			if remaining > math.MaxUint64/2 {
+				return fmt.Errorf("Parser: Map of %d pairs is too large", remaining)
+			}
+			remaining *= 2
+		}
+
+		if !h.Indefinite && remaining == 0 {
+			return p.itemDone()
+		}
+
+		p.stack = append(p.stack, parserFrame{major: h.Major, indefinite: h.Indefinite, remaining: remaining})
+		return nil
+
+	case Tag:
+		p.stack = append(p.stack, parserFrame{major: Tag, remaining: 1})
+		return nil
+
+	case SimpleData:
+		if h.IsBreak() {
+			if top == nil || !top.indefinite {
+				return fmt.Errorf("Parser: Break stop code without an indefinite-length container")
+			} else if top.major == Map && top.items%2 != 0 {
+				return fmt.Errorf("Parser: Map with an odd number of %d data items", top.items)
+			}
+
+			p.stack = p.stack[:len(p.stack)-1]
+			return p.itemDone()
+		} else if h.Info == 24 && h.Argument < 32 {
+			return fmt.Errorf("Parser: Simple value %d in two bytes is not well-formed", h.Argument)
+		}
+		return p.itemDone()
+
+	default:
+		return p.itemDone()
+	}
+}
+
+// stringDone is called after a string's content or a chunk's content was
+// completely received.
+func (p *Parser) stringDone() error {
+	if len(p.stack) > 0 {
+		if top := p.stack[len(p.stack)-1]; top.indefinite && (top.major == ByteString || top.major == TextString) {
+			return nil
+		}
+	}
+	return p.itemDone()
+}
+
+// itemDone marks a data item as completed for the innermost container. A
+// completed container or tag completes an item for its enclosing container.
+func (p *Parser) itemDone() error {
+	for len(p.stack) > 0 {
+		top := &p.stack[len(p.stack)-1]
+		top.items++
+
+		if top.indefinite {
+			return nil
+		}
+
+		top.remaining--
+		if top.remaining > 0 {
+			return nil
+		}
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+
+	return p.handler.Item(p.offset)
+}
+
+// ItemSplitter splits a fragmented input stream of a CBOR sequence into its
+// complete top-level data items, using a Parser.
+type ItemSplitter struct {
+	parser *Parser
+
+	pending []byte
+	items   [][]byte
+
+	chunk      []byte
+	chunkStart int64
+	itemStart  int64
+}
+
+// NewItemSplitter creates a new ItemSplitter.
+func NewItemSplitter() *ItemSplitter {
+	is := &ItemSplitter{}
+	is.parser = NewParser((*splitHandler)(is))
+	return is
+}
+
+// Feed parses the next fragment of the input stream and returns all data items
+// completed by this fragment. The returned byte slices do not alias the given
+// fragment. An incomplete data item is kept until further fragments arrive.
+func (is *ItemSplitter) Feed(data []byte) (items [][]byte, err error) {
+	is.chunk = data
+	is.chunkStart = is.parser.Offset()
+
+	err = is.parser.Feed(data)
+
+	if is.itemStart < is.parser.Offset() {
+		from := is.itemStart - is.chunkStart
+		if from < 0 {
+			from = 0
+		}
+		is.pending = append(is.pending, data[from:is.parser.Offset()-is.chunkStart]...)
+	}
+
+	items, is.items = is.items, nil
+	is.chunk = nil
+	return
+}
+
+// Close checks that the input stream did not end within a data item.
+func (is *ItemSplitter) Close() error {
+	return is.parser.Close()
+}
+
+// splitHandler is the ItemSplitter's ParseHandler.
+type splitHandler ItemSplitter
+
+func (is *splitHandler) Head(_ Head) error {
+	return nil
+}
+
+func (is *splitHandler) Data(_ []byte) error {
+	return nil
+}
+
+func (is *splitHandler) Item(offset int64) error {
+	from := is.itemStart - is.chunkStart
+	if from < 0 {
+		from = 0
+	}
+
+	item := append(is.pending, is.chunk[from:offset-is.chunkStart]...)
+	is.items = append(is.items, item)
+
+	is.pending = nil
+	is.itemStart = offset
+	return nil
+}
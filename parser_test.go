@@ -0,0 +1,161 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"pgregory.net/rapid"
+)
+
+// recordHandler records all events as strings.
+type recordHandler struct {
+	events []string
+}
+
+func (rh *recordHandler) Head(h Head) error {
+	rh.events = append(rh.events, h.String())
+	return nil
+}
+
+func (rh *recordHandler) Data(p []byte) error {
+	// Merge consecutive data events, as their fragmentation depends on the input.
+	if l := len(rh.events); l > 0 && rh.events[l-1][0] == 'd' {
+		rh.events[l-1] += fmt.Sprintf("%x", p)
+	} else {
+		rh.events = append(rh.events, fmt.Sprintf("data %x", p))
+	}
+	return nil
+}
+
+func (rh *recordHandler) Item(offset int64) error {
+	rh.events = append(rh.events, fmt.Sprintf("item %d", offset))
+	return nil
+}
+
+func TestParser(t *testing.T) {
+	// [1, "ab", [_ 2]] 24(h'8001') {_ 1: (_ h'01', h'')} 1000
+	data := []byte{
+		0x83, 0x01, 0x62, 0x61, 0x62, 0x9F, 0x02, 0xFF,
+		0xD8, 0x18, 0x42, 0x80, 0x01,
+		0xBF, 0x01, 0x5F, 0x41, 0x01, 0x40, 0xFF, 0xFF,
+		0x19, 0x03, 0xE8}
+	events := []string{
+		"array(3)", "unsigned(1)", "text(2)", "data 6162", "array(*)", "unsigned(2)", "break", "item 8",
+		"tag(24)", "bytes(2)", "data 8001", "item 13",
+		"map(*)", "unsigned(1)", "bytes(*)", "bytes(1)", "data 01", "bytes(0)", "break", "break", "item 21",
+		"unsigned(1000)", "item 24",
+	}
+
+	// Feed the whole input at once, byte by byte and in chunks of three bytes.
+	for _, chunkSize := range []int{len(data), 1, 3} {
+		rh := &recordHandler{}
+		p := NewParser(rh)
+
+		for i := 0; i < len(data); i += chunkSize {
+			end := i + chunkSize
+			if end > len(data) {
+				end = len(data)
+			}
+
+			if err := p.Feed(data[i:end]); err != nil {
+				t.Fatalf("Chunk size %d: %v", chunkSize, err)
+			}
+		}
+
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(rh.events, events) {
+			t.Fatalf("Chunk size %d: events mismatch:\n%v\n%v", chunkSize, rh.events, events)
+		}
+	}
+}
+
+func TestParserError(t *testing.T) {
+	tests := [][]byte{
+		{0xFF},
+		{0x1C},
+		{0x1F},
+		{0x82, 0x01, 0xFF},
+		{0xBF, 0x01, 0xFF},
+		{0x5F, 0x61, 0x61, 0xFF},
+		{0x5F, 0x5F, 0xFF, 0xFF},
+		{0xF8, 0x01},
+	}
+
+	for _, test := range tests {
+		p := NewParser(&recordHandler{})
+		if err := p.Feed(test); err == nil {
+			t.Fatalf("Illegal input %x did not error", test)
+		} else if err2 := p.Feed([]byte{0x00}); err2 != err {
+			t.Fatalf("Error was not sticky: %v != %v", err2, err)
+		}
+	}
+
+	for _, test := range [][]byte{{0x18}, {0x82, 0x01}, {0x62, 0x61}, {0xC1}} {
+		p := NewParser(&recordHandler{})
+		if err := p.Feed(test); err != nil {
+			t.Fatal(err)
+		} else if err := p.Close(); err != io.ErrUnexpectedEOF {
+			t.Fatalf("Truncated input %x returned %v on Close", test, err)
+		}
+	}
+}
+
+func TestItemSplitter(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		items := rapid.SliceOfN(rapid.SliceOfN(rapid.Byte(), 0, 64), 0, 16).Draw(t, "items")
+		chunkSize := rapid.IntRange(1, 32).Draw(t, "chunkSize")
+
+		var data bytes.Buffer
+		var expected [][]byte
+		for i, item := range items {
+			var buff bytes.Buffer
+			if i%2 == 0 {
+				_ = WriteByteString(item, &buff)
+			} else {
+				_ = WriteArrayLength(2, &buff)
+				_ = WriteUInt(uint64(len(item)), &buff)
+				_ = WriteByteStringChunked(bytes.NewReader(item), 5, &buff)
+			}
+
+			expected = append(expected, buff.Bytes())
+			data.Write(buff.Bytes())
+		}
+
+		is := NewItemSplitter()
+		var split [][]byte
+		raw := data.Bytes()
+		for i := 0; i < len(raw); i += chunkSize {
+			end := i + chunkSize
+			if end > len(raw) {
+				end = len(raw)
+			}
+
+			chunk := append([]byte{}, raw[i:end]...)
+			chunkItems, err := is.Feed(chunk)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Overwrite the chunk to detect aliasing.
+			for j := range chunk {
+				chunk[j] = 0xFF
+			}
+			split = append(split, chunkItems...)
+		}
+
+		if err := is.Close(); err != nil {
+			t.Fatal(err)
+		} else if len(split) != len(expected) {
+			t.Fatalf("Split %d instead of %d items", len(split), len(expected))
+		}
+		for i := range split {
+			if !bytes.Equal(split[i], expected[i]) {
+				t.Fatalf("Item %d mismatches: %x != %x", i, split[i], expected[i])
+			}
+		}
+	})
+}
@@ -0,0 +1,279 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Patch copies the next data item from the Reader into the Writer and replaces
+// the data item nested at the Path by the replacement, which must be exactly
+// one encoded data item. The Path is interpreted as for Query.
+//
+// All other bytes are copied unchanged, including the encoding of untouched
+// data items, non-minimal heads and indefinite lengths. The lengths of arrays,
+// maps and tags are not affected by a replacement. Only a byte string
+// embedding the target, as selected by a PathEmbedded element, needs a new
+// length if the replacement's length differs. Its head keeps its width if the
+// new length fits, and an indefinite-length byte string then becomes a single
+// chunk. As the embedding byte string is rewritten, its content is held in
+// memory, while everything else is streamed.
+//
+// If the Path does not exist, ErrPathNotFound is returned, wrapped with the
+// Path up to the missing element. On an error, the Writer might have received
+// a part of the data item. If the Reader is at its end, io.EOF is returned.
+func Patch(r io.Reader, w io.Writer, path Path, replacement []byte) error {
+	rr := bytes.NewReader(replacement)
+	if err := SkipItem(rr); err != nil {
+		return fmt.Errorf("Patch: Replacement is not well-formed: %w", err)
+	} else if rr.Len() > 0 {
+		return fmt.Errorf("Patch: Replacement is followed by %d bytes", rr.Len())
+	}
+
+	h, err := ReadHead(r)
+	if err != nil {
+		return err
+	}
+
+	if err := patchItem(h, r, w, path, 0, replacement); err != nil {
+		return fmt.Errorf("Patch: %w", err)
+	}
+	return nil
+}
+
+// patchItem copies the data item of the already read head and follows the
+// Path from its i-th element to the target, which is replaced.
+func patchItem(h Head, r io.Reader, w io.Writer, path Path, i int, replacement []byte) error {
+	if i == len(path) {
+		if err := copyItem(h, r, io.Discard, i); err != nil {
+			return fmt.Errorf("%v: %w", path, err)
+		}
+		_, err := w.Write(replacement)
+		return err
+	}
+
+	e := path[i]
+	fail := func(err error) error {
+		return fmt.Errorf("%v: %w", path[:i+1], err)
+	}
+
+	switch e.Kind {
+	case PathIndex, PathKey:
+		if e.Kind == PathIndex && h.Major != Array {
+			return fail(fmt.Errorf("Expected array, got %v", h))
+		} else if e.Kind == PathKey && h.Major != Map {
+			return fail(fmt.Errorf("Expected map, got %v", h))
+		} else if e.Kind == PathIndex && !h.Indefinite && e.Index >= h.Argument {
+			return fail(ErrPathNotFound)
+		}
+
+		var wanted *Item
+		if e.Kind == PathKey {
+			var err error
+			if wanted, err = ReadItem(bytes.NewReader(e.Key)); err != nil {
+				return fail(fmt.Errorf("Illegal key %x: %w", e.Key, err))
+			}
+		}
+
+		if err := WriteHead(h, w); err != nil {
+			return err
+		}
+
+		items := h.Argument
+		if h.Major == Map {
+			items *= 2
+		}
+
+		for n := uint64(0); h.Indefinite || n < items; n++ {
+			next, err := readNestedHead(r)
+			if err != nil {
+				return fail(err)
+			} else if next.IsBreak() && h.Indefinite && (h.Major == Array || n%2 == 0) {
+				break
+			}
+
+			target := e.Kind == PathIndex && n == e.Index
+			if e.Kind == PathKey && n%2 == 0 {
+				// Compare the key, which is copied as it is.
+				var rawKey bytes.Buffer
+				if err := copyItem(next, r, &rawKey, i+1); err != nil {
+					return fail(err)
+				}
+
+				key, keyErr := ReadItem(bytes.NewReader(rawKey.Bytes()))
+				if _, err := w.Write(rawKey.Bytes()); err != nil {
+					return err
+				} else if keyErr != nil || !sameValue(key, wanted) {
+					continue
+				}
+
+				n++
+				if next, err = readNestedHead(r); err != nil {
+					return fail(err)
+				}
+				target = true
+			}
+
+			if !target {
+				if err := copyItem(next, r, w, i+1); err != nil {
+					return fail(err)
+				}
+				continue
+			}
+
+			if err := patchItem(next, r, w, path, i+1, replacement); err != nil {
+				return err
+			} else if err := copyRest(h, n+1, r, w, i); err != nil {
+				return fail(err)
+			}
+			return nil
+		}
+		return fail(ErrPathNotFound)
+
+	case PathTag:
+		if h.Major != Tag || h.Argument != e.Tag {
+			return fail(fmt.Errorf("Expected tag(%d), got %v", e.Tag, h))
+		} else if err := WriteHead(h, w); err != nil {
+			return err
+		}
+
+		next, err := readNestedHead(r)
+		if err != nil {
+			return fail(err)
+		}
+		return patchItem(next, r, w, path, i+1, replacement)
+
+	case PathEmbedded:
+		return patchEmbedded(h, r, w, path, i, replacement)
+
+	default:
+		return fail(fmt.Errorf("Illegal path element %v", e))
+	}
+}
+
+// copyRest copies the remaining data items of an array or a map, after the
+// first done data items were already copied, including an indefinite length's
+// break stop code.
+func copyRest(h Head, done uint64, r io.Reader, w io.Writer, depth int) error {
+	items := h.Argument
+	if h.Major == Map {
+		items *= 2
+	}
+
+	for n := done; h.Indefinite || n < items; n++ {
+		next, err := readNestedHead(r)
+		if err != nil {
+			return err
+		} else if next.IsBreak() && h.Indefinite && (h.Major == Array || n%2 == 0) {
+			return WriteHead(next, w)
+		} else if err := copyItem(next, r, w, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// patchEmbedded patches the data item embedded in the byte string of the
+// already read head. The byte string's head is only changed if the patched
+// content's length differs.
+func patchEmbedded(h Head, r io.Reader, w io.Writer, path Path, i int, replacement []byte) error {
+	fail := func(err error) error {
+		return fmt.Errorf("%v: %w", path[:i+1], err)
+	}
+
+	if h.Major != ByteString {
+		return fail(fmt.Errorf("Expected byte string, got %v", h))
+	}
+
+	// For an indefinite-length byte string, its chunks' heads are kept.
+	var content []byte
+	var chunks []Head
+	if !h.Indefinite {
+		data, err := ReadRawBytes(h.Argument, r)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fail(err)
+		}
+		content = data
+	} else {
+		for {
+			chunk, err := readNestedHead(r)
+			if err != nil {
+				return fail(err)
+			} else if chunk.IsBreak() {
+				break
+			} else if chunk.Major != ByteString || chunk.Indefinite {
+				return fail(fmt.Errorf("Illegal chunk %v in indefinite-length byte string", chunk))
+			}
+
+			data, err := ReadRawBytes(chunk.Argument, r)
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return fail(err)
+			}
+			content = append(content, data...)
+			chunks = append(chunks, chunk)
+		}
+	}
+
+	cr := bytes.NewReader(content)
+	next, err := readNestedHead(cr)
+	if err != nil {
+		return fail(err)
+	}
+
+	var patched bytes.Buffer
+	if err := patchItem(next, cr, &patched, path, i+1, replacement); err != nil {
+		return err
+	}
+	// Data following the embedded data item is kept as well.
+	patched.Write(content[len(content)-cr.Len():])
+
+	n := uint64(patched.Len())
+	switch {
+	case n == uint64(len(content)) && !h.Indefinite:
+		if err := WriteHead(h, w); err != nil {
+			return err
+		}
+		_, err := w.Write(patched.Bytes())
+		return err
+
+	case n == uint64(len(content)):
+		if err := WriteHead(h, w); err != nil {
+			return err
+		}
+		for _, chunk := range chunks {
+			if err := WriteHead(chunk, w); err != nil {
+				return err
+			} else if _, err := w.Write(patched.Next(int(chunk.Argument))); err != nil {
+				return err
+			}
+		}
+		_, err := w.Write([]byte{BreakCode})
+		return err
+
+	case !h.Indefinite && h.Width > 1 && HeadSize(n) <= uint64(h.Width):
+		// Keep the head's width, e.g., of a fixed-width head reserved before.
+		if err := WriteHead(Head{Major: ByteString, Info: h.Info, Argument: n, Width: h.Width}, w); err != nil {
+			return err
+		}
+		_, err := w.Write(patched.Bytes())
+		return err
+
+	case !h.Indefinite:
+		return WriteByteString(patched.Bytes(), w)
+
+	default:
+		if _, err := w.Write([]byte{IndefiniteByteString}); err != nil {
+			return err
+		} else if err := WriteByteString(patched.Bytes(), w); err != nil {
+			return err
+		}
+		_, err := w.Write([]byte{BreakCode})
+		return err
+	}
+}
@@ -0,0 +1,196 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		data        []byte
+		path        string
+		replacement []byte
+		expected    []byte
+	}{
+		// Root
+		{[]byte{0x01}, "", []byte{0x62, 0x61, 0x62}, []byte{0x62, 0x61, 0x62}},
+		// Arrays, keeping non-minimal and indefinite-length heads
+		{
+			[]byte{0x98, 0x03, 0x01, 0x19, 0x00, 0x02, 0x03},
+			"[1]",
+			[]byte{0x82, 0x04, 0x05},
+			[]byte{0x98, 0x03, 0x01, 0x82, 0x04, 0x05, 0x03},
+		},
+		{
+			[]byte{0x9F, 0x01, 0x9F, 0x02, 0x03, 0xFF, 0x04, 0xFF},
+			"[1][0]",
+			[]byte{0xF6},
+			[]byte{0x9F, 0x01, 0x9F, 0xF6, 0x03, 0xFF, 0x04, 0xFF},
+		},
+		// Maps, matching keys by their value
+		{
+			[]byte{0xA2, 0x61, 0x61, 0x01, 0x18, 0x04, 0x02},
+			"{4}",
+			[]byte{0x19, 0x01, 0x00},
+			[]byte{0xA2, 0x61, 0x61, 0x01, 0x18, 0x04, 0x19, 0x01, 0x00},
+		},
+		{
+			[]byte{0xBF, 0x61, 0x61, 0x01, 0x61, 0x62, 0x02, 0x61, 0x63, 0x03, 0xFF},
+			`{"b"}`,
+			[]byte{0x80},
+			[]byte{0xBF, 0x61, 0x61, 0x01, 0x61, 0x62, 0x80, 0x61, 0x63, 0x03, 0xFF},
+		},
+		// Tags
+		{
+			[]byte{0xC1, 0x1A, 0x51, 0x4B, 0x67, 0xB0},
+			"#1",
+			[]byte{0x00},
+			[]byte{0xC1, 0x00},
+		},
+		// Embedded data items of the same length keep their heads
+		{
+			[]byte{0x82, 0xD8, 0x18, 0x43, 0x82, 0x01, 0x02, 0x03},
+			"[0]#24<<>>[1]",
+			[]byte{0x07},
+			[]byte{0x82, 0xD8, 0x18, 0x43, 0x82, 0x01, 0x07, 0x03},
+		},
+		{
+			[]byte{0x5F, 0x42, 0x82, 0x01, 0x41, 0x02, 0xFF},
+			"<<>>[1]",
+			[]byte{0x07},
+			[]byte{0x5F, 0x42, 0x82, 0x01, 0x41, 0x07, 0xFF},
+		},
+		// Embedded data items of another length require a new head
+		{
+			[]byte{0xD8, 0x18, 0x43, 0x82, 0x01, 0x02},
+			"#24<<>>[1]",
+			[]byte{0x18, 0x20},
+			[]byte{0xD8, 0x18, 0x44, 0x82, 0x01, 0x18, 0x20},
+		},
+		{
+			[]byte{0x58, 0x03, 0x82, 0x01, 0x02},
+			"<<>>[1]",
+			[]byte{0x18, 0x20},
+			[]byte{0x58, 0x04, 0x82, 0x01, 0x18, 0x20},
+		},
+		{
+			[]byte{0x57, 0x82, 0x01, 0x54, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+			"<<>>[0]",
+			[]byte{0x18, 0x20},
+			[]byte{0x58, 0x18, 0x82, 0x18, 0x20, 0x54, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			[]byte{0x5F, 0x42, 0x82, 0x01, 0x41, 0x02, 0xFF},
+			"<<>>[1]",
+			[]byte{0x18, 0x20},
+			[]byte{0x5F, 0x44, 0x82, 0x01, 0x18, 0x20, 0xFF},
+		},
+		// Data following an embedded data item is kept
+		{[]byte{0x42, 0x01, 0x02}, "<<>>", []byte{0x00}, []byte{0x42, 0x00, 0x02}},
+		// Nested embedded data items
+		{
+			[]byte{0x43, 0x42, 0x81, 0x01},
+			"<<>><<>>[0]",
+			[]byte{0x18, 0x20},
+			[]byte{0x44, 0x43, 0x81, 0x18, 0x20},
+		},
+	}
+
+	for _, test := range tests {
+		path, err := ParsePath(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buff bytes.Buffer
+		if err := Patch(bytes.NewReader(test.data), &buff, path, test.replacement); err != nil {
+			t.Fatalf("Patching %x at %s errored: %v", test.data, test.path, err)
+		} else if !bytes.Equal(buff.Bytes(), test.expected) {
+			t.Fatalf("Patching %x at %s resulted in %x, expected %x", test.data, test.path, buff.Bytes(), test.expected)
+		}
+	}
+}
+
+func TestPatchStream(t *testing.T) {
+	// Only the first data item is patched and the remaining data is left unread.
+	r := bytes.NewReader([]byte{0x82, 0x01, 0x02, 0x03})
+
+	var buff bytes.Buffer
+	if err := Patch(r, &buff, Path{{Kind: PathIndex, Index: 0}}, []byte{0x04}); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(buff.Bytes(), []byte{0x82, 0x04, 0x02}) {
+		t.Fatalf("Unexpected result %x", buff.Bytes())
+	} else if r.Len() != 1 {
+		t.Fatalf("Patch left %d instead of 1 byte unread", r.Len())
+	}
+
+	if err := Patch(bytes.NewReader(nil), &buff, nil, []byte{0x00}); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestPatchErrors(t *testing.T) {
+	tests := []struct {
+		data        []byte
+		path        string
+		replacement []byte
+		notFound    bool
+	}{
+		{[]byte{0x82, 0x01, 0x02}, "[2]", []byte{0x00}, true},
+		{[]byte{0x9F, 0x01, 0xFF}, "[1]", []byte{0x00}, true},
+		{[]byte{0xA1, 0x01, 0x02}, "{2}", []byte{0x00}, true},
+		{[]byte{0x82, 0x01, 0x02}, "{1}", []byte{0x00}, false},
+		{[]byte{0xC1, 0x01}, "#2", []byte{0x00}, false},
+		{[]byte{0x01}, "<<>>", []byte{0x00}, false},
+		{[]byte{0x42, 0x01}, "<<>>", []byte{0x00}, false},
+		{[]byte{0x41, 0x82}, "<<>>", []byte{0x00}, false},
+		{[]byte{0x82, 0x01}, "[0]", []byte{0x00}, false},
+		{[]byte{0x82, 0x01}, "[1]", []byte{0x00}, false},
+		{[]byte{0x9F, 0x01, 0x02}, "[0]", []byte{0x00}, false},
+		{[]byte{0x82, 0x01, 0x02}, "[0]", []byte{}, false},
+		{[]byte{0x82, 0x01, 0x02}, "[0]", []byte{0x82, 0x01}, false},
+		{[]byte{0x82, 0x01, 0x02}, "[0]", []byte{0x01, 0x02}, false},
+	}
+
+	for _, test := range tests {
+		path, err := ParsePath(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = Patch(bytes.NewReader(test.data), io.Discard, path, test.replacement)
+		if err == nil {
+			t.Fatalf("Patching %x at %s did not error", test.data, test.path)
+		} else if errors.Is(err, ErrPathNotFound) != test.notFound {
+			t.Fatalf("Patching %x at %s errored unexpectedly: %v", test.data, test.path, err)
+		}
+	}
+}
+
+func TestPatchQuery(t *testing.T) {
+	// [1, {"a": 24(<<[2, 3]>>)}]
+	data := []byte{0x82, 0x01, 0xA1, 0x61, 0x61, 0xD8, 0x18, 0x43, 0x82, 0x02, 0x03}
+	path := Path{
+		{Kind: PathIndex, Index: 1}, {Kind: PathKey, Key: []byte{0x61, 0x61}},
+		{Kind: PathTag, Tag: 24}, {Kind: PathEmbedded}, {Kind: PathIndex, Index: 0},
+	}
+
+	var buff bytes.Buffer
+	if err := Patch(bytes.NewReader(data), &buff, path, []byte{0x63, 0x66, 0x6F, 0x6F}); err != nil {
+		t.Fatal(err)
+	}
+
+	if raw, err := Query(bytes.NewReader(buff.Bytes()), path); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(raw, []byte{0x63, 0x66, 0x6F, 0x6F}) {
+		t.Fatalf("Query after Patch resulted in %x", raw)
+	}
+
+	if raw, err := Query(bytes.NewReader(buff.Bytes()), path[:4]); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(raw, []byte{0x82, 0x63, 0x66, 0x6F, 0x6F, 0x03}) {
+		t.Fatalf("Query after Patch resulted in %x", raw)
+	}
+}
@@ -0,0 +1,225 @@
+package cboring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PathKind is the kind of a PathElement.
+type PathKind int
+
+const (
+	// PathIndex selects an array's element by its index.
+	PathIndex PathKind = iota
+	// PathKey selects a map's value by its key.
+	PathKey
+	// PathTag selects a tag's content, if the tag has the expected number.
+	PathTag
+	// PathEmbedded selects the data item embedded in a byte string, e.g.,
+	// within a tag 24.
+	PathEmbedded
+)
+
+// PathElement is a single step of a Path.
+type PathElement struct {
+	Kind PathKind
+	// Index of an array's element for PathIndex.
+	Index uint64
+	// Key is the encoded map key for PathKey.
+	Key []byte
+	// Tag is the tag number for PathTag.
+	Tag uint64
+}
+
+// Path describes the position of a data item nested within another one.
+//
+// Its textual representation, as returned by String, concatenates the steps:
+// "[0]" for an array's first element, `{"k"}` or "{1}" for a map's value by a
+// text or integer key, "#24" for the content of a tag 24 and "<<>>" for the
+// data item embedded in a byte string. Other map keys are represented by
+// their encoding, e.g., "{0x8201}" for the key [1]. An empty Path refers to
+// the data item itself.
+type Path []PathElement
+
+// Append returns a new Path extended by the element, leaving p unchanged.
+func (p Path) Append(e PathElement) Path {
+	return append(p[:len(p):len(p)], e)
+}
+
+// Equal checks if both Paths have the same elements.
+func (p Path) Equal(other Path) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		a, b := p[i], other[i]
+		if a.Kind != b.Kind || a.Index != b.Index || a.Tag != b.Tag || !bytes.Equal(a.Key, b.Key) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p Path) String() string {
+	var b strings.Builder
+	for _, e := range p {
+		b.WriteString(e.String())
+	}
+	return b.String()
+}
+
+func (e PathElement) String() string {
+	switch e.Kind {
+	case PathIndex:
+		return fmt.Sprintf("[%d]", e.Index)
+	case PathKey:
+		return "{" + pathKeyString(e.Key) + "}"
+	case PathTag:
+		return fmt.Sprintf("#%d", e.Tag)
+	case PathEmbedded:
+		return "<<>>"
+	default:
+		return fmt.Sprintf("<invalid path element %d>", e.Kind)
+	}
+}
+
+// pathKeyString represents an encoded map key within a Path.
+func pathKeyString(key []byte) string {
+	r := bytes.NewReader(key)
+	h, err := ReadHead(r)
+	if err != nil || !h.IsMinimal() || h.Indefinite {
+		return fmt.Sprintf("0x%x", key)
+	}
+
+	switch {
+	case h.Major == UInt && r.Len() == 0:
+		return strconv.FormatUint(h.Argument, 10)
+	case h.Major == NInt && r.Len() == 0:
+		if h.Argument < 1<<63 {
+			return strconv.FormatInt(^int64(h.Argument), 10)
+		}
+	case h.Major == TextString:
+		if data, err := ReadRawBytes(h.Argument, r); err == nil && r.Len() == 0 {
+			return strconv.Quote(string(data))
+		}
+	}
+	return fmt.Sprintf("0x%x", key)
+}
+
+// ParsePath parses the textual representation of a Path, as returned by String.
+// Map keys might be given as a quoted text string, an integer or the key's
+// encoding in hexadecimal, prefixed by "0x". Text strings and integers are
+// encoded in their shortest form.
+func ParsePath(s string) (Path, error) {
+	path := Path{}
+	for pos := 0; pos < len(s); {
+		rest := s[pos:]
+
+		var e PathElement
+		var n int
+		var err error
+		switch {
+		case rest[0] == '[':
+			e.Kind = PathIndex
+			n = strings.IndexByte(rest, ']') + 1
+			if n == 0 {
+				return nil, fmt.Errorf("ParsePath: Unterminated index at offset %d", pos)
+			}
+			e.Index, err = parsePathNumber(rest[1 : n-1])
+
+		case rest[0] == '{':
+			e.Kind = PathKey
+			e.Key, n, err = parsePathKey(rest)
+
+		case rest[0] == '#':
+			e.Kind = PathTag
+			n = 1
+			for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+				n++
+			}
+			e.Tag, err = parsePathNumber(rest[1:n])
+
+		case strings.HasPrefix(rest, "<<>>"):
+			e.Kind = PathEmbedded
+			n = 4
+
+		default:
+			return nil, fmt.Errorf("ParsePath: Unexpected %q at offset %d", rest[0], pos)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("ParsePath: Element at offset %d: %w", pos, err)
+		}
+		path = append(path, e)
+		pos += n
+	}
+	return path, nil
+}
+
+// parsePathNumber parses an array index or a tag number.
+func parsePathNumber(s string) (uint64, error) {
+	if s == "" || s[0] < '0' || s[0] > '9' {
+		return 0, fmt.Errorf("Expected a number, got %q", s)
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// parsePathKey parses a map key in braces at the start of s and returns its
+// encoding together with the length of its textual representation.
+func parsePathKey(s string) (key []byte, n int, err error) {
+	var buff bytes.Buffer
+	inner := s[1:]
+
+	switch {
+	case strings.HasPrefix(inner, `"`):
+		quoted, quoteErr := strconv.QuotedPrefix(inner)
+		if quoteErr != nil {
+			return nil, 0, fmt.Errorf("Illegal quoted key: %w", quoteErr)
+		}
+		text, _ := strconv.Unquote(quoted)
+		if err = WriteTextString(text, &buff); err != nil {
+			return
+		}
+		inner = inner[len(quoted):]
+
+	default:
+		end := strings.IndexByte(inner, '}')
+		if end < 0 {
+			return nil, 0, fmt.Errorf("Unterminated key")
+		}
+		literal := inner[:end]
+		inner = inner[end:]
+
+		if hexKey, isHex := strings.CutPrefix(literal, "0x"); isHex {
+			if key, err = hex.DecodeString(hexKey); err != nil {
+				return nil, 0, fmt.Errorf("Illegal encoded key: %w", err)
+			} else if len(key) == 0 {
+				return nil, 0, fmt.Errorf("Empty encoded key")
+			}
+			buff.Write(key)
+		} else if strings.HasPrefix(literal, "-") {
+			i, parseErr := strconv.ParseInt(literal, 10, 64)
+			if parseErr != nil {
+				return nil, 0, fmt.Errorf("Illegal key %q: %w", literal, parseErr)
+			}
+			err = WriteInt(i, &buff)
+		} else {
+			u, parseErr := parsePathNumber(literal)
+			if parseErr != nil {
+				return nil, 0, fmt.Errorf("Illegal key %q: %w", literal, parseErr)
+			}
+			err = WriteUInt(u, &buff)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	if !strings.HasPrefix(inner, "}") {
+		return nil, 0, fmt.Errorf("Unterminated key")
+	}
+	return buff.Bytes(), len(s) - len(inner) + 1, nil
+}
@@ -0,0 +1,78 @@
+package cboring
+
+import "testing"
+
+func TestPathString(t *testing.T) {
+	tests := []struct {
+		path     Path
+		expected string
+	}{
+		{nil, ""},
+		{Path{{Kind: PathIndex, Index: 0}, {Kind: PathIndex, Index: 3}}, "[0][3]"},
+		{Path{{Kind: PathKey, Key: []byte{0x61, 0x6B}}}, `{"k"}`},
+		{Path{{Kind: PathKey, Key: []byte{0x01}}, {Kind: PathKey, Key: []byte{0x20}}}, "{1}{-1}"},
+		{Path{{Kind: PathKey, Key: []byte{0x18, 0x01}}}, "{0x1801}"},
+		{Path{{Kind: PathKey, Key: []byte{0x82, 0x01, 0x02}}}, "{0x820102}"},
+		{Path{{Kind: PathKey, Key: []byte{0x3B, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}}}, "{0x3bffffffffffffffff}"},
+		{Path{{Kind: PathIndex, Index: 1}, {Kind: PathTag, Tag: 24}, {Kind: PathEmbedded}, {Kind: PathIndex, Index: 2}}, "[1]#24<<>>[2]"},
+	}
+
+	for _, test := range tests {
+		if s := test.path.String(); s != test.expected {
+			t.Fatalf("Path %v is %q, expected %q", []PathElement(test.path), s, test.expected)
+		}
+	}
+}
+
+func TestPathAppend(t *testing.T) {
+	base := make(Path, 1, 4)
+	a := base.Append(PathElement{Kind: PathIndex, Index: 1})
+	b := base.Append(PathElement{Kind: PathIndex, Index: 2})
+
+	if a.String() != "[0][1]" || b.String() != "[0][2]" {
+		t.Fatalf("Appended paths share their elements: %v, %v", a, b)
+	} else if a.Equal(b) || !a.Equal(Path{{}, {Kind: PathIndex, Index: 1}}) {
+		t.Fatalf("Equal mismatches for %v and %v", a, b)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Path
+	}{
+		{"", Path{}},
+		{"[0][3]", Path{{Kind: PathIndex, Index: 0}, {Kind: PathIndex, Index: 3}}},
+		{`{"k"}`, Path{{Kind: PathKey, Key: []byte{0x61, 0x6B}}}},
+		{`{"}\"{"}`, Path{{Kind: PathKey, Key: []byte{0x63, 0x7D, 0x22, 0x7B}}}},
+		{"{1}{-1}", Path{{Kind: PathKey, Key: []byte{0x01}}, {Kind: PathKey, Key: []byte{0x20}}}},
+		{"{24}", Path{{Kind: PathKey, Key: []byte{0x18, 0x18}}}},
+		{"{0x1801}", Path{{Kind: PathKey, Key: []byte{0x18, 0x01}}}},
+		{"{0x820102}", Path{{Kind: PathKey, Key: []byte{0x82, 0x01, 0x02}}}},
+		{"[1]#24<<>>[2]", Path{{Kind: PathIndex, Index: 1}, {Kind: PathTag, Tag: 24}, {Kind: PathEmbedded}, {Kind: PathIndex, Index: 2}}},
+	}
+
+	for _, test := range tests {
+		path, err := ParsePath(test.text)
+		if err != nil {
+			t.Fatalf("Parsing %q errored: %v", test.text, err)
+		} else if !path.Equal(test.expected) {
+			t.Fatalf("Parsing %q resulted in %v, expected %v", test.text, path, test.expected)
+		} else if path.String() != test.text {
+			t.Fatalf("Parsed %q is printed as %q", test.text, path.String())
+		}
+	}
+}
+
+func TestParsePathMalformed(t *testing.T) {
+	tests := []string{
+		"0", "[", "[]", "[-1]", "[a]", "[18446744073709551616]", "#", "#a", "<<>", "<>",
+		"{", "{}", `{"k}`, `{"k"`, `{"k"x}`, "{1", "{0x}", "{0x1}", "{a}", "{-}", "{--1}", " [0]",
+	}
+
+	for _, test := range tests {
+		if path, err := ParsePath(test); err == nil {
+			t.Fatalf("Parsing %q did not error: %v", test, path)
+		}
+	}
+}
@@ -119,3 +119,17 @@ func ReadMapPairLength(r io.Reader) (n uint64, err error) {
 func WriteMapPairLength(n uint64, w io.Writer) error {
 	return WriteMajors(Map, n, w)
 }
+
+/*** Tag ***/
+
+// ReadTag expects a tag at the Reader's position and returns its number. The
+// tagged data item follows.
+func ReadTag(r io.Reader) (n uint64, err error) {
+	return ReadExpectMajors(Tag, r)
+}
+
+// WriteTag writes a tag with the given number into the Writer. The tagged data
+// item must be written afterwards.
+func WriteTag(n uint64, w io.Writer) error {
+	return WriteMajors(Tag, n, w)
+}
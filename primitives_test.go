@@ -341,3 +341,37 @@ func TestMapPairLen(t *testing.T) {
 		}
 	}
 }
+
+/*** Tag ***/
+
+func TestTag(t *testing.T) {
+	tests := []struct {
+		data []byte
+		tag  uint64
+	}{
+		{[]byte{0xC1}, 1},
+		{[]byte{0xD8, 0x18}, 24},
+		{[]byte{0xD9, 0xD9, 0xF7}, 55799},
+	}
+
+	for _, test := range tests {
+		// Read
+		buff := &bytes.Buffer{}
+		_, _ = buff.Write(test.data)
+		if n, err := ReadTag(buff); err != nil {
+			t.Fatal(err)
+		} else if n != test.tag {
+			t.Fatalf("Resulting tag %d is not %d", n, test.tag)
+		}
+
+		// Write
+		buff.Reset()
+		if err := WriteTag(test.tag, buff); err != nil {
+			t.Fatal(err)
+		}
+
+		if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Serialized data mismatches: %x != %x", bb, test.data)
+		}
+	}
+}
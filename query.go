@@ -0,0 +1,151 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrPathNotFound is returned if a Path's array index or map key does not
+// exist in the data item.
+var ErrPathNotFound = errors.New("Path not found")
+
+// Query reads the next data item from the Reader and returns the encoding of
+// the data item nested at the Path. Only the data items on the way to the
+// target are read, while preceding siblings are skipped without decoding, and
+// reading stops at the target's end.
+//
+// Map keys match by their value, independent of their encoding. If an index or
+// a key does not exist, ErrPathNotFound is returned, wrapped with the Path up
+// to the missing element. If the Reader is at its end, io.EOF is returned.
+func Query(r io.Reader, path Path) ([]byte, error) {
+	var buff bytes.Buffer
+	if err := queryItem(r, path, &buff); err == io.EOF {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("Query: %w", err)
+	}
+	return buff.Bytes(), nil
+}
+
+// QueryUnmarshal reads the data item nested at the Path, as Query, and
+// unmarshals it into the CborMarshaler. An error is returned if the
+// CborMarshaler does not consume exactly this data item.
+func QueryUnmarshal(r io.Reader, path Path, data CborMarshaler) error {
+	raw, err := Query(r, path)
+	if err != nil {
+		return err
+	}
+
+	br := bytes.NewReader(raw)
+	if err := data.UnmarshalCbor(br); err != nil {
+		return fmt.Errorf("QueryUnmarshal: %w", err)
+	} else if br.Len() > 0 {
+		return fmt.Errorf("QueryUnmarshal: %d bytes were not unmarshaled", br.Len())
+	}
+	return nil
+}
+
+// queryItem follows the Path within the next data item of the Reader and
+// copies the target's encoding into the Writer.
+func queryItem(r io.Reader, path Path, w io.Writer) error {
+	h, err := ReadHead(r)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range path {
+		if h, r, err = queryStep(h, r, e, i); err != nil {
+			return fmt.Errorf("%v: %w", path[:i+1], err)
+		}
+	}
+	return copyItem(h, r, w, len(path))
+}
+
+// queryStep follows a single PathElement from the data item of the already
+// read head and returns the selected data item's head and the Reader to
+// continue with, which differs for an embedded data item.
+func queryStep(h Head, r io.Reader, e PathElement, depth int) (Head, io.Reader, error) {
+	switch e.Kind {
+	case PathIndex:
+		if h.Major != Array {
+			return h, r, fmt.Errorf("Expected array, got %v", h)
+		} else if !h.Indefinite && e.Index >= h.Argument {
+			return h, r, ErrPathNotFound
+		}
+
+		for i := uint64(0); ; i++ {
+			next, err := readNestedHead(r)
+			if err != nil {
+				return h, r, err
+			} else if next.IsBreak() && h.Indefinite {
+				return h, r, ErrPathNotFound
+			} else if i == e.Index {
+				return next, r, nil
+			} else if err := copyItem(next, r, io.Discard, depth+1); err != nil {
+				return h, r, err
+			}
+		}
+
+	case PathKey:
+		if h.Major != Map {
+			return h, r, fmt.Errorf("Expected map, got %v", h)
+		}
+
+		wanted, err := ReadItem(bytes.NewReader(e.Key))
+		if err != nil {
+			return h, r, fmt.Errorf("Illegal key %x: %w", e.Key, err)
+		}
+
+		for i := uint64(0); h.Indefinite || i < h.Argument; i++ {
+			next, err := readNestedHead(r)
+			if err != nil {
+				return h, r, err
+			} else if next.IsBreak() && h.Indefinite {
+				break
+			}
+
+			var rawKey bytes.Buffer
+			if err := copyItem(next, r, &rawKey, depth+1); err != nil {
+				return h, r, err
+			}
+
+			value, err := readNestedHead(r)
+			if err != nil {
+				return h, r, err
+			}
+
+			if key, err := ReadItem(&rawKey); err == nil && sameValue(key, wanted) {
+				return value, r, nil
+			} else if err := copyItem(value, r, io.Discard, depth+1); err != nil {
+				return h, r, err
+			}
+		}
+		return h, r, ErrPathNotFound
+
+	case PathTag:
+		if h.Major != Tag || h.Argument != e.Tag {
+			return h, r, fmt.Errorf("Expected tag(%d), got %v", e.Tag, h)
+		}
+
+		next, err := readNestedHead(r)
+		return next, r, err
+
+	case PathEmbedded:
+		if h.Major != ByteString {
+			return h, r, fmt.Errorf("Expected byte string, got %v", h)
+		}
+
+		var content io.Reader = &stringReader{r: r, n: h.Argument}
+		if h.Indefinite {
+			content = &chunkedStringReader{r: r}
+		}
+
+		next, err := readNestedHead(content)
+		return next, content, err
+
+	default:
+		return h, r, fmt.Errorf("Illegal path element %v", e)
+	}
+}
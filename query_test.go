@@ -0,0 +1,115 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestQuery(t *testing.T) {
+	// [1, {"a": [2, 3], 4: 24(<<[5, 6]>>), [7]: 8}, 1(9)]
+	data := []byte{
+		0x83, 0x01,
+		0xA3, 0x61, 0x61, 0x82, 0x02, 0x03, 0x04, 0xD8, 0x18, 0x43, 0x82, 0x05, 0x06, 0x81, 0x07, 0x08,
+		0xC1, 0x09,
+	}
+
+	tests := []struct {
+		path     string
+		expected []byte
+	}{
+		{"", data},
+		{"[0]", []byte{0x01}},
+		{`[1]{"a"}`, []byte{0x82, 0x02, 0x03}},
+		{`[1]{"a"}[1]`, []byte{0x03}},
+		{"[1]{4}", []byte{0xD8, 0x18, 0x43, 0x82, 0x05, 0x06}},
+		{"[1]{4}#24", []byte{0x43, 0x82, 0x05, 0x06}},
+		{"[1]{4}#24<<>>", []byte{0x82, 0x05, 0x06}},
+		{"[1]{4}#24<<>>[1]", []byte{0x06}},
+		{"[1]{0x8107}", []byte{0x08}},
+		// Keys match by their value
+		{"[1]{0x1804}", []byte{0xD8, 0x18, 0x43, 0x82, 0x05, 0x06}},
+		{"[2]#1", []byte{0x09}},
+	}
+
+	for _, test := range tests {
+		path, err := ParsePath(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if raw, err := Query(bytes.NewReader(data), path); err != nil {
+			t.Fatalf("Querying %s errored: %v", test.path, err)
+		} else if !bytes.Equal(raw, test.expected) {
+			t.Fatalf("Querying %s resulted in %x, expected %x", test.path, raw, test.expected)
+		}
+	}
+}
+
+func TestQueryNotFound(t *testing.T) {
+	data := []byte{0x9F, 0x01, 0xBF, 0x01, 0x02, 0xFF, 0xFF}
+
+	for _, test := range []string{"[2]", "[1]{2}", "[1]{0x1802}"} {
+		path, _ := ParsePath(test)
+		if _, err := Query(bytes.NewReader(data), path); !errors.Is(err, ErrPathNotFound) {
+			t.Fatalf("Querying %s resulted in %v", test, err)
+		}
+	}
+
+	for _, test := range []string{"[0][0]", "[1][0]", "#1", "[0]<<>>", "[1]{1}#0"} {
+		path, _ := ParsePath(test)
+		if _, err := Query(bytes.NewReader(data), path); err == nil || errors.Is(err, ErrPathNotFound) {
+			t.Fatalf("Querying %s resulted in %v", test, err)
+		}
+	}
+}
+
+func TestQueryStops(t *testing.T) {
+	// The query stops at its target, even if the remaining data is truncated.
+	data := []byte{0x84, 0x01, 0x82, 0x02, 0x03, 0x61}
+	r := bytes.NewReader(data)
+
+	if raw, err := Query(r, Path{{Kind: PathIndex, Index: 1}}); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(raw, []byte{0x82, 0x02, 0x03}) {
+		t.Fatalf("Unexpected result %x", raw)
+	} else if r.Len() != 1 {
+		t.Fatalf("Query left %d instead of 1 byte unread", r.Len())
+	}
+
+	if _, err := Query(bytes.NewReader(data), Path{{Kind: PathIndex, Index: 2}}); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if _, err := Query(bytes.NewReader(nil), nil); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestQueryEmbeddedChunked(t *testing.T) {
+	// (_ h'8201', h'02')
+	data := []byte{0x5F, 0x42, 0x82, 0x01, 0x41, 0x02, 0xFF}
+
+	if raw, err := Query(bytes.NewReader(data), Path{{Kind: PathEmbedded}, {Kind: PathIndex, Index: 1}}); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(raw, []byte{0x02}) {
+		t.Fatalf("Unexpected result %x", raw)
+	}
+}
+
+func TestQueryUnmarshal(t *testing.T) {
+	data := []byte{0xA1, 0x61, 0x62, 0x82, 0x01, 0x02}
+	path := Path{{Kind: PathKey, Key: []byte{0x61, 0x62}}}
+
+	var item embeddedTestItem
+	if err := QueryUnmarshal(bytes.NewReader(data), path, &item); err != nil {
+		t.Fatal(err)
+	} else if item != (embeddedTestItem{1, 2}) {
+		t.Fatalf("Unexpected item %v", item)
+	}
+
+	data = []byte{0xA1, 0x61, 0x62, 0x83, 0x01, 0x02, 0x03}
+	if err := QueryUnmarshal(bytes.NewReader(data), path, &item); err == nil {
+		t.Fatalf("Unmarshaling a longer data item did not error")
+	}
+}
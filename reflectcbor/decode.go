@@ -0,0 +1,613 @@
+package reflectcbor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/dtn7/cboring"
+)
+
+// Unmarshal reads one data item from the Reader into the value pointed to by
+// v, which must be a non-nil pointer. The mapping of Marshal is reversed.
+//
+// Decoding into an empty interface results in uint64, int64, []byte, string,
+// bool, float32, float64 or nil for the respective data items, as returned by
+// cboring.Decoder.Token, in []any for arrays and map[any]any for maps, in
+// time.Time for tag 0 and tag 1 and in Tag for other tags. Times are decoded
+// in UTC.
+//
+// Null and undefined result in a nil pointer, interface, slice or map. Pairs of
+// unknown struct keys are ignored or preserved in a field tagged with
+// `cbor:",unknown"`. Fields without a pair keep their value. Missing optional
+// array elements result in nil, and a const=N array element must be N.
+func Unmarshal(v any, r io.Reader) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("Unmarshal: Expected a non-nil pointer, got %T", v)
+	}
+
+	if err := decode(r, rv.Elem(), 0); err != nil {
+		return fmt.Errorf("Unmarshal: %w", err)
+	}
+	return nil
+}
+
+// readHead reads the head of an expected data item.
+func readHead(r io.Reader) (cboring.Head, error) {
+	h, err := cboring.ReadHead(r)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return h, err
+}
+
+// unread returns a Reader starting with the already read head, followed by
+// the Reader's remaining data.
+func unread(h cboring.Head, r io.Reader) io.Reader {
+	var buff bytes.Buffer
+	_ = cboring.WriteHead(h, &buff)
+	return io.MultiReader(&buff, r)
+}
+
+// isNull checks if a head is null or undefined.
+func isNull(h cboring.Head) bool {
+	initial := h.Initial()
+	return initial == cboring.Null || initial == cboring.Undefined
+}
+
+// items calls f for the head of each item of an array or, for each pair's key,
+// of a map. The heads of indefinite-length containers are checked for the
+// break stop code.
+func items(h cboring.Head, r io.Reader, f func(i uint64, h cboring.Head) error) error {
+	if !h.Indefinite && h.Argument > cboring.MaxContainerLength {
+		return fmt.Errorf("%v exceeds the maximum container length", h)
+	}
+
+	for i := uint64(0); h.Indefinite || i < h.Argument; i++ {
+		eh, err := readHead(r)
+		if err != nil {
+			return err
+		} else if h.Indefinite && eh.IsBreak() {
+			return nil
+		} else if err := f(i, eh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decode(r io.Reader, v reflect.Value, depth int) error {
+	h, err := readHead(r)
+	if err != nil {
+		return err
+	}
+	return decodeHead(h, r, v, depth)
+}
+
+// decodeHead decodes the data item of the already read head into v, which must
+// be settable.
+func decodeHead(h cboring.Head, r io.Reader, v reflect.Value, depth int) error {
+	if depth > cboring.MaxNestingDepth {
+		return fmt.Errorf("Exceeding the maximum nesting depth of %d", cboring.MaxNestingDepth)
+	}
+
+	t := v.Type()
+	if k := t.Kind(); k != reflect.Pointer && k != reflect.Interface && reflect.PointerTo(t).Implements(marshalerType) {
+		return v.Addr().Interface().(cboring.CborMarshaler).UnmarshalCbor(unread(h, r))
+	}
+
+	if isNull(h) {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			v.Set(reflect.Zero(t))
+			return nil
+		default:
+			return fmt.Errorf("Cannot decode %v into %v", h, t)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return decodeHead(h, r, v.Elem(), depth)
+
+	case reflect.Interface:
+		// Decode into an existing pointer, as done by encoding/json.
+		if !v.IsNil() && v.Elem().Kind() == reflect.Pointer && !v.Elem().IsNil() {
+			return decodeHead(h, r, v.Elem().Elem(), depth)
+		} else if t.NumMethod() > 0 {
+			return fmt.Errorf("Cannot decode %v into non-empty interface %v", h, t)
+		}
+
+		value, err := decodeAnyHead(h, r, depth)
+		if err != nil {
+			return err
+		} else if value == nil {
+			v.Set(reflect.Zero(t))
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	switch h.Major {
+	case cboring.Tag:
+		return decodeTag(h, r, v, depth)
+
+	case cboring.Array:
+		return decodeArray(h, r, v, depth)
+
+	case cboring.Map:
+		return decodeMap(h, r, v, depth)
+
+	default:
+		token, err := cboring.NewDecoder(unread(h, r)).Token()
+		if err != nil {
+			return err
+		}
+		return assign(token, h, v)
+	}
+}
+
+func decodeTag(h cboring.Head, r io.Reader, v reflect.Value, depth int) error {
+	switch v.Type() {
+	case timeType:
+		value, err := decodeAnyHead(h, r, depth)
+		if err != nil {
+			return err
+		} else if tv, ok := value.(time.Time); ok {
+			v.Set(reflect.ValueOf(tv))
+			return nil
+		}
+		return fmt.Errorf("Cannot decode %v into %v", h, v.Type())
+
+	case tagType:
+		content, err := decodeAny(r, depth+1)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(Tag{Number: h.Argument, Content: content}))
+		return nil
+
+	default:
+		return fmt.Errorf("Cannot decode %v into %v", h, v.Type())
+	}
+}
+
+func decodeArray(h cboring.Head, r io.Reader, v reflect.Value, depth int) error {
+	t := v.Type()
+
+	switch {
+	case t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8:
+		slice := reflect.MakeSlice(t, 0, int(min(h.Argument, 1024)))
+		err := items(h, r, func(i uint64, eh cboring.Head) error {
+			elem := reflect.New(t.Elem()).Elem()
+			if err := decodeHead(eh, r, elem, depth+1); err != nil {
+				return fmt.Errorf("Element %d: %w", i, err)
+			}
+			slice = reflect.Append(slice, elem)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		v.Set(slice)
+		return nil
+
+	case t.Kind() == reflect.Array && t.Elem().Kind() != reflect.Uint8:
+		n := uint64(0)
+		err := items(h, r, func(i uint64, eh cboring.Head) error {
+			if i >= uint64(t.Len()) {
+				return fmt.Errorf("Array exceeds length %d of %v", t.Len(), t)
+			}
+			n++
+			return decodeHead(eh, r, v.Index(int(i)), depth+1)
+		})
+		if err != nil {
+			return err
+		} else if n != uint64(t.Len()) {
+			return fmt.Errorf("Array of length %d mismatches %v", n, t)
+		}
+		return nil
+
+	case t.Kind() == reflect.Struct:
+		si, err := getStructInfo(t)
+		if err != nil {
+			return err
+		} else if !si.asArray {
+			return fmt.Errorf("Cannot decode %v into %v, which is a map", h, t)
+		}
+		return decodeStructArray(si, h, r, v, depth)
+
+	default:
+		return fmt.Errorf("Cannot decode %v into %v", h, t)
+	}
+}
+
+// decodeStructArray reads an array into a struct. Missing optional elements
+// are set to nil and all remaining elements are read into a rest field.
+func decodeStructArray(si *structInfo, h cboring.Head, r io.Reader, v reflect.Value, depth int) error {
+	t := v.Type()
+
+	required, maxLength := uint64(si.required()), uint64(len(si.fields))
+	if len(si.fields) > 0 && si.fields[len(si.fields)-1].rest {
+		maxLength = cboring.MaxContainerLength
+	}
+	if h.Indefinite || h.Argument < required || h.Argument > maxLength {
+		if required == maxLength {
+			return fmt.Errorf("%v: Expected array of length %d, got %v", t, required, h)
+		}
+		return fmt.Errorf("%v: Expected array of length %d to %d, got %v", t, required, maxLength, h)
+	}
+
+	for i := range si.fields {
+		f := &si.fields[i]
+		fv := v.FieldByIndex(f.index)
+
+		var err error
+		switch {
+		case f.optional && uint64(i) >= h.Argument:
+			fv.Set(reflect.Zero(fv.Type()))
+
+		case f.optional:
+			// A present optional element must not be null.
+			fv.Set(reflect.New(fv.Type().Elem()))
+			err = decode(r, fv.Elem(), depth+1)
+
+		case f.rest:
+			slice := reflect.Zero(fv.Type())
+			for j := uint64(i); j < h.Argument; j++ {
+				elem := reflect.New(fv.Type().Elem()).Elem()
+				if err = decode(r, elem, depth+1); err != nil {
+					err = fmt.Errorf("Element %d: %w", j-uint64(i), err)
+					break
+				}
+				slice = reflect.Append(slice, elem)
+			}
+			fv.Set(slice)
+
+		default:
+			err = decodeField(f, r, fv, depth+1)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%v.%s: %w", t, f.name, err)
+		}
+	}
+	return nil
+}
+
+// decodeField reads a struct's field, honoring its raw and const options.
+func decodeField(f *field, r io.Reader, v reflect.Value, depth int) error {
+	switch {
+	case f.raw:
+		raw, err := cboring.ReadRawItem(r)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+		v.SetBytes(raw)
+		return nil
+
+	case f.constant != "":
+		// A blank field cannot be set, thus its value is only compared.
+		value := reflect.New(v.Type()).Elem()
+		if err := decode(r, value, depth); err != nil {
+			return err
+		}
+
+		var n string
+		if value.CanInt() {
+			n = strconv.FormatInt(value.Int(), 10)
+		} else {
+			n = strconv.FormatUint(value.Uint(), 10)
+		}
+		if n != f.constant {
+			return fmt.Errorf("Expected %s, got %s", f.constant, n)
+		}
+		return nil
+
+	default:
+		return decode(r, v, depth)
+	}
+}
+
+func decodeMap(h cboring.Head, r io.Reader, v reflect.Value, depth int) error {
+	t := v.Type()
+
+	switch t.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		}
+
+		seen := make(map[any]bool)
+		return items(h, r, func(i uint64, kh cboring.Head) error {
+			key := reflect.New(t.Key()).Elem()
+			if err := decodeHead(kh, r, key, depth+1); err != nil {
+				return fmt.Errorf("Key of pair %d: %w", i, err)
+			} else if key.Kind() == reflect.Interface && !hashable(key.Interface()) {
+				return fmt.Errorf("Key of pair %d has the unsupported type %T", i, key.Interface())
+			} else if seen[key.Interface()] {
+				return fmt.Errorf("Duplicate key %v of pair %d", key, i)
+			}
+			seen[key.Interface()] = true
+
+			value := reflect.New(t.Elem()).Elem()
+			if err := decode(r, value, depth+1); err != nil {
+				return fmt.Errorf("Value of key %v: %w", key, err)
+			}
+			v.SetMapIndex(key, value)
+			return nil
+		})
+
+	case reflect.Struct:
+		si, err := getStructInfo(t)
+		if err != nil {
+			return err
+		} else if si.asArray {
+			return fmt.Errorf("Cannot decode %v into %v, which is an array", h, t)
+		}
+		return decodeStructMap(si, h, r, v, depth)
+
+	default:
+		return fmt.Errorf("Cannot decode %v into %v", h, t)
+	}
+}
+
+func decodeStructMap(si *structInfo, h cboring.Head, r io.Reader, v reflect.Value, depth int) error {
+	var unknown []cboring.RawPair
+	seen := make(map[string]bool)
+
+	err := items(h, r, func(i uint64, kh cboring.Head) error {
+		rawKey, err := cboring.ReadRawItem(unread(kh, r))
+		if err != nil {
+			return fmt.Errorf("Key of pair %d: %w", i, err)
+		}
+
+		// Keys are identified by their value, independent of their encoding.
+		key, err := decodeAny(bytes.NewReader(rawKey), depth+1)
+		if err != nil {
+			return fmt.Errorf("Key of pair %d: %w", i, err)
+		}
+		id := string(rawKey)
+		switch key.(type) {
+		case uint64, int64, string:
+			id = fmt.Sprintf("%T:%v", key, key)
+		}
+		if seen[id] {
+			return fmt.Errorf("Duplicate key %x of pair %d", rawKey, i)
+		}
+		seen[id] = true
+
+		if f, ok := si.fieldByKey(key); ok {
+			if err := decodeField(f, r, v.FieldByIndex(f.index), depth+1); err != nil {
+				return fmt.Errorf("%v.%s: %w", v.Type(), f.name, err)
+			}
+			return nil
+		} else if si.unknown == nil {
+			return cboring.SkipItem(r)
+		}
+
+		rawValue, err := cboring.ReadRawItem(r)
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return fmt.Errorf("Value of pair %d: %w", i, err)
+		}
+		unknown = append(unknown, cboring.RawPair{Key: rawKey, Value: rawValue})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if si.unknown != nil {
+		v.FieldByIndex(si.unknown).Set(reflect.ValueOf(unknown))
+	}
+	return nil
+}
+
+// assign sets v to a token of a scalar or string data item.
+func assign(token cboring.Token, h cboring.Head, v reflect.Value) error {
+	t := v.Type()
+	mismatch := fmt.Errorf("Cannot decode %v into %v", h, t)
+	overflow := fmt.Errorf("Value %v overflows %v", token, t)
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, ok := token.(bool); ok {
+			v.SetBool(b)
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch tok := token.(type) {
+		case uint64:
+			if tok > math.MaxInt64 {
+				return overflow
+			}
+			n = int64(tok)
+		case int64:
+			n = tok
+		case cboring.NegativeInt:
+			return overflow
+		default:
+			return mismatch
+		}
+
+		if v.OverflowInt(n) {
+			return overflow
+		}
+		v.SetInt(n)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if tok, ok := token.(uint64); !ok {
+			return mismatch
+		} else if v.OverflowUint(tok) {
+			return overflow
+		} else {
+			v.SetUint(tok)
+			return nil
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch tok := token.(type) {
+		case float32:
+			f = float64(tok)
+		case float64:
+			f = tok
+		case uint64:
+			f = float64(tok)
+		case int64:
+			f = float64(tok)
+		default:
+			return mismatch
+		}
+
+		if v.OverflowFloat(f) {
+			return overflow
+		}
+		v.SetFloat(f)
+		return nil
+
+	case reflect.String:
+		if s, ok := token.(string); ok {
+			v.SetString(s)
+			return nil
+		}
+
+	case reflect.Slice:
+		if b, ok := token.([]byte); ok && t.Elem().Kind() == reflect.Uint8 {
+			v.Set(reflect.ValueOf(b).Convert(t))
+			return nil
+		}
+
+	case reflect.Array:
+		if b, ok := token.([]byte); ok && t.Elem().Kind() == reflect.Uint8 {
+			if len(b) != t.Len() {
+				return fmt.Errorf("Byte string of length %d mismatches %v", len(b), t)
+			}
+			reflect.Copy(v, reflect.ValueOf(b))
+			return nil
+		}
+	}
+
+	return mismatch
+}
+
+func decodeAny(r io.Reader, depth int) (any, error) {
+	h, err := readHead(r)
+	if err != nil {
+		return nil, err
+	}
+	return decodeAnyHead(h, r, depth)
+}
+
+// decodeAnyHead decodes the data item of the already read head for an empty
+// interface, as documented for Unmarshal.
+func decodeAnyHead(h cboring.Head, r io.Reader, depth int) (any, error) {
+	if depth > cboring.MaxNestingDepth {
+		return nil, fmt.Errorf("Exceeding the maximum nesting depth of %d", cboring.MaxNestingDepth)
+	}
+
+	switch h.Major {
+	case cboring.Array:
+		var elems []any
+		err := items(h, r, func(i uint64, eh cboring.Head) error {
+			elem, err := decodeAnyHead(eh, r, depth+1)
+			if err != nil {
+				return fmt.Errorf("Element %d: %w", i, err)
+			}
+			elems = append(elems, elem)
+			return nil
+		})
+		if elems == nil && err == nil {
+			elems = []any{}
+		}
+		return elems, err
+
+	case cboring.Map:
+		m := make(map[any]any)
+		err := items(h, r, func(i uint64, kh cboring.Head) error {
+			key, err := decodeAnyHead(kh, r, depth+1)
+			if err != nil {
+				return fmt.Errorf("Key of pair %d: %w", i, err)
+			} else if !hashable(key) {
+				return fmt.Errorf("Key of pair %d has the unsupported type %T", i, key)
+			} else if _, exists := m[key]; exists {
+				return fmt.Errorf("Duplicate key %v of pair %d", key, i)
+			}
+
+			value, err := decodeAny(r, depth+1)
+			if err != nil {
+				return fmt.Errorf("Value of key %v: %w", key, err)
+			}
+			m[key] = value
+			return nil
+		})
+		return m, err
+
+	case cboring.Tag:
+		content, err := decodeAny(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+
+		switch c := content.(type) {
+		case string:
+			if h.Argument == TagDateTime {
+				return time.Parse(time.RFC3339Nano, c)
+			}
+		case uint64, int64, float32, float64:
+			if h.Argument == TagEpochTime {
+				return epochTime(c), nil
+			}
+		}
+		return Tag{Number: h.Argument, Content: content}, nil
+
+	default:
+		return cboring.NewDecoder(unread(h, r)).Token()
+	}
+}
+
+// epochTime converts a tag 1 number into a time.Time.
+func epochTime(n any) time.Time {
+	switch n := n.(type) {
+	case uint64:
+		return time.Unix(int64(n), 0).UTC()
+	case int64:
+		return time.Unix(n, 0).UTC()
+	case float32:
+		return floatTime(float64(n))
+	default:
+		return floatTime(n.(float64))
+	}
+}
+
+func floatTime(f float64) time.Time {
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(math.Round(frac*1e9))).UTC()
+}
+
+// hashable checks if a decoded key can be used within a map[any]any.
+func hashable(key any) bool {
+	switch key.(type) {
+	case nil, uint64, int64, cboring.NegativeInt, string, bool, float32, float64,
+		cboring.UndefinedValue, cboring.SimpleValue, time.Time:
+		return true
+	default:
+		return false
+	}
+}
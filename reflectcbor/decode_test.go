@@ -0,0 +1,167 @@
+package reflectcbor
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dtn7/cboring"
+)
+
+func TestRoundTrip(t *testing.T) {
+	i8 := int8(-2)
+	tests := []any{
+		uint8(23),
+		int32(-1000),
+		float32(1.5),
+		// Floats whose bits would fit into a shorter head
+		float32(0),
+		0.0,
+		math.Copysign(0, -1),
+		float32(math.SmallestNonzeroFloat32),
+		math.SmallestNonzeroFloat64,
+		struct{ F float64 }{0},
+		"text",
+		[]byte{0x01, 0x02},
+		[]string{"a", "b"},
+		[3]int{1, 2, 3},
+		map[int]string{1: "a", -1: "b"},
+		&i8,
+		time.Unix(1600000000, 0).UTC(),
+		time.Unix(1600000000, 500000000).UTC(),
+		arrayTest{A: 1, B: []string{"x"}, C: &i8, D: [2]byte{1, 2}, E: time.Unix(0, 0).UTC()},
+		optionsTest{Data: []byte{0x01}},
+		optionsTest{Data: []byte{0x82, 0x01, 0x02}, A: new(uint64), B: new(string)},
+		restTest{Tail: []string{"a"}},
+		mapTest{A: 5, B: "b", C: true, D: "d", M: marshalerTest{called: true},
+			Unknown: []cboring.RawPair{{Key: []byte{0x18, 0x2A}, Value: []byte{0xF6}}}},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		if err := Marshal(test, &buff); err != nil {
+			t.Fatal(err)
+		}
+
+		v := reflect.New(reflect.TypeOf(test))
+		if err := Unmarshal(v.Interface(), &buff); err != nil {
+			t.Fatalf("Unmarshaling %v errored: %v", test, err)
+		} else if !reflect.DeepEqual(v.Elem().Interface(), test) {
+			t.Fatalf("Unmarshaled value mismatches: %v != %v", v.Elem().Interface(), test)
+		} else if buff.Len() > 0 {
+			t.Fatalf("Unmarshaling %v left %d bytes", test, buff.Len())
+		}
+	}
+}
+
+func TestUnmarshalAny(t *testing.T) {
+	tests := []struct {
+		data  []byte
+		value any
+	}{
+		{[]byte{0x17}, uint64(23)},
+		{[]byte{0x37}, int64(-24)},
+		{[]byte{0xF6}, nil},
+		{[]byte{0xF9, 0x3C, 0x00}, float32(1)},
+		{[]byte{0x5F, 0x41, 0x01, 0x41, 0x02, 0xFF}, []byte{0x01, 0x02}},
+		{[]byte{0x9F, 0x01, 0x80, 0xFF}, []any{uint64(1), []any{}}},
+		{[]byte{0xBF, 0x61, 0x61, 0x20, 0xFF}, map[any]any{"a": int64(-1)}},
+		{[]byte{0xC1, 0x1A, 0x5F, 0x5E, 0x10, 0x00}, time.Unix(1600000000, 0).UTC()},
+		{[]byte{0xC0, 0x74, 0x32, 0x30, 0x32, 0x30, 0x2D, 0x30, 0x39, 0x2D, 0x31, 0x33, 0x54,
+			0x31, 0x32, 0x3A, 0x32, 0x36, 0x3A, 0x34, 0x30, 0x5A},
+			time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)},
+		{[]byte{0xD8, 0x18, 0x41, 0x00}, Tag{Number: 24, Content: []byte{0x00}}},
+	}
+
+	for _, test := range tests {
+		var value any
+		if err := Unmarshal(&value, bytes.NewBuffer(test.data)); err != nil {
+			t.Fatalf("Unmarshaling %x errored: %v", test.data, err)
+		} else if !reflect.DeepEqual(value, test.value) {
+			t.Fatalf("Unmarshaling %x resulted in %#v instead of %#v", test.data, value, test.value)
+		}
+	}
+}
+
+func TestUnmarshalStructMap(t *testing.T) {
+	// {_ "name": true, 1: 23, 3: null, -1: "b"}, with the unknown key 3
+	data := []byte{0xBF, 0x64, 0x6E, 0x61, 0x6D, 0x65, 0xF5, 0x01, 0x17, 0x03, 0xF6, 0x20, 0x61, 0x62, 0xFF}
+
+	var mt mapTest
+	if err := Unmarshal(&mt, bytes.NewBuffer(data)); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := mapTest{A: 23, B: "b", C: true, Unknown: []cboring.RawPair{{Key: []byte{0x03}, Value: []byte{0xF6}}}}
+	if !reflect.DeepEqual(mt, expected) {
+		t.Fatalf("Unmarshaled struct mismatches: %v != %v", mt, expected)
+	}
+}
+
+func TestUnmarshalNull(t *testing.T) {
+	i := 5
+	v := struct {
+		P *int
+		S []int
+	}{&i, []int{1}}
+
+	// {"P": null, "S": undefined}
+	data := []byte{0xA2, 0x61, 0x50, 0xF6, 0x61, 0x53, 0xF7}
+	if err := Unmarshal(&v, bytes.NewBuffer(data)); err != nil {
+		t.Fatal(err)
+	} else if v.P != nil || v.S != nil {
+		t.Fatalf("Null did not reset values: %v", v)
+	}
+}
+
+func TestUnmarshalError(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		target any
+	}{
+		// Overflows
+		{[]byte{0x19, 0x01, 0x00}, new(uint8)},
+		{[]byte{0x38, 0x80}, new(int8)},
+		{[]byte{0x3B, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, new(int64)},
+		// Type mismatches
+		{[]byte{0x61, 0x61}, new(int)},
+		{[]byte{0x01}, new(string)},
+		{[]byte{0xF6}, new(int)},
+		{[]byte{0xC1, 0x01}, new(int)},
+		{[]byte{0x82, 0x01, 0x02}, new([3]int)},
+		{[]byte{0x41, 0x01}, new([2]byte)},
+		{[]byte{0xA0}, new(arrayTest)},
+		{[]byte{0x80}, new(mapTest)},
+		// Array options: a wrong constant, a null optional element, a wrong length
+		{[]byte{0x82, 0x08, 0x01}, new(optionsTest)},
+		{[]byte{0x83, 0x07, 0x01, 0xF6}, new(optionsTest)},
+		{[]byte{0x81, 0x07}, new(optionsTest)},
+		{[]byte{0x85, 0x07, 0x01, 0x00, 0x60, 0x00}, new(optionsTest)},
+		{[]byte{0x80}, new(restTest)},
+		{[]byte{0x9F, 0x20, 0xFF}, new(restTest)},
+		// Duplicate keys
+		{[]byte{0xA2, 0x01, 0x00, 0x01, 0x00}, new(map[int]int)},
+		{[]byte{0xA2, 0x01, 0x00, 0x18, 0x01, 0x00}, new(mapTest)},
+		{[]byte{0xA2, 0x01, 0x00, 0x01, 0x00}, new(any)},
+		// Unhashable key
+		{[]byte{0xA1, 0x41, 0x00, 0x00}, new(any)},
+		{[]byte{0xA1, 0x80, 0x00}, new(map[any]int)},
+		// Truncated
+		{[]byte{0x82, 0x01}, new([]int)},
+		{[]byte{}, new(int)},
+		// Marshaler errors
+		{[]byte{0x61, 0x61}, new(marshalerTest)},
+	}
+
+	for _, test := range tests {
+		if err := Unmarshal(test.target, bytes.NewBuffer(test.data)); err == nil {
+			t.Fatalf("Unmarshaling %x into %T did not error", test.data, test.target)
+		}
+	}
+
+	if err := Unmarshal(0, bytes.NewBuffer([]byte{0x00})); err == nil {
+		t.Fatal("Unmarshaling into a non-pointer did not error")
+	}
+}
@@ -0,0 +1,351 @@
+// Package reflectcbor marshals and unmarshals arbitrary Go values by
+// reflection, based on the cboring package.
+//
+// This package is meant for prototypes, configuration files and tests. The
+// cboring package itself stays free of reflection; for the hot path, either
+// hand-written or generated CborMarshalers should be used, e.g., by
+// cboring-gen. Types implementing cboring.CborMarshaler are always encoded by
+// their own methods.
+package reflectcbor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/dtn7/cboring"
+)
+
+// TagEpochTime is the tag for a numerical date and time in seconds since the
+// epoch, as specified in RFC 8949, section 3.4.2.
+const TagEpochTime uint64 = 1
+
+// TagDateTime is the tag for a standard date and time string, as specified in
+// RFC 8949, section 3.4.1.
+const TagDateTime uint64 = 0
+
+// Tag is a tagged data item, decoded into an empty interface if its tag is
+// not otherwise supported.
+type Tag struct {
+	Number  uint64
+	Content any
+}
+
+var (
+	marshalerType = reflect.TypeOf((*cboring.CborMarshaler)(nil)).Elem()
+	timeType      = reflect.TypeOf(time.Time{})
+	tagType       = reflect.TypeOf(Tag{})
+)
+
+// Marshal writes the CBOR representation of the value v into the Writer.
+//
+// Values are encoded as follows:
+//
+//   - A cboring.CborMarshaler, also implemented by pointer, by its MarshalCbor.
+//   - Booleans, integers, floating-point values and strings by their types.
+//   - A []byte or [N]byte as a byte string, other slices and arrays as arrays.
+//   - A map as a map, sorted by the bytewise order of its encoded keys.
+//   - A time.Time as an epoch-based date and time, tag 1.
+//   - A Tag by its number and content.
+//   - A nil pointer, interface, slice or map as null.
+//   - A struct as a map of its exported fields, sorted as maps.
+//
+// A struct field's key is its name as a text string unless specified by its
+// tag `cbor:"KEY[,OPTION...]"`. An integer KEY results in an integer key,
+// otherwise a text key. The tag `cbor:"-"` ignores a field. The option
+// omitempty omits a field with an empty value, and raw writes a []byte field
+// holding an encoded data item as it is, or null if empty. A
+// []cboring.RawPair field tagged with `cbor:",unknown"` is written as
+// additional pairs, as done by cboring.WriteIntMap.
+//
+// A blank field tagged with `cbor:",asarray"` encodes the struct as an array
+// of its tagged fields, with KEY being each field's position. Within arrays,
+// the options optional, rest and const=N are supported as by cboring-gen:
+// optional marks trailing pointer fields, only written up to the last
+// non-nil one, rest writes all elements of the last slice field as further
+// array elements, and const=N writes N for a blank integer field. Thus, all
+// struct tags of cboring-gen are supported, although Unmarshal neither
+// requires map keys nor resets omitted fields.
+func Marshal(v any, w io.Writer) error {
+	if err := encode(reflect.ValueOf(v), w); err != nil {
+		return fmt.Errorf("Marshal: %w", err)
+	}
+	return nil
+}
+
+func encode(v reflect.Value, w io.Writer) error {
+	if !v.IsValid() {
+		return cboring.WriteNull(w)
+	}
+
+	t := v.Type()
+	if t.Implements(marshalerType) {
+		if t.Kind() == reflect.Pointer && v.IsNil() {
+			return cboring.WriteNull(w)
+		}
+		return v.Interface().(cboring.CborMarshaler).MarshalCbor(w)
+	} else if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(marshalerType) {
+		if !v.CanAddr() {
+			tmp := reflect.New(t)
+			tmp.Elem().Set(v)
+			v = tmp.Elem()
+		}
+		return v.Addr().Interface().(cboring.CborMarshaler).MarshalCbor(w)
+	}
+
+	switch t {
+	case timeType:
+		return encodeTime(v.Interface().(time.Time), w)
+	case tagType:
+		tag := v.Interface().(Tag)
+		if err := cboring.WriteTag(tag.Number, w); err != nil {
+			return err
+		}
+		return encode(reflect.ValueOf(tag.Content), w)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return cboring.WriteBoolean(v.Bool(), w)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cboring.WriteInt(v.Int(), w)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return cboring.WriteUInt(v.Uint(), w)
+
+	case reflect.Float32:
+		return cboring.WriteFloat32(float32(v.Float()), w)
+
+	case reflect.Float64:
+		return cboring.WriteFloat64(v.Float(), w)
+
+	case reflect.String:
+		return cboring.WriteTextString(v.String(), w)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return cboring.WriteNull(w)
+		} else if t.Elem().Kind() == reflect.Uint8 {
+			return cboring.WriteByteString(v.Bytes(), w)
+		}
+		return encodeArray(v, w)
+
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			buf := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(buf), v)
+			return cboring.WriteByteString(buf, w)
+		}
+		return encodeArray(v, w)
+
+	case reflect.Map:
+		if v.IsNil() {
+			return cboring.WriteNull(w)
+		}
+		return encodeMap(v, w)
+
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return cboring.WriteNull(w)
+		}
+		return encode(v.Elem(), w)
+
+	case reflect.Struct:
+		return encodeStruct(v, w)
+
+	default:
+		return fmt.Errorf("Unsupported type %v", t)
+	}
+}
+
+// encodeTime writes a time as tag 1, with an integer for whole seconds and a
+// float64 otherwise.
+func encodeTime(t time.Time, w io.Writer) error {
+	if err := cboring.WriteTag(TagEpochTime, w); err != nil {
+		return err
+	}
+
+	if t.Nanosecond() == 0 {
+		return cboring.WriteInt(t.Unix(), w)
+	}
+	return cboring.WriteFloat64(float64(t.UnixNano())/1e9, w)
+}
+
+func encodeArray(v reflect.Value, w io.Writer) error {
+	if err := cboring.WriteArrayLength(uint64(v.Len()), w); err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := encode(v.Index(i), w); err != nil {
+			return fmt.Errorf("Element %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// encodedPair is a map pair, sorted by its encoded key.
+type encodedPair struct {
+	key   []byte
+	value func(w io.Writer) error
+}
+
+// writePairs writes a map of the pairs, sorted by their encoded keys.
+func writePairs(pairs []encodedPair, w io.Writer) error {
+	sort.SliceStable(pairs, func(i, j int) bool { return bytes.Compare(pairs[i].key, pairs[j].key) < 0 })
+	for i := 1; i < len(pairs); i++ {
+		if bytes.Equal(pairs[i-1].key, pairs[i].key) {
+			return fmt.Errorf("Duplicate key %x", pairs[i].key)
+		}
+	}
+
+	if err := cboring.WriteMapPairLength(uint64(len(pairs)), w); err != nil {
+		return err
+	}
+
+	for _, p := range pairs {
+		if _, err := w.Write(p.key); err != nil {
+			return err
+		} else if err := p.value(w); err != nil {
+			return fmt.Errorf("Value of key %x: %w", p.key, err)
+		}
+	}
+	return nil
+}
+
+func encodeMap(v reflect.Value, w io.Writer) error {
+	pairs := make([]encodedPair, 0, v.Len())
+
+	iter := v.MapRange()
+	for iter.Next() {
+		var key bytes.Buffer
+		if err := encode(iter.Key(), &key); err != nil {
+			return fmt.Errorf("Key %v: %w", iter.Key(), err)
+		}
+
+		value := iter.Value()
+		pairs = append(pairs, encodedPair{key.Bytes(), func(w io.Writer) error {
+			return encode(value, w)
+		}})
+	}
+
+	return writePairs(pairs, w)
+}
+
+func encodeStruct(v reflect.Value, w io.Writer) error {
+	si, err := getStructInfo(v.Type())
+	if err != nil {
+		return err
+	}
+
+	if si.asArray {
+		return encodeStructArray(si, v, w)
+	}
+
+	pairs := make([]encodedPair, 0, len(si.fields))
+	for _, f := range si.fields {
+		fv := v.FieldByIndex(f.index)
+		if f.omitEmpty && isEmpty(fv) {
+			continue
+		}
+
+		pairs = append(pairs, encodedPair{f.key, func(w io.Writer) error {
+			if err := encodeField(f, fv, w); err != nil {
+				return fmt.Errorf("%v.%s: %w", v.Type(), f.name, err)
+			}
+			return nil
+		}})
+	}
+
+	if si.unknown != nil {
+		for _, rp := range v.FieldByIndex(si.unknown).Interface().([]cboring.RawPair) {
+			value := rp.Value
+			pairs = append(pairs, encodedPair{rp.Key, func(w io.Writer) error {
+				_, err := w.Write(value)
+				return err
+			}})
+		}
+	}
+
+	return writePairs(pairs, w)
+}
+
+// encodeStructArray writes a struct as an array. Its length covers the last
+// present optional element or all elements of a rest field.
+func encodeStructArray(si *structInfo, v reflect.Value, w io.Writer) error {
+	n := si.required()
+	for i, f := range si.fields {
+		if fv := v.FieldByIndex(f.index); f.optional && !fv.IsNil() {
+			n = i + 1
+		} else if f.rest {
+			n += fv.Len()
+		}
+	}
+
+	if err := cboring.WriteArrayLength(uint64(n), w); err != nil {
+		return err
+	}
+
+	for i, f := range si.fields {
+		fv := v.FieldByIndex(f.index)
+		switch {
+		case f.optional && i >= n:
+			return nil
+
+		case f.optional && fv.IsNil():
+			return fmt.Errorf("%v.%s: Missing value before a later optional element", v.Type(), f.name)
+
+		case f.rest:
+			for j := 0; j < fv.Len(); j++ {
+				if err := encode(fv.Index(j), w); err != nil {
+					return fmt.Errorf("%v.%s: Element %d: %w", v.Type(), f.name, j, err)
+				}
+			}
+
+		default:
+			if err := encodeField(f, fv, w); err != nil {
+				return fmt.Errorf("%v.%s: %w", v.Type(), f.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// encodeField writes a struct's field, honoring its raw and const options.
+func encodeField(f field, v reflect.Value, w io.Writer) error {
+	switch {
+	case f.raw:
+		if v.Len() == 0 {
+			return cboring.WriteNull(w)
+		}
+		_, err := w.Write(v.Bytes())
+		return err
+
+	case f.constant == "":
+		return encode(v, w)
+
+	case v.CanInt():
+		n, _ := strconv.ParseInt(f.constant, 10, 64)
+		return cboring.WriteInt(n, w)
+
+	default:
+		n, _ := strconv.ParseUint(f.constant, 10, 64)
+		return cboring.WriteUInt(n, w)
+	}
+}
+
+// isEmpty checks if a value is omitted for the omitempty option, which is the
+// case for zero values and empty strings, slices and maps.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
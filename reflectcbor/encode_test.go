@@ -0,0 +1,176 @@
+package reflectcbor
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dtn7/cboring"
+)
+
+// marshalerTest is encoded by its own methods as a text string "m".
+type marshalerTest struct {
+	called bool
+}
+
+func (mt *marshalerTest) MarshalCbor(w io.Writer) error {
+	return cboring.WriteTextString("m", w)
+}
+
+func (mt *marshalerTest) UnmarshalCbor(r io.Reader) error {
+	if s, err := cboring.ReadTextString(r); err != nil {
+		return err
+	} else if s != "m" {
+		return io.ErrUnexpectedEOF
+	}
+	mt.called = true
+	return nil
+}
+
+type arrayTest struct {
+	_ struct{} `cbor:",asarray"`
+
+	A uint16    `cbor:"0"`
+	B []string  `cbor:"1"`
+	C *int8     `cbor:"2"`
+	D [2]byte   `cbor:"3"`
+	E time.Time `cbor:"4"`
+}
+
+// optionsTest uses the array options of cboring-gen.
+type optionsTest struct {
+	_ struct{} `cbor:",asarray"`
+
+	_    uint8   `cbor:"0,const=7"`
+	Data []byte  `cbor:"1,raw"`
+	A    *uint64 `cbor:"2,optional"`
+	B    *string `cbor:"3,optional"`
+}
+
+// restTest has a rest field after a constant.
+type restTest struct {
+	_ struct{} `cbor:",asarray"`
+
+	_    int      `cbor:"0,const=-1"`
+	Tail []string `cbor:"1,rest"`
+}
+
+type mapTest struct {
+	A       uint64            `cbor:"1"`
+	B       string            `cbor:"-1,omitempty"`
+	C       bool              `cbor:"name"`
+	D       any               `cbor:",omitempty"`
+	M       marshalerTest     `cbor:"2"`
+	Ignored int               `cbor:"-"`
+	Unknown []cboring.RawPair `cbor:",unknown"`
+
+	private int
+}
+
+func TestMarshal(t *testing.T) {
+	i8 := int8(-2)
+	tests := []struct {
+		value any
+		data  []byte
+	}{
+		{uint8(23), []byte{0x17}},
+		{-24, []byte{0x37}},
+		{true, []byte{0xF5}},
+		{1.5, []byte{0xFB, 0x3F, 0xF8, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{0.0, []byte{0xFB, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{math.Copysign(0, -1), []byte{0xFB, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{math.SmallestNonzeroFloat64, []byte{0xFB, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}},
+		{float32(0), []byte{0xFA, 0x00, 0x00, 0x00, 0x00}},
+		{struct{ F float64 }{0}, []byte{0xA1, 0x61, 0x46, 0xFB, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		{"a", []byte{0x61, 0x61}},
+		{[]byte{0x01}, []byte{0x41, 0x01}},
+		{[]byte(nil), []byte{0xF6}},
+		{[]int{1, 2}, []byte{0x82, 0x01, 0x02}},
+		{map[string]int{"b": 2, "a": 1}, []byte{0xA2, 0x61, 0x61, 0x01, 0x61, 0x62, 0x02}},
+		{(*int)(nil), []byte{0xF6}},
+		{nil, []byte{0xF6}},
+		{time.Unix(1, 0), []byte{0xC1, 0x01}},
+		{Tag{Number: 24, Content: []byte{0x00}}, []byte{0xD8, 0x18, 0x41, 0x00}},
+		{marshalerTest{}, []byte{0x61, 0x6D}},
+		{&marshalerTest{}, []byte{0x61, 0x6D}},
+		{arrayTest{A: 1, B: []string{"x"}, C: &i8, D: [2]byte{1, 2}, E: time.Unix(0, 0)},
+			[]byte{0x85, 0x01, 0x81, 0x61, 0x78, 0x21, 0x42, 0x01, 0x02, 0xC1, 0x00}},
+		// Optional elements up to the last present one, an empty raw field as null
+		{optionsTest{Data: []byte{0x01}}, []byte{0x82, 0x07, 0x01}},
+		{optionsTest{A: new(uint64)}, []byte{0x83, 0x07, 0xF6, 0x00}},
+		{restTest{Tail: []string{"a", "b"}}, []byte{0x83, 0x20, 0x61, 0x61, 0x61, 0x62}},
+		{restTest{}, []byte{0x81, 0x20}},
+		// Pairs are sorted by their encoded keys: 1, 2, "name", 0x18 0x2A.
+		{mapTest{A: 5, C: true, Ignored: 7, private: 8,
+			Unknown: []cboring.RawPair{{Key: []byte{0x18, 0x2A}, Value: []byte{0xF6}}}},
+			[]byte{0xA4, 0x01, 0x05, 0x02, 0x61, 0x6D, 0x18, 0x2A, 0xF6,
+				0x64, 0x6E, 0x61, 0x6D, 0x65, 0xF5}},
+	}
+
+	for _, test := range tests {
+		var buff bytes.Buffer
+		if err := Marshal(test.value, &buff); err != nil {
+			t.Fatalf("Marshaling %v errored: %v", test.value, err)
+		} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, test.data) {
+			t.Fatalf("Marshaling %v resulted in %x instead of %x", test.value, bb, test.data)
+		}
+	}
+}
+
+func TestMarshalError(t *testing.T) {
+	tests := []any{
+		make(chan int),
+		[]any{func() {}},
+		struct {
+			A int `cbor:"0,foo"`
+		}{},
+		struct {
+			A int `cbor:"0"`
+			B int `cbor:"0"`
+		}{},
+		struct {
+			_ struct{} `cbor:",asarray"`
+			A int      `cbor:"1"`
+		}{},
+		// Misused options
+		struct {
+			A int `cbor:"0,raw"`
+		}{},
+		struct {
+			A *int `cbor:"0,optional"`
+		}{},
+		struct {
+			_ struct{} `cbor:",asarray"`
+			A int      `cbor:"0,optional"`
+		}{},
+		struct {
+			_ struct{} `cbor:",asarray"`
+			A *int     `cbor:"0,optional"`
+			B int      `cbor:"1"`
+		}{},
+		struct {
+			_ struct{} `cbor:",asarray"`
+			A []int    `cbor:"0,rest"`
+			B int      `cbor:"1"`
+		}{},
+		struct {
+			_ struct{} `cbor:",asarray"`
+			_ uint8    `cbor:"0,const=256"`
+		}{},
+		struct {
+			_ struct{} `cbor:",asarray"`
+			A int      `cbor:"0,const=1"`
+		}{},
+		// A nil optional element before a present one
+		optionsTest{B: new(string)},
+	}
+
+	for _, test := range tests {
+		if err := Marshal(test, io.Discard); err == nil {
+			t.Fatalf("Marshaling %T did not error", test)
+		}
+	}
+}
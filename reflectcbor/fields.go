@@ -0,0 +1,251 @@
+package reflectcbor
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dtn7/cboring"
+)
+
+// field is a struct's field to be encoded.
+type field struct {
+	name  string
+	index []int
+	// key is the encoded map key, unused for arrays.
+	key []byte
+	// intKey or textKey is the key's value, depending on isInt.
+	intKey    int64
+	textKey   string
+	isInt     bool
+	omitEmpty bool
+	// raw is set for a []byte holding an encoded data item.
+	raw bool
+	// optional and rest are only supported for trailing array elements.
+	optional bool
+	rest     bool
+	// constant is the decimal value of a blank integer array element.
+	constant string
+}
+
+// structInfo describes how a struct type is encoded.
+type structInfo struct {
+	asArray bool
+	// fields are ordered by their position for arrays or by their encoded key
+	// for maps.
+	fields []field
+	// unknown is the index of a []cboring.RawPair field, or nil.
+	unknown []int
+}
+
+var (
+	structInfoCache sync.Map
+	rawPairsType    = reflect.TypeOf([]cboring.RawPair(nil))
+)
+
+// getStructInfo returns the cached structInfo of a struct type.
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if si, ok := structInfoCache.Load(t); ok {
+		return si.(*structInfo), nil
+	}
+
+	si, err := newStructInfo(t)
+	if err != nil {
+		return nil, err
+	}
+
+	structInfoCache.Store(t, si)
+	return si, nil
+}
+
+// newStructInfo inspects a struct type's fields and their cbor tags, as
+// documented for Marshal.
+func newStructInfo(t reflect.Type) (*structInfo, error) {
+	si := &structInfo{}
+	seen := make(map[string]string)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("cbor")
+		if tag == "-" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		options := make(map[string]bool)
+		var constant string
+		for _, option := range parts[1:] {
+			switch {
+			case option == "asarray", option == "unknown", option == "omitempty", option == "raw",
+				option == "optional", option == "rest":
+				options[option] = true
+			case strings.HasPrefix(option, "const="):
+				constant = strings.TrimPrefix(option, "const=")
+			default:
+				return nil, fmt.Errorf("%v: Field %s has unknown option %q", t, sf.Name, option)
+			}
+		}
+
+		if options["asarray"] {
+			si.asArray = true
+			continue
+		} else if !sf.IsExported() && constant == "" {
+			continue
+		}
+
+		if options["unknown"] {
+			if sf.Type != rawPairsType {
+				return nil, fmt.Errorf("%v: Unknown field %s must be a []cboring.RawPair", t, sf.Name)
+			}
+			si.unknown = sf.Index
+			continue
+		}
+
+		f := field{
+			name:      sf.Name,
+			index:     sf.Index,
+			omitEmpty: options["omitempty"],
+			raw:       options["raw"],
+			optional:  options["optional"],
+			rest:      options["rest"],
+		}
+		if f.raw && (sf.Type.Kind() != reflect.Slice || sf.Type.Elem().Kind() != reflect.Uint8) {
+			return nil, fmt.Errorf("%v: Raw field %s must be a []byte", t, sf.Name)
+		} else if f.optional && sf.Type.Kind() != reflect.Pointer {
+			return nil, fmt.Errorf("%v: Optional field %s must be a pointer", t, sf.Name)
+		} else if f.rest && (sf.Type.Kind() != reflect.Slice || sf.Type.Elem().Kind() == reflect.Uint8) {
+			return nil, fmt.Errorf("%v: Rest field %s must be a slice", t, sf.Name)
+		}
+
+		if constant != "" {
+			n, err := constValue(sf, constant)
+			if err != nil {
+				return nil, fmt.Errorf("%v: %w", t, err)
+			}
+			f.constant = n
+		}
+
+		if !tagged || parts[0] == "" {
+			// Untagged fields or option-only tags, e.g., `cbor:",omitempty"`,
+			// use the field's name as a text key.
+			f.textKey = sf.Name
+		} else if n, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+			f.intKey, f.isInt = n, true
+		} else {
+			f.textKey = parts[0]
+		}
+
+		var buff bytes.Buffer
+		if f.isInt {
+			_ = cboring.WriteInt(f.intKey, &buff)
+		} else {
+			_ = cboring.WriteTextString(f.textKey, &buff)
+		}
+		f.key = buff.Bytes()
+
+		if other, exists := seen[string(f.key)]; exists {
+			return nil, fmt.Errorf("%v: Fields %s and %s share a key", t, other, sf.Name)
+		}
+		seen[string(f.key)] = sf.Name
+
+		si.fields = append(si.fields, f)
+	}
+
+	if si.asArray {
+		return si, si.arrayPositions(t)
+	}
+
+	for _, f := range si.fields {
+		if f.optional || f.rest || f.constant != "" {
+			return nil, fmt.Errorf("%v: Field %s must be an array element for optional, rest or const", t, f.name)
+		}
+	}
+
+	sort.SliceStable(si.fields, func(i, j int) bool {
+		return bytes.Compare(si.fields[i].key, si.fields[j].key) < 0
+	})
+	return si, nil
+}
+
+// arrayPositions orders an array's fields by their positions, which must be
+// integer keys from zero onwards.
+func (si *structInfo) arrayPositions(t reflect.Type) error {
+	if si.unknown != nil {
+		return fmt.Errorf("%v: Unknown pairs are only supported for maps", t)
+	}
+
+	sort.SliceStable(si.fields, func(i, j int) bool { return si.fields[i].intKey < si.fields[j].intKey })
+	optional := false
+	for i, f := range si.fields {
+		switch {
+		case !f.isInt || f.intKey != int64(i):
+			return fmt.Errorf("%v: Field %s has no array position %d", t, f.name, i)
+		case f.omitEmpty:
+			return fmt.Errorf("%v: Field %s within an array cannot be omitted", t, f.name)
+		case optional && !f.optional:
+			return fmt.Errorf("%v: Field %s must be optional, as it follows an optional field", t, f.name)
+		case f.rest && (optional || i != len(si.fields)-1):
+			return fmt.Errorf("%v: Rest field %s must follow all other fields", t, f.name)
+		}
+		optional = optional || f.optional
+	}
+	return nil
+}
+
+// required returns the amount of an array's elements which are neither
+// optional nor rest fields.
+func (si *structInfo) required() (n int) {
+	for _, f := range si.fields {
+		if !f.optional && !f.rest {
+			n++
+		}
+	}
+	return
+}
+
+// constValue checks a const=N option of a blank integer field, returning N in
+// its canonical decimal form.
+func constValue(sf reflect.StructField, constant string) (string, error) {
+	if sf.Name != "_" {
+		return "", fmt.Errorf("Constant field %s must be blank", sf.Name)
+	}
+
+	v := reflect.New(sf.Type).Elem()
+	switch sf.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, err := strconv.ParseInt(constant, 10, 64); err == nil && !v.OverflowInt(n) {
+			return strconv.FormatInt(n, 10), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseUint(constant, 10, 64); err == nil && !v.OverflowUint(n) {
+			return strconv.FormatUint(n, 10), nil
+		}
+	}
+	return "", fmt.Errorf("Constant %q is not a valid %v", constant, sf.Type)
+}
+
+// fieldByKey finds a map field by its key, read as a token.
+func (si *structInfo) fieldByKey(key any) (*field, bool) {
+	for i := range si.fields {
+		f := &si.fields[i]
+		switch k := key.(type) {
+		case uint64:
+			if f.isInt && f.intKey >= 0 && uint64(f.intKey) == k {
+				return f, true
+			}
+		case int64:
+			if f.isInt && f.intKey == k {
+				return f, true
+			}
+		case string:
+			if !f.isInt && f.textKey == k {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
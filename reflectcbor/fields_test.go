@@ -0,0 +1,35 @@
+package reflectcbor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStructInfo(t *testing.T) {
+	si, err := getStructInfo(reflect.TypeOf(mapTest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ordered by encoded keys: 1, 2, -1, "D", "name"
+	var names []string
+	for _, f := range si.fields {
+		names = append(names, f.name)
+	}
+	if expected := []string{"A", "M", "B", "D", "C"}; !reflect.DeepEqual(names, expected) {
+		t.Fatalf("Field order mismatches: %v != %v", names, expected)
+	}
+
+	for key, name := range map[any]string{uint64(1): "A", int64(-1): "B", "name": "C", "D": "D"} {
+		if f, ok := si.fieldByKey(key); !ok || f.name != name {
+			t.Fatalf("Key %v did not resolve to field %s", key, name)
+		}
+	}
+	if _, ok := si.fieldByKey("A"); ok {
+		t.Fatal("Field A was found by its name despite its integer key")
+	}
+
+	if si2, _ := getStructInfo(reflect.TypeOf(mapTest{})); si2 != si {
+		t.Fatal("structInfo was not cached")
+	}
+}
@@ -0,0 +1,150 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+)
+
+// MediaTypeSequence is the media type of a CBOR Sequence, RFC 8742, section 6.
+const MediaTypeSequence = "application/cbor-seq"
+
+// IsSequenceMediaType checks if a media type, e.g., of a Content-Type header,
+// denotes a CBOR Sequence. This is either application/cbor-seq or a media type
+// with the +cbor-seq structured syntax suffix. Parameters are ignored.
+func IsSequenceMediaType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == MediaTypeSequence ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+cbor-seq"))
+}
+
+// SequenceReader reads the data items of a CBOR Sequence, RFC 8742, which are
+// concatenated without any framing.
+//
+// Its methods return io.EOF only if the sequence ends cleanly between two data
+// items and io.ErrUnexpectedEOF if the last data item is truncated. After an
+// error, the SequenceReader returns the same error again.
+type SequenceReader struct {
+	r   io.Reader
+	n   uint64
+	err error
+}
+
+// NewSequenceReader creates a SequenceReader for a Reader. As the data items
+// are read exactly, the Reader might be shared with other consumers, e.g., to
+// continue reading after a sequence of a known length.
+func NewSequenceReader(r io.Reader) *SequenceReader {
+	return &SequenceReader{r: r}
+}
+
+// Count returns the number of data items read so far.
+func (sr *SequenceReader) Count() uint64 {
+	return sr.n
+}
+
+// ReadRaw returns the next data item's encoded bytes.
+func (sr *SequenceReader) ReadRaw() ([]byte, error) {
+	if sr.err != nil {
+		return nil, sr.err
+	}
+
+	data, err := ReadRawItem(sr.r)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		sr.err = err
+	case err != nil:
+		sr.err = fmt.Errorf("SequenceReader: Data item %d: %w", sr.n, err)
+	default:
+		sr.n++
+	}
+	return data, sr.err
+}
+
+// Unmarshal reads the next data item into a CborMarshaler. The data item is
+// read completely before it is unmarshaled, so that a truncated sequence is
+// always reported as io.ErrUnexpectedEOF. An error is returned if the
+// CborMarshaler does not consume exactly this data item.
+func (sr *SequenceReader) Unmarshal(data CborMarshaler) error {
+	n := sr.n
+	raw, err := sr.ReadRaw()
+	if err != nil {
+		return err
+	}
+
+	r := bytes.NewReader(raw)
+	if err := data.UnmarshalCbor(r); err != nil {
+		return fmt.Errorf("SequenceReader: Data item %d: %w", n, err)
+	} else if r.Len() > 0 {
+		return fmt.Errorf("SequenceReader: Data item %d: %d bytes were not unmarshaled", n, r.Len())
+	}
+	return nil
+}
+
+// ReadSequenceOf reads all data items of a CBOR Sequence from the Reader,
+// unmarshals each into a new CborMarshaler, created by the newElem function,
+// and passes it to the f function. Reading stops at the sequence's clean end,
+// which is not reported as an error, or at the first error, e.g., of f.
+func ReadSequenceOf[T CborMarshaler](newElem func() T, r io.Reader, f func(elem T) error) error {
+	sr := NewSequenceReader(r)
+	for {
+		elem := newElem()
+		if err := sr.Unmarshal(elem); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := f(elem); err != nil {
+			return fmt.Errorf("ReadSequenceOf: Data item %d: %w", sr.Count()-1, err)
+		}
+	}
+}
+
+// SequenceWriter writes the data items of a CBOR Sequence, RFC 8742.
+type SequenceWriter struct {
+	w io.Writer
+	n uint64
+}
+
+// NewSequenceWriter creates a SequenceWriter for a Writer.
+func NewSequenceWriter(w io.Writer) *SequenceWriter {
+	return &SequenceWriter{w: w}
+}
+
+// Count returns the number of data items written so far.
+func (sw *SequenceWriter) Count() uint64 {
+	return sw.n
+}
+
+// WriteRaw writes an already encoded data item. The data must be exactly one
+// well-formed data item, as a sequence cannot recover from a malformed one.
+func (sw *SequenceWriter) WriteRaw(data []byte) error {
+	r := bytes.NewReader(data)
+	if err := SkipItem(r); err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("SequenceWriter: Data item %d is truncated", sw.n)
+	} else if err != nil {
+		return fmt.Errorf("SequenceWriter: Data item %d: %w", sw.n, err)
+	} else if r.Len() > 0 {
+		return fmt.Errorf("SequenceWriter: Data item %d is followed by %d bytes", sw.n, r.Len())
+	}
+
+	if _, err := sw.w.Write(data); err != nil {
+		return err
+	}
+	sw.n++
+	return nil
+}
+
+// Marshal writes a CborMarshaler as the next data item.
+func (sw *SequenceWriter) Marshal(data CborMarshaler) error {
+	if err := data.MarshalCbor(sw.w); err != nil {
+		return fmt.Errorf("SequenceWriter: Data item %d: %w", sw.n, err)
+	}
+	sw.n++
+	return nil
+}
@@ -0,0 +1,191 @@
+package cboring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestSequenceReaderReadRaw(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		items    [][]byte
+		expected error
+	}{
+		{[]byte{}, nil, io.EOF},
+		{[]byte{0x01, 0x82, 0x02, 0x03, 0x61, 0x61}, [][]byte{{0x01}, {0x82, 0x02, 0x03}, {0x61, 0x61}}, io.EOF},
+		{[]byte{0x9F, 0x01, 0xFF, 0xF6}, [][]byte{{0x9F, 0x01, 0xFF}, {0xF6}}, io.EOF},
+		// Truncated data items
+		{[]byte{0x01, 0x82, 0x02}, [][]byte{{0x01}}, io.ErrUnexpectedEOF},
+		{[]byte{0x01, 0x19, 0x01}, [][]byte{{0x01}}, io.ErrUnexpectedEOF},
+		{[]byte{0x62, 0x61}, nil, io.ErrUnexpectedEOF},
+		{[]byte{0x9F, 0x01}, nil, io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		sr := NewSequenceReader(bytes.NewReader(test.data))
+
+		var items [][]byte
+		var err error
+		for {
+			var item []byte
+			if item, err = sr.ReadRaw(); err != nil {
+				break
+			}
+			items = append(items, item)
+		}
+
+		if err != test.expected {
+			t.Fatalf("Reading %x ended with %v, expected %v", test.data, err, test.expected)
+		} else if len(items) != len(test.items) || sr.Count() != uint64(len(test.items)) {
+			t.Fatalf("Reading %x resulted in %x, expected %x", test.data, items, test.items)
+		}
+		for i := range items {
+			if !bytes.Equal(items[i], test.items[i]) {
+				t.Fatalf("Reading %x resulted in %x, expected %x", test.data, items, test.items)
+			}
+		}
+
+		if _, again := sr.ReadRaw(); again != err {
+			t.Fatalf("Reading %x again resulted in %v instead of %v", test.data, again, err)
+		}
+	}
+}
+
+func TestSequenceReaderMalformed(t *testing.T) {
+	sr := NewSequenceReader(bytes.NewReader([]byte{0x01, 0xFF, 0x02}))
+	if _, err := sr.ReadRaw(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := sr.ReadRaw()
+	if err == nil || err == io.EOF || err == io.ErrUnexpectedEOF {
+		t.Fatalf("Reading a break stop code resulted in %v", err)
+	}
+}
+
+func TestSequenceReaderUnmarshal(t *testing.T) {
+	tests := []struct {
+		data     []byte
+		items    []embeddedTestItem
+		expected error
+	}{
+		{[]byte{0x82, 0x01, 0x02, 0x82, 0x03, 0x04}, []embeddedTestItem{{1, 2}, {3, 4}}, io.EOF},
+		{[]byte{0x82, 0x01, 0x02, 0x82, 0x03}, []embeddedTestItem{{1, 2}}, io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		sr := NewSequenceReader(bytes.NewReader(test.data))
+
+		var items []embeddedTestItem
+		var err error
+		for {
+			var item embeddedTestItem
+			if err = sr.Unmarshal(&item); err != nil {
+				break
+			}
+			items = append(items, item)
+		}
+
+		if err != test.expected {
+			t.Fatalf("Reading %x ended with %v, expected %v", test.data, err, test.expected)
+		} else if fmt.Sprint(items) != fmt.Sprint(test.items) {
+			t.Fatalf("Reading %x resulted in %v, expected %v", test.data, items, test.items)
+		}
+	}
+
+	// The CborMarshaler must consume the whole data item.
+	sr := NewSequenceReader(bytes.NewReader([]byte{0x83, 0x01, 0x02, 0x03}))
+	if err := sr.Unmarshal(&embeddedTestItem{}); err == nil {
+		t.Fatalf("Unmarshaling a longer data item did not error")
+	}
+}
+
+func TestReadSequenceOf(t *testing.T) {
+	data := []byte{0x82, 0x01, 0x02, 0x82, 0x03, 0x04}
+
+	var items []*embeddedTestItem
+	err := ReadSequenceOf(func() *embeddedTestItem { return &embeddedTestItem{} }, bytes.NewReader(data),
+		func(item *embeddedTestItem) error {
+			items = append(items, item)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(items) != 2 || *items[0] != (embeddedTestItem{1, 2}) || *items[1] != (embeddedTestItem{3, 4}) {
+		t.Fatalf("Unexpected items %v", items)
+	}
+
+	err = ReadSequenceOf(func() *embeddedTestItem { return &embeddedTestItem{} }, bytes.NewReader(data[:5]),
+		func(*embeddedTestItem) error { return nil })
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	stop := errors.New("stop")
+	err = ReadSequenceOf(func() *embeddedTestItem { return &embeddedTestItem{} }, bytes.NewReader(data),
+		func(*embeddedTestItem) error { return stop })
+	if !errors.Is(err, stop) {
+		t.Fatalf("Expected the callback's error, got %v", err)
+	}
+}
+
+func TestSequenceWriter(t *testing.T) {
+	var buff bytes.Buffer
+	sw := NewSequenceWriter(&buff)
+
+	if err := sw.WriteRaw([]byte{0x01}); err != nil {
+		t.Fatal(err)
+	} else if err := sw.Marshal(&embeddedTestItem{2, 3}); err != nil {
+		t.Fatal(err)
+	} else if err := sw.WriteRaw([]byte{0x9F, 0xF6, 0xFF}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, data := range [][]byte{{}, {0x82, 0x01}, {0x01, 0x02}, {0xFF}} {
+		if err := sw.WriteRaw(data); err == nil {
+			t.Fatalf("Writing %x did not error", data)
+		}
+	}
+
+	if expected := []byte{0x01, 0x82, 0x02, 0x03, 0x9F, 0xF6, 0xFF}; !bytes.Equal(buff.Bytes(), expected) {
+		t.Fatalf("Expected %x, got %x", expected, buff.Bytes())
+	} else if sw.Count() != 3 {
+		t.Fatalf("Expected 3 written data items, got %d", sw.Count())
+	}
+
+	// Round trip
+	sr := NewSequenceReader(&buff)
+	for _, expected := range [][]byte{{0x01}, {0x82, 0x02, 0x03}, {0x9F, 0xF6, 0xFF}} {
+		if data, err := sr.ReadRaw(); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data, expected) {
+			t.Fatalf("Expected %x, got %x", expected, data)
+		}
+	}
+	if _, err := sr.ReadRaw(); err != io.EOF {
+		t.Fatalf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestIsSequenceMediaType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"application/cbor-seq", true},
+		{"Application/CBOR-Seq; charset=binary", true},
+		{"application/senml+cbor-seq", true},
+		{"application/cbor", false},
+		{"text/cbor-seq", false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if IsSequenceMediaType(test.contentType) != test.expected {
+			t.Fatalf("%q should be a CBOR Sequence: %t", test.contentType, test.expected)
+		}
+	}
+}
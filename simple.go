@@ -10,6 +10,8 @@ const (
 	simpleFalse byte = 20
 	simpleTrue  byte = 21
 	simpleNull  byte = 22
+
+	simpleUndefined byte = 23
 )
 
 // ReadBoolean reads a bool value from the Reader.
@@ -34,6 +36,8 @@ func ReadBoolean(r io.Reader) (b bool, err error) {
 		b = true
 	case simpleNull:
 		err = FlagNull
+	case simpleUndefined:
+		err = FlagUndefined
 	default:
 		err = fmt.Errorf("ReadBoolean: Unknown additional 0x%x", adds)
 	}
@@ -67,10 +71,23 @@ func ReadFloat32(r io.Reader) (f float32, err error) {
 	return
 }
 
-// WriteFloat32 writes a float32 into the Writer.
+// WriteFloat32 writes a float32 as a single-precision value into the Writer.
+// Its head always has the fixed width of five bytes, as a shorter head would
+// be a simple value, e.g., 0xE0 for the bits of 0.0.
 func WriteFloat32(f float32, w io.Writer) (err error) {
 	fbits := math.Float32bits(f)
-	return WriteMajors(SimpleData, uint64(fbits), w)
+	return WriteHead(Head{Major: SimpleData, Info: 26, Argument: uint64(fbits), Width: 5}, w)
+}
+
+// WriteFloat16 writes a float32 as a half-precision value into the Writer. An
+// error is returned if the value is not exactly representable, as checked by
+// Float32ToHalf.
+func WriteFloat16(f float32, w io.Writer) error {
+	half, ok := Float32ToHalf(f)
+	if !ok {
+		return fmt.Errorf("WriteFloat16: %v is not representable as half-precision value", f)
+	}
+	return WriteHead(Head{Major: SimpleData, Info: 25, Argument: uint64(half), Width: 3}, w)
 }
 
 // ReadFloat64 reads a float64 value from the Reader.
@@ -84,13 +101,77 @@ func ReadFloat64(r io.Reader) (f float64, err error) {
 	return
 }
 
-// WriteFloat64 writes a float64 into the Writer.
+// WriteFloat64 writes a float64 as a double-precision value into the Writer.
+// Its head always has the fixed width of nine bytes, as for WriteFloat32.
 func WriteFloat64(f float64, w io.Writer) (err error) {
 	fbits := math.Float64bits(f)
-	return WriteMajors(SimpleData, fbits, w)
+	return WriteHead(Head{Major: SimpleData, Info: 27, Argument: fbits, Width: 9}, w)
 }
 
 // WriteNull writes a null into the Writer.
 func WriteNull(w io.Writer) (err error) {
 	return WriteMajors(SimpleData, uint64(simpleNull), w)
 }
+
+// halfToFloat32 converts the bits of an IEEE 754 half-precision value into a
+// float32, which represents every such value exactly.
+func halfToFloat32(h uint16) float32 {
+	sign := uint32(h&0x8000) << 16
+	exp := uint32(h>>10) & 0x1F
+	frac := uint32(h & 0x03FF)
+
+	switch {
+	case exp == 0x1F:
+		// Infinity or NaN
+		return math.Float32frombits(sign | 0x7F800000 | frac<<13)
+	case exp == 0 && frac == 0:
+		return math.Float32frombits(sign)
+	case exp == 0:
+		// Subnormal values are normalized for float32
+		exp = 127 - 15 + 1
+		for frac&0x0400 == 0 {
+			frac <<= 1
+			exp--
+		}
+		frac &= 0x03FF
+		return math.Float32frombits(sign | exp<<23 | frac<<13)
+	default:
+		return math.Float32frombits(sign | (exp+127-15)<<23 | frac<<13)
+	}
+}
+
+// Float32ToHalf converts a float32 into the bits of an IEEE 754 half-precision
+// value, if it is exactly representable. This includes infinity and NaNs whose
+// payload fits into half-precision.
+func Float32ToHalf(f float32) (uint16, bool) {
+	bits := math.Float32bits(f)
+	sign := uint16(bits>>16) & 0x8000
+	exp := int(bits>>23&0xFF) - 127
+	frac := bits & 0x7FFFFF
+
+	switch {
+	case exp == 128:
+		// Infinity or NaN
+		if frac&0x1FFF != 0 {
+			return 0, false
+		}
+		return sign | 0x7C00 | uint16(frac>>13), true
+	case bits&0x7FFFFFFF == 0:
+		return sign, true
+	case exp >= -14 && exp <= 15:
+		if frac&0x1FFF != 0 {
+			return 0, false
+		}
+		return sign | uint16(exp+15)<<10 | uint16(frac>>13), true
+	case exp >= -24 && exp < -14:
+		// Subnormal half-precision values have a precision of 2^-24.
+		significand := frac | 0x800000
+		shift := -1 - exp
+		if significand&(1<<shift-1) != 0 {
+			return 0, false
+		}
+		return sign | uint16(significand>>shift), true
+	default:
+		return 0, false
+	}
+}
@@ -2,6 +2,7 @@ package cboring
 
 import (
 	"bytes"
+	"math"
 	"reflect"
 	"testing"
 )
@@ -44,6 +45,10 @@ func TestFloat32(t *testing.T) {
 	}{
 		{[]byte{0xfa, 0x47, 0xc3, 0x50, 0x00}, 100000.0},
 		{[]byte{0xfa, 0x7f, 0x7f, 0xff, 0xff}, 3.4028234663852886e+38},
+		// Bit patterns fitting into a shorter head
+		{[]byte{0xfa, 0x00, 0x00, 0x00, 0x00}, 0.0},
+		{[]byte{0xfa, 0x80, 0x00, 0x00, 0x00}, float32(math.Copysign(0, -1))},
+		{[]byte{0xfa, 0x00, 0x00, 0x00, 0x01}, math.SmallestNonzeroFloat32},
 	}
 
 	for _, test := range tests {
@@ -76,6 +81,10 @@ func TestFloat64(t *testing.T) {
 		{[]byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}, 1.1},
 		{[]byte{0xfb, 0x7e, 0x37, 0xe4, 0x3c, 0x88, 0x00, 0x75, 0x9c}, 1.0e+300},
 		{[]byte{0xfb, 0xc0, 0x10, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66}, -4.1},
+		// Bit patterns fitting into a shorter head
+		{[]byte{0xfb, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, 0.0},
+		{[]byte{0xfb, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}, math.Copysign(0, -1)},
+		{[]byte{0xfb, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}, math.SmallestNonzeroFloat64},
 	}
 
 	for _, test := range tests {
@@ -99,3 +108,56 @@ func TestFloat64(t *testing.T) {
 		}
 	}
 }
+
+func TestFloat32ToHalf(t *testing.T) {
+	tests := []struct {
+		f        float32
+		half     uint16
+		expected bool
+	}{
+		{0, 0x0000, true},
+		{float32(math.Copysign(0, -1)), 0x8000, true},
+		{1, 0x3C00, true},
+		{1.5, 0x3E00, true},
+		{-2, 0xC000, true},
+		{65504, 0x7BFF, true},
+		{65505, 0, false},
+		{100000, 0, false},
+		{0.1, 0, false},
+		{float32(math.Inf(1)), 0x7C00, true},
+		{float32(math.Inf(-1)), 0xFC00, true},
+		{math.Float32frombits(0x7FC00000), 0x7E00, true},
+		{math.Float32frombits(0x7F800001), 0, false},
+		// The smallest normal and subnormal half-precision values
+		{6.103515625e-05, 0x0400, true},
+		{5.960464477539063e-08, 0x0001, true},
+		{5.960464477539063e-08 * 3, 0x0003, true},
+		{5.960464477539063e-08 * 1.5, 0, false},
+		{5.960464477539063e-08 / 2, 0, false},
+		{1 + 1.0/2048, 0, false},
+	}
+
+	for _, test := range tests {
+		half, ok := Float32ToHalf(test.f)
+		if ok != test.expected || half != test.half {
+			t.Fatalf("Converting %v resulted in %04x, %t; expected %04x, %t", test.f, half, ok, test.half, test.expected)
+		}
+
+		if back := halfToFloat32(half); ok && math.Float32bits(back) != math.Float32bits(test.f) {
+			t.Fatalf("Converting %04x back resulted in %v instead of %v", half, back, test.f)
+		}
+	}
+}
+
+func TestFloat16(t *testing.T) {
+	var buff bytes.Buffer
+	if err := WriteFloat16(1.5, &buff); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(buff.Bytes(), []byte{0xF9, 0x3E, 0x00}) {
+		t.Fatalf("Serialized data mismatches: %x", buff.Bytes())
+	}
+
+	if err := WriteFloat16(0.1, &buff); err == nil {
+		t.Fatalf("Writing an unrepresentable value did not error")
+	}
+}
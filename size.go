@@ -0,0 +1,139 @@
+package cboring
+
+// SizedMarshaler is an optional interface for a CborMarshaler, which is able to
+// report the length of its encoded CBOR representation without marshaling it.
+type SizedMarshaler interface {
+	CborMarshaler
+
+	// CborSize returns the amount of bytes MarshalCbor would write.
+	CborSize() uint64
+}
+
+// Size returns the length of a CborMarshaler's CBOR representation. If the
+// CborMarshaler is also a SizedMarshaler, its CborSize method is used.
+// Otherwise, it is marshaled into a counting Writer, which discards the data.
+func Size(data CborMarshaler) (uint64, error) {
+	if sm, ok := data.(SizedMarshaler); ok {
+		return sm.CborSize(), nil
+	}
+
+	var cw countWriter
+	if err := data.MarshalCbor(&cw); err != nil {
+		return 0, err
+	}
+	return cw.n, nil
+}
+
+// countWriter is an io.Writer which only counts the written bytes.
+type countWriter struct {
+	n uint64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	cw.n += uint64(len(p))
+	return len(p), nil
+}
+
+// HeadSize returns the length of a (major) type definition written by
+// WriteMajors for the argument n.
+func HeadSize(n uint64) uint64 {
+	switch {
+	case n < 24:
+		return 1
+	case n < 1<<8:
+		return 2
+	case n < 1<<16:
+		return 3
+	case n < 1<<32:
+		return 5
+	default:
+		return 9
+	}
+}
+
+// UIntSize returns the length of an unsigned integer written by WriteUInt.
+func UIntSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// NIntSize returns the length of a negative integer written by WriteNInt.
+func NIntSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// IntSize returns the length of an integer written by WriteInt.
+func IntSize(n int64) uint64 {
+	if n < 0 {
+		return NIntSize(uint64(^n))
+	}
+	return UIntSize(uint64(n))
+}
+
+// ByteStringLenSize returns the length of a byte string's type definition
+// written by WriteByteStringLen.
+func ByteStringLenSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// ByteStringSize returns the length of a byte string written by
+// WriteByteString.
+func ByteStringSize(data []byte) uint64 {
+	return ByteStringLenSize(uint64(len(data))) + uint64(len(data))
+}
+
+// ByteStringFromSize returns the length of a byte string of length n written
+// by WriteByteStringFrom.
+func ByteStringFromSize(n uint64) uint64 {
+	return ByteStringLenSize(n) + n
+}
+
+// TextStringLenSize returns the length of a text string's type definition
+// written by WriteTextStringLen.
+func TextStringLenSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// TextStringSize returns the length of a text string written by
+// WriteTextString.
+func TextStringSize(data string) uint64 {
+	return TextStringLenSize(uint64(len(data))) + uint64(len(data))
+}
+
+// ArrayLengthSize returns the length of an array's type definition written by
+// WriteArrayLength.
+func ArrayLengthSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// MapPairLengthSize returns the length of a map's type definition written by
+// WriteMapPairLength.
+func MapPairLengthSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// TagSize returns the length of a tag written by WriteTag.
+func TagSize(n uint64) uint64 {
+	return HeadSize(n)
+}
+
+// BooleanSize returns the length of a bool written by WriteBoolean.
+func BooleanSize() uint64 {
+	return 1
+}
+
+// NullSize returns the length of a null written by WriteNull.
+func NullSize() uint64 {
+	return 1
+}
+
+// Float32Size returns the length of a float32 written by WriteFloat32, which
+// is always five bytes.
+func Float32Size(_ float32) uint64 {
+	return 5
+}
+
+// Float64Size returns the length of a float64 written by WriteFloat64, which
+// is always nine bytes.
+func Float64Size(_ float64) uint64 {
+	return 9
+}
@@ -0,0 +1,91 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestSizeFunctions(t *testing.T) {
+	tests := []struct {
+		size  uint64
+		write func(w io.Writer) error
+	}{
+		{UIntSize(0), func(w io.Writer) error { return WriteUInt(0, w) }},
+		{UIntSize(23), func(w io.Writer) error { return WriteUInt(23, w) }},
+		{UIntSize(24), func(w io.Writer) error { return WriteUInt(24, w) }},
+		{UIntSize(1 << 8), func(w io.Writer) error { return WriteUInt(1<<8, w) }},
+		{UIntSize(1 << 16), func(w io.Writer) error { return WriteUInt(1<<16, w) }},
+		{UIntSize(1 << 32), func(w io.Writer) error { return WriteUInt(1<<32, w) }},
+		{UIntSize(math.MaxUint64), func(w io.Writer) error { return WriteUInt(math.MaxUint64, w) }},
+		{NIntSize(42), func(w io.Writer) error { return WriteNInt(42, w) }},
+		{IntSize(-25), func(w io.Writer) error { return WriteInt(-25, w) }},
+		{IntSize(math.MinInt64), func(w io.Writer) error { return WriteInt(math.MinInt64, w) }},
+		{IntSize(1000), func(w io.Writer) error { return WriteInt(1000, w) }},
+		{ByteStringSize(nil), func(w io.Writer) error { return WriteByteString(nil, w) }},
+		{ByteStringSize(make([]byte, 300)), func(w io.Writer) error { return WriteByteString(make([]byte, 300), w) }},
+		{ByteStringFromSize(30), func(w io.Writer) error {
+			return WriteByteStringFrom(30, bytes.NewReader(make([]byte, 30)), w)
+		}},
+		{TextStringSize("IETF"), func(w io.Writer) error { return WriteTextString("IETF", w) }},
+		{ArrayLengthSize(25), func(w io.Writer) error { return WriteArrayLength(25, w) }},
+		{MapPairLengthSize(70000), func(w io.Writer) error { return WriteMapPairLength(70000, w) }},
+		{BooleanSize(), func(w io.Writer) error { return WriteBoolean(true, w) }},
+		{NullSize(), func(w io.Writer) error { return WriteNull(w) }},
+		{Float32Size(100000.0), func(w io.Writer) error { return WriteFloat32(100000.0, w) }},
+		{Float64Size(-4.1), func(w io.Writer) error { return WriteFloat64(-4.1, w) }},
+	}
+
+	for i, test := range tests {
+		var buff bytes.Buffer
+		if err := test.write(&buff); err != nil {
+			t.Fatal(err)
+		} else if uint64(buff.Len()) != test.size {
+			t.Fatalf("Test %d: size %d mismatches written %d bytes", i, test.size, buff.Len())
+		}
+	}
+}
+
+type sizeTestArray []uint64
+
+func (sta *sizeTestArray) MarshalCbor(w io.Writer) error {
+	if err := WriteArrayLength(uint64(len(*sta)), w); err != nil {
+		return err
+	}
+	for _, n := range *sta {
+		if err := WriteUInt(n, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (sta *sizeTestArray) UnmarshalCbor(r io.Reader) error {
+	return nil
+}
+
+type sizedTestArray struct {
+	sizeTestArray
+}
+
+func (sta *sizedTestArray) CborSize() uint64 {
+	// Deliberately wrong to check that CborSize is preferred.
+	return 0
+}
+
+func TestSize(t *testing.T) {
+	sta := sizeTestArray{1, 2, 1000}
+	if size, err := Size(&sta); err != nil {
+		t.Fatal(err)
+	} else if size != 6 {
+		t.Fatalf("Size is %d instead of 6", size)
+	}
+
+	sized := sizedTestArray{sta}
+	if size, err := Size(&sized); err != nil {
+		t.Fatal(err)
+	} else if size != 0 {
+		t.Fatalf("Size is %d, CborSize was not used", size)
+	}
+}
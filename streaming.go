@@ -0,0 +1,157 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// WriteByteStringFrom writes a byte string of length n into the Writer, whose
+// content is copied from the src Reader. Thus, the byte string does not need to
+// be held in memory at once.
+func WriteByteStringFrom(n uint64, src io.Reader, w io.Writer) error {
+	if n > math.MaxInt64 {
+		return fmt.Errorf("WriteByteStringFrom: Length %d exceeds max int64", n)
+	}
+
+	if err := WriteByteStringLen(n, w); err != nil {
+		return err
+	}
+
+	if cn, err := io.CopyN(w, src, int64(n)); err == io.EOF {
+		return fmt.Errorf("WriteByteStringFrom: Copied %d instead of %d bytes", cn, n)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteByteStringChunked writes an indefinite-length byte string into the
+// Writer. Its content is read from the src Reader until io.EOF and is split
+// into chunks of up to chunkSize bytes. This allows streaming data of an
+// unknown length.
+func WriteByteStringChunked(src io.Reader, chunkSize int, w io.Writer) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("WriteByteStringChunked: Illegal chunk size %d", chunkSize)
+	}
+
+	if _, err := w.Write([]byte{IndefiniteByteString}); err != nil {
+		return err
+	}
+
+	buff := make([]byte, chunkSize)
+	for {
+		n, rerr := io.ReadFull(src, buff)
+		if n > 0 {
+			if err := WriteByteString(buff[:n], w); err != nil {
+				return err
+			}
+		}
+
+		if rerr == io.EOF || rerr == io.ErrUnexpectedEOF {
+			break
+		} else if rerr != nil {
+			return rerr
+		}
+	}
+
+	_, err := w.Write([]byte{BreakCode})
+	return err
+}
+
+// ReadByteStringReader expects a byte string at the Reader's position and
+// returns a Reader for its content. Both definite-length and indefinite-length
+// byte strings are supported. The returned Reader reports io.EOF at the end of
+// the byte string and io.ErrUnexpectedEOF if the underlying data was truncated.
+//
+// The returned Reader must be read until io.EOF before the next item can be
+// read from r.
+func ReadByteStringReader(r io.Reader) (io.Reader, error) {
+	h, err := ReadHead(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case nullFlag(h) != nil:
+		return nil, nullFlag(h)
+
+	case h.Major != ByteString:
+		return nil, fmt.Errorf("ReadByteStringReader: Wrong Major Type: 0x%x instead of 0x%x",
+			h.Major, ByteString)
+
+	case h.Indefinite:
+		return &chunkedStringReader{r: r}, nil
+
+	default:
+		return &stringReader{r: r, n: h.Argument}, nil
+	}
+}
+
+// stringReader reads the remaining n bytes of a definite-length string.
+type stringReader struct {
+	r io.Reader
+	n uint64
+}
+
+func (sr *stringReader) Read(p []byte) (n int, err error) {
+	if sr.n == 0 {
+		return 0, io.EOF
+	}
+
+	if uint64(len(p)) > sr.n {
+		p = p[:sr.n]
+	}
+
+	n, err = sr.r.Read(p)
+	sr.n -= uint64(n)
+
+	if err == io.EOF {
+		if sr.n > 0 {
+			err = io.ErrUnexpectedEOF
+		} else {
+			err = nil
+		}
+	}
+	return
+}
+
+// chunkedStringReader reads the chunks of an indefinite-length byte string
+// until its break stop code.
+type chunkedStringReader struct {
+	r     io.Reader
+	chunk stringReader
+	done  bool
+}
+
+func (cr *chunkedStringReader) nextChunk() error {
+	h, err := ReadHead(cr.r)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+
+	if h.IsBreak() {
+		cr.done = true
+		return nil
+	} else if h.Major != ByteString || h.Indefinite {
+		return fmt.Errorf("ReadByteStringReader: Illegal chunk %v in indefinite-length byte string", h)
+	}
+
+	cr.chunk = stringReader{r: cr.r, n: h.Argument}
+	return nil
+}
+
+func (cr *chunkedStringReader) Read(p []byte) (n int, err error) {
+	for cr.chunk.n == 0 {
+		if cr.done {
+			return 0, io.EOF
+		}
+		if err = cr.nextChunk(); err != nil {
+			return
+		}
+	}
+
+	return cr.chunk.Read(p)
+}
@@ -0,0 +1,105 @@
+package cboring
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestByteStringFrom(t *testing.T) {
+	tests := [][]byte{
+		{},
+		{0x01, 0x02, 0x03, 0x04},
+		bytes.Repeat([]byte{0xAA}, 1024),
+	}
+
+	for _, test := range tests {
+		buff := &bytes.Buffer{}
+		if err := WriteByteStringFrom(uint64(len(test)), bytes.NewReader(test), buff); err != nil {
+			t.Fatal(err)
+		}
+
+		var expected bytes.Buffer
+		if err := WriteByteString(test, &expected); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(buff.Bytes(), expected.Bytes()) {
+			t.Fatalf("Serialized data mismatches: %x != %x", buff.Bytes(), expected.Bytes())
+		}
+
+		sr, err := ReadByteStringReader(buff)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, err := io.ReadAll(sr); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(data, test) {
+			t.Fatalf("Deserialized data mismatches: %x != %x", data, test)
+		}
+	}
+}
+
+func TestWriteByteStringFromShort(t *testing.T) {
+	if err := WriteByteStringFrom(4, bytes.NewReader([]byte{0x01}), io.Discard); err == nil {
+		t.Fatal("Short source did not error")
+	}
+}
+
+func TestByteStringChunked(t *testing.T) {
+	data := []byte{0x01, 0x02, 0x03, 0x04, 0x05}
+	cbor := []byte{0x5F, 0x42, 0x01, 0x02, 0x42, 0x03, 0x04, 0x41, 0x05, 0xFF}
+
+	buff := &bytes.Buffer{}
+	if err := WriteByteStringChunked(bytes.NewReader(data), 2, buff); err != nil {
+		t.Fatal(err)
+	} else if bb := buff.Bytes(); !reflect.DeepEqual(bb, cbor) {
+		t.Fatalf("Serialized data mismatches: %x != %x", bb, cbor)
+	}
+
+	// Append another item to ensure the Reader stops at the break stop code.
+	buff.WriteByte(0x07)
+
+	sr, err := ReadByteStringReader(buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rdata, err := io.ReadAll(sr); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(rdata, data) {
+		t.Fatalf("Deserialized data mismatches: %x != %x", rdata, data)
+	}
+
+	if n, err := ReadUInt(buff); err != nil {
+		t.Fatal(err)
+	} else if n != 7 {
+		t.Fatalf("Following item is %d instead of 7", n)
+	}
+}
+
+func TestReadByteStringReaderError(t *testing.T) {
+	tests := [][]byte{
+		// Truncated definite-length byte string
+		{0x44, 0x01, 0x02},
+		// Truncated indefinite-length byte string
+		{0x5F, 0x42, 0x01, 0x02},
+		{0x5F, 0x42, 0x01},
+		// Text string chunk within a byte string
+		{0x5F, 0x61, 0x61, 0xFF},
+		// Nested indefinite-length chunk
+		{0x5F, 0x5F, 0xFF, 0xFF},
+	}
+
+	for _, test := range tests {
+		sr, err := ReadByteStringReader(bytes.NewBuffer(test))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadAll(sr); err == nil {
+			t.Fatalf("Illegal input %x did not error", test)
+		}
+	}
+
+	if _, err := ReadByteStringReader(bytes.NewBuffer([]byte{0x61, 0x61})); err == nil {
+		t.Fatal("Text string did not error")
+	}
+}
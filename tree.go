@@ -0,0 +1,157 @@
+package cboring
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// Item is a decoded data item together with its nested data items, e.g., to
+// inspect data of an unknown structure.
+//
+// An Item keeps its whole Head, not only its Major Type, as validating against
+// a schema depends on the encoding itself. E.g., CDDL's float16 and float32
+// differ by the additional information only, and the Width locates nested data
+// items within an embedding byte string.
+type Item struct {
+	// Head is the data item's head, e.g., with its Major Type or if it has an
+	// indefinite length. For an indefinite-length string, this is the head
+	// starting the string.
+	Head Head
+	// Value is the Token of a scalar or string, as returned by Decoder.Token,
+	// or the tag number as an uint64 for a tag. It is nil for arrays and maps.
+	Value Token
+	// Items are an array's elements, a map's keys and values in alternation,
+	// or a tag's single data item.
+	Items []*Item
+	// Offset is the data item's position relative to ReadItem's start.
+	Offset int64
+}
+
+// Major returns the data item's Major Type.
+func (item *Item) Major() MajorType {
+	return item.Head.Major
+}
+
+// ReadItem reads the next data item from the Reader, including its nested
+// data items, into a tree of Items. If the Reader is at its end, io.EOF is
+// returned. Nesting is limited by MaxNestingDepth.
+func ReadItem(r io.Reader) (*Item, error) {
+	pr := &peekReader{r: r}
+	h, err := ReadHead(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	return readItem(h, 0, pr, 0)
+}
+
+// readNestedItem reads a data item within another one, where io.EOF is
+// unexpected.
+func readNestedItem(pr *peekReader, depth int) (*Item, error) {
+	offset := pr.offset
+	h, err := readNestedHead(pr)
+	if err != nil {
+		return nil, err
+	}
+	return readItem(h, offset, pr, depth)
+}
+
+// readItem reads the remainder of a data item after its head, which started at
+// the offset.
+func readItem(h Head, offset int64, pr *peekReader, depth int) (item *Item, err error) {
+	if depth > MaxNestingDepth {
+		return nil, fmt.Errorf("ReadItem: Exceeding the maximum nesting depth of %d", MaxNestingDepth)
+	}
+
+	item = &Item{Head: h, Offset: offset}
+
+	switch h.Major {
+	case UInt:
+		item.Value = h.Argument
+
+	case NInt:
+		if h.Argument > math.MaxInt64 {
+			item.Value = NegativeInt(h.Argument)
+		} else {
+			item.Value = ^int64(h.Argument)
+		}
+
+	case ByteString, TextString:
+		data, dataErr := readStringContent(h, pr)
+		if dataErr == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		} else if dataErr != nil {
+			return nil, dataErr
+		}
+
+		if h.Major == ByteString {
+			item.Value = data
+		} else {
+			item.Value = string(data)
+		}
+
+	case Array, Map:
+		err = readItems(item, pr, depth)
+
+	case Tag:
+		item.Value = h.Argument
+
+		var child *Item
+		if child, err = readNestedItem(pr, depth+1); err == nil {
+			item.Items = []*Item{child}
+		}
+
+	default:
+		item.Value, err = simpleToken(h)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// readItems reads the nested data items of an array or map.
+func readItems(item *Item, pr *peekReader, depth int) error {
+	h := item.Head
+	if !h.Indefinite {
+		n := h.Argument
+		if h.Major == Map {
+			if n > math.MaxUint64/2 {
+				return fmt.Errorf("ReadItem: Map of %d pairs is too large", n)
+			}
+			n *= 2
+		}
+
+		for i := uint64(0); i < n; i++ {
+			child, err := readNestedItem(pr, depth+1)
+			if err != nil {
+				return err
+			}
+			item.Items = append(item.Items, child)
+		}
+		return nil
+	}
+
+	for {
+		offset := pr.offset
+		ch, err := readNestedHead(pr)
+		if err != nil {
+			return err
+		} else if ch.IsBreak() {
+			break
+		}
+
+		child, err := readItem(ch, offset, pr, depth+1)
+		if err != nil {
+			return err
+		}
+		item.Items = append(item.Items, child)
+	}
+
+	if h.Major == Map && len(item.Items)%2 != 0 {
+		return fmt.Errorf("ReadItem: Map with an odd number of %d data items", len(item.Items))
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cboring
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestReadItem(t *testing.T) {
+	// [1, {_ "a": -2}, 24(h'00'), [_ true], null]
+	data := []byte{0x85, 0x01, 0xBF, 0x61, 0x61, 0x21, 0xFF, 0xD8, 0x18, 0x41, 0x00, 0x9F, 0xF5, 0xFF, 0xF6}
+
+	expected := []string{
+		"0 array(5) <nil>",
+		"1 unsigned(1) 1",
+		"2 map(*) <nil>",
+		"3 text(1) a",
+		"5 negative(-1-1) -2",
+		"7 tag(24) 24",
+		"9 bytes(1) [0]",
+		"11 array(*) <nil>",
+		"12 simple(21) true",
+		"14 simple(22) <nil>",
+	}
+
+	// Append another data item, which must not be read.
+	buff := bytes.NewBuffer(append(append([]byte{}, data...), 0x07))
+	item, err := ReadItem(buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var walk func(item *Item)
+	var items []string
+	walk = func(item *Item) {
+		items = append(items, fmt.Sprintf("%d %v %v", item.Offset, item.Head, item.Value))
+		for _, child := range item.Items {
+			walk(child)
+		}
+	}
+	walk(item)
+
+	if !reflect.DeepEqual(items, expected) {
+		t.Fatalf("Items mismatch: %v != %v", items, expected)
+	} else if buff.Len() != 1 {
+		t.Fatalf("ReadItem left %d instead of 1 byte", buff.Len())
+	}
+}
+
+func TestReadItemError(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  error
+	}{
+		{[]byte{}, io.EOF},
+		{[]byte{0x82, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0x9F, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0xC1}, io.ErrUnexpectedEOF},
+		{[]byte{0xA1, 0x01}, io.ErrUnexpectedEOF},
+		{[]byte{0xFF}, nil},
+		{[]byte{0xBF, 0x01, 0xFF}, nil},
+		{[]byte{0x5F, 0x61, 0x61, 0xFF}, nil},
+	}
+
+	for _, test := range tests {
+		_, err := ReadItem(bytes.NewBuffer(test.data))
+		if err == nil {
+			t.Fatalf("Illegal input %x did not error", test.data)
+		} else if test.err != nil && err != test.err {
+			t.Fatalf("Input %x errored with %v instead of %v", test.data, err, test.err)
+		}
+	}
+
+	deep := append(bytes.Repeat([]byte{0x81}, MaxNestingDepth+2), 0x00)
+	if _, err := ReadItem(bytes.NewBuffer(deep)); err == nil {
+		t.Fatal("Exceeding the maximum nesting depth did not error")
+	}
+}